@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"sre-chat-api/internal"
 	"sre-chat-api/internal/config"
 	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/storage"
+	_ "time/tzdata" // reminder time zones must resolve even without system zoneinfo
 
 	"go.uber.org/zap"
 )
@@ -42,8 +47,48 @@ func main() {
 		}
 	}
 
-	// Set up router
-	router := internal.SetupRouter(logger)
+	// Set up attachment storage
+	if err := storage.Init(cfg); err != nil {
+		logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
+	}
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	retention := jobs.NewRetentionEnforcer(cfg.Retention, logger)
+	scheduler := jobs.NewScheduler(cfg.Scheduler, logger)
+	reminders := jobs.NewReminderRunner(cfg.Reminders, logger)
+	handoffs := jobs.NewHandoffAnnouncer(cfg.Oncall, logger)
+	polls := jobs.NewPollCloser(cfg.Polls, logger)
+
+	go jobs.NewPurger(cfg.Purge, logger).Run(ctx)
+	go retention.Run(ctx)
+	go jobs.NewWebhookDispatcher(cfg.Webhooks, logger).Run(ctx)
+
+	// Set up router and gRPC server
+	router, grpcServer := internal.SetupServers(logger, cfg, retention, scheduler, reminders, handoffs, polls)
+
+	// The router connects these jobs to SSE delivery, so start them afterwards
+	go scheduler.Run(ctx)
+	go reminders.Run(ctx)
+	go handoffs.Run(ctx)
+	go polls.Run(ctx)
+
+	// Start gRPC server
+	if cfg.GRPC.Enabled {
+		grpcAddr := fmt.Sprintf(":%s", cfg.GRPC.Port)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.Fatal("Failed to listen for gRPC", zap.Error(err))
+		}
+		logger.Info("Starting gRPC server", zap.String("address", grpcAddr))
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
 
 	// Start server
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
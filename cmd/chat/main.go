@@ -0,0 +1,78 @@
+// Command chat is a terminal client for the chat API, for hosts where the web
+// client cannot be reached. It logs in as a user, lists groups, tails one group's
+// event stream and sends, edits and deletes messages.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sre-chat-api/pkg/client"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	server := flag.String("server", envOr("CHAT_SERVER", "http://localhost:8080"), "chat API base URL (CHAT_SERVER)")
+	user := flag.String("user", os.Getenv("CHAT_USER"), "name to chat as; prompted for when empty (CHAT_USER)")
+	group := flag.String("group", os.Getenv("CHAT_GROUP"), "group to join on start, by ID or name (CHAT_GROUP)")
+	history := flag.Int("history", defaultHistory, "messages shown when joining a group")
+	noColor := flag.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable colored output (NO_COLOR)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	input := bufio.NewReader(os.Stdin)
+	interactive := isTerminal(os.Stdin) && isTerminal(os.Stdout)
+
+	name := strings.TrimSpace(*user)
+	for name == "" {
+		if !interactive {
+			fatalf("no user given: set -user or CHAT_USER")
+		}
+		fmt.Print("Name: ")
+		line, err := input.ReadString('\n')
+		if err != nil {
+			os.Exit(1)
+		}
+		name = strings.TrimSpace(line)
+	}
+
+	c, err := client.New(*server, client.WithUser(name))
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	out := newPrinter(os.Stdout, interactive, style{enabled: interactive && !*noColor, user: name})
+	s := newSession(c, out, *history)
+	if err := s.login(ctx, *group); err != nil {
+		fatalf("failed to log in to %s: %v", *server, err)
+	}
+
+	if err := s.run(ctx, input); err != nil && ctx.Err() == nil {
+		fatalf("%v", err)
+	}
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "chat: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sre-chat-api/pkg/client"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nameColors are the ANSI colors other users' names are shown in
+var nameColors = []string{"31", "32", "33", "34", "35", "36"}
+
+// style colors output with ANSI escapes when enabled
+type style struct {
+	enabled bool
+	// user is the logged in user, whose name is shown in bold
+	user string
+}
+
+func (s style) wrap(code, text string) string {
+	if !s.enabled {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+func (s style) dim(text string) string {
+	return s.wrap("2", text)
+}
+
+func (s style) bold(text string) string {
+	return s.wrap("1", text)
+}
+
+func (s style) red(text string) string {
+	return s.wrap("31", text)
+}
+
+// sanitize drops control characters other than newlines from text sent by the
+// server, so that names and content cannot carry escape sequences that move the
+// cursor, rewrite the screen, set the window title or write to the clipboard
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// name shows a user's name in a color that stays the same across messages
+func (s style) name(user string) string {
+	if user == s.user {
+		return s.bold(user)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(user))
+	return s.wrap(nameColors[h.Sum32()%uint32(len(nameColors))], user)
+}
+
+// formatMessage renders a message as "15:04 #12 alice: content", indenting the
+// further lines of multi-line content
+func formatMessage(m client.Message, s style) string {
+	var b strings.Builder
+	b.WriteString(s.dim(fmt.Sprintf("%s #%d", m.CreatedAt.Local().Format("15:04"), m.ID)))
+	b.WriteString(" ")
+	b.WriteString(s.name(sanitize(m.User)))
+	if m.Bot {
+		b.WriteString(s.dim(" [bot]"))
+	}
+	b.WriteString(": ")
+	b.WriteString(strings.ReplaceAll(strings.TrimRight(sanitize(m.Content), "\n"), "\n", "\n    "))
+
+	var notes []string
+	if m.UpdatedAt.Sub(m.CreatedAt) > time.Second {
+		notes = append(notes, "edited")
+	}
+	if n := len(m.Attachments); n == 1 {
+		notes = append(notes, "1 attachment")
+	} else if n > 1 {
+		notes = append(notes, fmt.Sprintf("%d attachments", n))
+	}
+	if m.Poll != nil {
+		notes = append(notes, fmt.Sprintf("poll, %d voted", m.Poll.TotalVoters))
+	}
+	if len(notes) > 0 {
+		b.WriteString(s.dim(" (" + strings.Join(notes, ", ") + ")"))
+	}
+	return b.String()
+}
+
+// printer writes lines to the terminal. Lines printed while the prompt is shown,
+// such as messages arriving on the stream, replace the prompt line and redraw the
+// prompt below them.
+type printer struct {
+	mu          sync.Mutex
+	w           io.Writer
+	interactive bool
+	style       style
+	prompt      string
+	prompting   bool
+}
+
+func newPrinter(w io.Writer, interactive bool, s style) *printer {
+	return &printer{w: w, interactive: interactive, style: s}
+}
+
+func (p *printer) println(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.prompting {
+		io.WriteString(p.w, "\r\033[K")
+	}
+	io.WriteString(p.w, line+"\n")
+	if p.prompting {
+		io.WriteString(p.w, p.prompt)
+	}
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	p.println(fmt.Sprintf(format, args...))
+}
+
+// errorf prints an error; errors can quote what the server sent, so they are
+// sanitized
+func (p *printer) errorf(format string, args ...interface{}) {
+	p.println(p.style.red("error: " + sanitize(fmt.Sprintf(format, args...))))
+}
+
+// showPrompt shows the prompt when reading from a terminal
+func (p *printer) showPrompt(prompt string) {
+	if !p.interactive {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prompt = prompt
+	p.prompting = true
+	io.WriteString(p.w, prompt)
+}
+
+// promptDone records that the user entered a line, leaving the prompt behind
+func (p *printer) promptDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompting = false
+}
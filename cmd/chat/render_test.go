@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sre-chat-api/pkg/client"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMessageStripsEscapes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	line := formatMessage(client.Message{
+		ID:        7,
+		User:      "mallory\x1b]0;owned\x07",
+		Content:   "\x1b]52;c;cm0gLXJmIH4K\x07clean\x1b[2J\u009b2J\nsecond\rline\x00",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, style{})
+
+	assert.NotContains(t, line, "\x1b")
+	assert.NotContains(t, line, "\x07")
+	assert.NotContains(t, line, "\u009b")
+	assert.NotContains(t, line, "\r")
+	assert.Equal(t, "12:00 #7 mallory]0;owned: ]52;c;cm0gLXJmIH4Kclean[2J2J\n    secondline", line)
+
+	// Colors added by the client itself are kept
+	colored := formatMessage(client.Message{ID: 7, User: "bob", Content: "\x1b[2Jhi", CreatedAt: now, UpdatedAt: now}, style{enabled: true})
+	assert.Equal(t, 1, strings.Count(colored, "\x1b[2m"))
+	assert.NotContains(t, colored, "\x1b[2J")
+}
+
+func TestPrinterSanitizesErrors(t *testing.T) {
+	out := &syncBuffer{}
+	newPrinter(out, false, style{}).errorf("server said %s", "\x1b]52;c;aGk=\x07no")
+	assert.Equal(t, "error: server said ]52;c;aGk=no\n", out.String())
+}
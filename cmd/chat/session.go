@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sre-chat-api/pkg/client"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHistory is how many messages are shown when joining a group
+const defaultHistory = 20
+
+// maxHistory bounds :history, matching the API's page limit
+const maxHistory = 1000
+
+// defaultGroup is joined on login unless another group is asked for
+const defaultGroup = "SRE Bootcamp"
+
+// errQuit ends the session
+var errQuit = errors.New("quit")
+
+const helpText = `Lines are sent to the current group; lines starting with / run slash commands.
+Commands:
+  :groups              list groups with unread counts        (:g)
+  :join <id|name>      switch to a group and tail it          (:j)
+  :history [n]         show the last n messages               (:h)
+  :edit <id> <text>    replace a message's content            (:e)
+  :delete <id>         delete a message                       (:d)
+  :help                show this help                         (:?)
+  :quit                leave                                  (:q)
+Start a line with :: to send a message beginning with ":".`
+
+// session is one logged in user's terminal chat
+type session struct {
+	client  *client.Client
+	out     *printer
+	history int
+
+	mu    sync.Mutex
+	group *client.GroupSummary
+	// lastID is the newest message shown of the current group
+	lastID   uint
+	stopTail context.CancelFunc
+}
+
+func newSession(c *client.Client, out *printer, history int) *session {
+	if history <= 0 || history > maxHistory {
+		history = defaultHistory
+	}
+	return &session{client: c, out: out, history: history}
+}
+
+// login checks that the API is reachable and joins the named group, or the
+// default group when name is empty
+func (s *session) login(ctx context.Context, name string) error {
+	groups, err := s.client.ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+	s.out.printf("Logged in as %s. Type :help for commands.", s.out.style.bold(s.client.User()))
+
+	if name != "" {
+		group, err := findGroup(groups, name)
+		if err != nil {
+			return err
+		}
+		s.join(ctx, group)
+		return nil
+	}
+	if group, err := findGroup(groups, defaultGroup); err == nil {
+		s.join(ctx, group)
+	} else if len(groups) > 0 {
+		s.join(ctx, groups[0])
+	} else {
+		s.out.println("There are no groups yet.")
+	}
+	return nil
+}
+
+// run reads and handles lines until the input ends, :quit or ctx is done
+func (s *session) run(ctx context.Context, input *bufio.Reader) error {
+	defer s.leave()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := input.ReadString('\n')
+			if line != "" {
+				lines <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		s.out.showPrompt(s.prompt())
+		var line string
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			line = l
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.out.promptDone()
+
+		if err := s.handle(ctx, line); errors.Is(err, errQuit) {
+			return nil
+		} else if err != nil {
+			s.out.errorf("%v", err)
+		}
+	}
+}
+
+func (s *session) prompt() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.group == nil {
+		return "> "
+	}
+	return s.out.style.bold(sanitize(s.group.Name)) + "> "
+}
+
+// handle runs a command or sends a message
+func (s *session) handle(ctx context.Context, line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if strings.HasPrefix(line, "::") {
+		return s.send(ctx, line[1:])
+	}
+	if !strings.HasPrefix(line, ":") {
+		return s.send(ctx, line)
+	}
+
+	name, arg := splitCommand(line[1:])
+	switch name {
+	case "groups", "g":
+		return s.listGroups(ctx)
+	case "join", "j":
+		if arg == "" {
+			return errors.New("usage: :join <id|name>")
+		}
+		groups, err := s.client.ListGroups(ctx)
+		if err != nil {
+			return err
+		}
+		group, err := findGroup(groups, arg)
+		if err != nil {
+			return err
+		}
+		s.join(ctx, group)
+		return nil
+	case "history", "h":
+		n := s.history
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n <= 0 || n > maxHistory {
+				return fmt.Errorf("usage: :history [1-%d]", maxHistory)
+			}
+		}
+		group, ok := s.currentGroup()
+		if !ok {
+			return errNoGroup
+		}
+		return s.showRecent(ctx, group.ID, n, false)
+	case "edit", "e":
+		idArg, content := splitCommand(arg)
+		messageID, err := parseMessageID(idArg)
+		if err != nil || strings.TrimSpace(content) == "" {
+			return errors.New("usage: :edit <id> <text>")
+		}
+		_, err = s.client.UpdateMessage(ctx, messageID, content)
+		return err
+	case "delete", "d":
+		messageID, err := parseMessageID(arg)
+		if err != nil {
+			return errors.New("usage: :delete <id>")
+		}
+		if err := s.client.DeleteMessage(ctx, messageID); err != nil {
+			return err
+		}
+		s.out.println(s.out.style.dim(fmt.Sprintf("Deleted #%d", messageID)))
+		return nil
+	case "help", "?":
+		s.out.println(helpText)
+		return nil
+	case "quit", "q":
+		return errQuit
+	}
+	return fmt.Errorf("unknown command :%s, :help lists commands", name)
+}
+
+var errNoGroup = errors.New("join a group first, :groups lists them")
+
+// send posts a message, or runs a slash command, in the current group. Posted
+// messages are shown when they come back on the stream.
+func (s *session) send(ctx context.Context, content string) error {
+	group, ok := s.currentGroup()
+	if !ok {
+		return errNoGroup
+	}
+
+	result, err := s.client.CreateMessage(ctx, client.CreateMessageRequest{
+		User:    s.client.User(),
+		GroupID: group.ID,
+		Content: content,
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case result.Command != nil:
+		s.out.println(s.out.style.dim("/"+sanitize(result.Command.Command)+": ") + sanitize(result.Command.Text))
+	case result.Scheduled != nil:
+		s.out.println(s.out.style.dim(fmt.Sprintf("Scheduled #%d for %s", result.Scheduled.ID, result.Scheduled.SendAt.Local().Format("Mon 15:04"))))
+	}
+	return nil
+}
+
+func (s *session) listGroups(ctx context.Context) error {
+	groups, err := s.client.ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		s.out.println("There are no groups yet.")
+		return nil
+	}
+
+	current, _ := s.currentGroup()
+	for _, group := range groups {
+		marker := " "
+		if group.ID == current.ID {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %4d  %s", marker, group.ID, sanitize(group.Name))
+		if group.UnreadCount > 0 {
+			line += s.out.style.bold(fmt.Sprintf("  (%d unread)", group.UnreadCount))
+		}
+		s.out.println(line)
+	}
+	return nil
+}
+
+// join makes group the current group and starts tailing it. Once the stream is
+// connected the group's recent messages are loaded.
+func (s *session) join(ctx context.Context, group client.GroupSummary) {
+	tailCtx, cancel := context.WithCancel(ctx)
+
+	s.mu.Lock()
+	if s.stopTail != nil {
+		s.stopTail()
+	}
+	s.group = &group
+	s.lastID = 0
+	s.stopTail = cancel
+	s.mu.Unlock()
+
+	s.out.println(s.out.style.bold("── " + sanitize(group.Name) + " ──"))
+	go func() {
+		err := s.client.Subscribe(tailCtx, client.StreamOptions{GroupID: group.ID}, func(event client.Event) error {
+			return s.handleEvent(tailCtx, group.ID, event)
+		})
+		if err != nil && tailCtx.Err() == nil {
+			s.out.errorf("stopped following %s: %v", group.Name, err)
+		}
+	}()
+}
+
+// leave stops tailing the current group
+func (s *session) leave() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopTail != nil {
+		s.stopTail()
+		s.stopTail = nil
+	}
+}
+
+func (s *session) currentGroup() (client.GroupSummary, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.group == nil {
+		return client.GroupSummary{}, false
+	}
+	return *s.group, true
+}
+
+// handleEvent shows an event of the tailed group
+func (s *session) handleEvent(ctx context.Context, groupID uint, event client.Event) error {
+	if current, ok := s.currentGroup(); !ok || current.ID != groupID {
+		return nil
+	}
+
+	switch event.Type {
+	case client.EventConnected, client.EventResync:
+		// The stream (re)started or events were missed: catch up from the API.
+		// Messages that are also replayed on the stream are only shown once.
+		if err := s.showRecent(ctx, groupID, s.history, true); err != nil && ctx.Err() == nil {
+			s.out.errorf("failed to load messages: %v", err)
+		}
+	case client.EventMessage:
+		if event.Message != nil && s.advance(event.Message.ID) {
+			s.out.println(formatMessage(*event.Message, s.out.style))
+			s.markRead(ctx, groupID, event.Message.ID)
+		}
+	case client.EventMessageUpdated:
+		if event.Message != nil {
+			s.out.println(s.out.style.dim("~ ") + formatMessage(*event.Message, s.out.style))
+		}
+	case client.EventMessageRestored:
+		if event.Message != nil {
+			s.out.println(s.out.style.dim("+ ") + formatMessage(*event.Message, s.out.style) + s.out.style.dim(" (restored)"))
+		}
+	case client.EventMessagePinned:
+		if event.Message != nil {
+			s.out.println(s.out.style.dim(fmt.Sprintf("Pinned #%d", event.Message.ID)))
+		}
+	}
+	return nil
+}
+
+// showRecent shows the group's last n messages. With onlyNew it skips those
+// already shown, as when catching up after a resync.
+func (s *session) showRecent(ctx context.Context, groupID uint, n int, onlyNew bool) error {
+	messages, err := s.client.ListMessages(ctx, client.MessageQuery{GroupID: groupID, Newest: true}, client.Page{Limit: n})
+	if err != nil {
+		return err
+	}
+	slices.Reverse(messages)
+
+	s.mu.Lock()
+	lastID := s.lastID
+	s.mu.Unlock()
+	if onlyNew && lastID != 0 && len(messages) == n && messages[0].ID > lastID+1 {
+		s.out.println(s.out.style.dim("… earlier messages skipped, :history <n> shows more"))
+	}
+
+	for _, message := range messages {
+		if onlyNew && message.ID <= lastID {
+			continue
+		}
+		s.advance(message.ID)
+		s.out.println(formatMessage(message, s.out.style))
+	}
+	if len(messages) > 0 {
+		s.markRead(ctx, groupID, messages[len(messages)-1].ID)
+	}
+	return nil
+}
+
+// advance records that a message was shown and reports whether it is newer than
+// those shown before, so messages loaded and then streamed are shown once
+func (s *session) advance(messageID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if messageID <= s.lastID {
+		return false
+	}
+	s.lastID = messageID
+	return true
+}
+
+// markRead moves the user's read position so other clients' unread counts agree
+// with what was shown here
+func (s *session) markRead(ctx context.Context, groupID, messageID uint) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	s.client.MarkRead(ctx, groupID, messageID)
+}
+
+// findGroup finds a group by ID or, ignoring case, by name
+func findGroup(groups []client.GroupSummary, key string) (client.GroupSummary, error) {
+	key = strings.TrimPrefix(strings.TrimSpace(key), "#")
+	if id, err := strconv.ParseUint(key, 10, 32); err == nil {
+		for _, group := range groups {
+			if group.ID == uint(id) {
+				return group, nil
+			}
+		}
+	}
+	for _, group := range groups {
+		if strings.EqualFold(group.Name, key) {
+			return group, nil
+		}
+	}
+	return client.GroupSummary{}, fmt.Errorf("no group %q, :groups lists them", key)
+}
+
+// splitCommand splits a line into its first word and the rest
+func splitCommand(line string) (name, arg string) {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		return line[:i], strings.TrimSpace(line[i+1:])
+	}
+	return line, ""
+}
+
+// parseMessageID parses a message ID, with or without its leading "#"
+func parseMessageID(arg string) (uint, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(arg, "#"), 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid message ID %q", arg)
+	}
+	return uint(id), nil
+}
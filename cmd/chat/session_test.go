@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/pkg/client"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI serves the endpoints the terminal client uses from memory
+type fakeAPI struct {
+	mu       sync.Mutex
+	messages []client.Message
+	requests []string
+	stream   chan client.Message
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/api/v1/groups":
+		json.NewEncoder(w).Encode([]client.GroupSummary{
+			{Group: client.Group{ID: 1, Name: "SRE Bootcamp"}},
+			{Group: client.Group{ID: 2, Name: "Incidents"}, UnreadCount: 3},
+		})
+	case r.URL.Path == "/api/v1/messages/stream":
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"type\": \"connected\"}\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case message := <-f.stream:
+				data, _ := json.Marshal(map[string]interface{}{"type": "message", "message": message})
+				fmt.Fprintf(w, "id: 1-%d\ndata: %s\n\n", message.ID, data)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	case r.URL.Path == "/api/v1/messages" && r.Method == http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var newest []client.Message
+		for i := len(f.messages) - 1; i >= 0; i-- {
+			if fmt.Sprint(f.messages[i].GroupID) == r.URL.Query().Get("group_id") {
+				newest = append(newest, f.messages[i])
+			}
+		}
+		json.NewEncoder(w).Encode(newest)
+	case r.URL.Path == "/api/v1/messages" && r.Method == http.MethodPost:
+		var req client.CreateMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasPrefix(req.Content, "/") {
+			json.NewEncoder(w).Encode(client.CommandResponse{Command: "help", Text: "Available commands", Ephemeral: true})
+			return
+		}
+		f.mu.Lock()
+		message := client.Message{ID: uint(len(f.messages) + 1), GroupID: req.GroupID, User: req.User, Content: req.Content, CreatedAt: time.Now()}
+		message.UpdatedAt = message.CreatedAt
+		f.messages = append(f.messages, message)
+		f.mu.Unlock()
+		f.stream <- message
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(message)
+	default:
+		io.WriteString(w, "{}")
+	}
+}
+
+func (f *fakeAPI) requested(request string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.requests {
+		if r == request {
+			return true
+		}
+	}
+	return false
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSession(t *testing.T) {
+	now := time.Now()
+	api := &fakeAPI{
+		messages: []client.Message{{ID: 1, GroupID: 1, User: "bob", Content: "hello", CreatedAt: now, UpdatedAt: now}},
+		stream:   make(chan client.Message, 10),
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithUser("alice"))
+	require.NoError(t, err)
+	out := &syncBuffer{}
+	s := newSession(c, newPrinter(out, false, style{user: "alice"}), 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.login(ctx, ""))
+
+	input, lines := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- s.run(ctx, bufio.NewReader(input))
+	}()
+	shows := func(text string) {
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), text) }, 5*time.Second, 10*time.Millisecond, "output lacks %q:\n%s", text, out.String())
+	}
+	enter := func(line string) {
+		_, err := io.WriteString(lines, line+"\n")
+		require.NoError(t, err)
+	}
+
+	// Joining the default group shows its history
+	shows("── SRE Bootcamp ──")
+	shows("#1 bob: hello")
+
+	// Sent messages show up when they come back on the stream, once
+	enter("hi there")
+	shows("#2 alice: hi there")
+	enter("::not a command")
+	shows("#3 alice: :not a command")
+
+	enter("/help")
+	shows("/help: Available commands")
+
+	enter(":edit #2 hi again")
+	assert.Eventually(t, func() bool { return api.requested("PUT /api/v1/messages/2") }, 5*time.Second, 10*time.Millisecond)
+	enter(":delete 2")
+	shows("Deleted #2")
+
+	enter(":groups")
+	shows("*    1  SRE Bootcamp")
+	shows("     2  Incidents  (3 unread)")
+
+	enter(":edit 2")
+	shows("error: usage: :edit <id> <text>")
+	enter(":nope")
+	shows("error: unknown command :nope")
+
+	enter(":join incidents")
+	shows("── Incidents ──")
+
+	enter(":q")
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, strings.Count(out.String(), "hi there"))
+	assert.True(t, api.requested("PUT /api/v1/groups/1/read"))
+}
+
+func TestFindGroup(t *testing.T) {
+	groups := []client.GroupSummary{
+		{Group: client.Group{ID: 1, Name: "SRE Bootcamp"}},
+		{Group: client.Group{ID: 7, Name: "42"}},
+	}
+
+	for key, want := range map[string]uint{"1": 1, "#7": 7, "sre bootcamp": 1, "42": 7} {
+		group, err := findGroup(groups, key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, group.ID, key)
+	}
+	_, err := findGroup(groups, "ops")
+	assert.Error(t, err)
+}
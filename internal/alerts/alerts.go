@@ -0,0 +1,174 @@
+// Package alerts renders Alertmanager webhook notifications as chat messages.
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notification statuses sent by Alertmanager
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// maxListedAlerts limits how many alerts of a group are listed in one message
+const maxListedAlerts = 10
+
+// Notification is the JSON body Alertmanager POSTs to webhook receivers
+type Notification struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey" binding:"required"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status" binding:"required,oneof=firing resolved"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is one alert of a notification
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Acknowledgement records who acknowledged an alert and when
+type Acknowledgement struct {
+	User string
+	At   time.Time
+}
+
+// Title names the alert group by its alertname label
+func (n Notification) Title() string {
+	if name := n.GroupLabels["alertname"]; name != "" {
+		return name
+	}
+	if name := n.CommonLabels["alertname"]; name != "" {
+		return name
+	}
+	return "Alert"
+}
+
+// Firing returns how many alerts of the notification are firing
+func (n Notification) Firing() int {
+	count := 0
+	for _, alert := range n.Alerts {
+		if alert.Status == StatusFiring {
+			count++
+		}
+	}
+	return count
+}
+
+// Render formats a notification as message content. id is the chat's alert ID
+// used to acknowledge it, and ack is nil while the alert is unacknowledged.
+func Render(n Notification, id uint, ack *Acknowledgement) string {
+	var b strings.Builder
+
+	if n.Status == StatusResolved {
+		fmt.Fprintf(&b, "✅ [RESOLVED] %s", n.Title())
+	} else {
+		fmt.Fprintf(&b, "🔥 [FIRING:%d] %s", n.Firing(), n.Title())
+	}
+	if labels := formatLabels(n.GroupLabels, "alertname"); labels != "" {
+		fmt.Fprintf(&b, " (%s)", labels)
+	}
+	b.WriteString("\n")
+
+	if summary := firstNonEmpty(n.CommonAnnotations["summary"], n.CommonAnnotations["description"]); summary != "" {
+		b.WriteString(summary)
+		b.WriteString("\n")
+	}
+
+	for i, alert := range n.Alerts {
+		if i == maxListedAlerts {
+			fmt.Fprintf(&b, "…and %d more\n", len(n.Alerts)-maxListedAlerts)
+			break
+		}
+		b.WriteString(renderAlert(n, alert))
+		b.WriteString("\n")
+	}
+	if n.TruncatedAlerts > 0 {
+		fmt.Fprintf(&b, "…and %d more not sent by Alertmanager\n", n.TruncatedAlerts)
+	}
+
+	switch {
+	case ack != nil:
+		fmt.Fprintf(&b, "Acknowledged by %s at %s", ack.User, ack.At.UTC().Format("2006-01-02 15:04 MST"))
+	case n.Status == StatusFiring:
+		fmt.Fprintf(&b, "Acknowledge with /ack %d", id)
+	default:
+		return strings.TrimRight(b.String(), "\n")
+	}
+	return b.String()
+}
+
+// renderAlert formats one alert as a bullet, showing the labels and annotations
+// that are not already shared by the whole group
+func renderAlert(n Notification, alert Alert) string {
+	text := firstNonEmpty(alert.Annotations["summary"], alert.Annotations["description"], alert.Labels["alertname"])
+	if text == n.CommonAnnotations["summary"] || text == n.CommonAnnotations["description"] {
+		text = alert.Labels["alertname"]
+	}
+
+	line := "• "
+	if alert.Status == StatusResolved && n.Status == StatusFiring {
+		line += "[resolved] "
+	}
+	line += text
+
+	distinct := make(map[string]string)
+	for name, value := range alert.Labels {
+		if _, common := n.CommonLabels[name]; !common {
+			distinct[name] = value
+		}
+	}
+	if labels := formatLabels(distinct); labels != "" {
+		line += " (" + labels + ")"
+	}
+	if alert.GeneratorURL != "" {
+		line += " " + alert.GeneratorURL
+	}
+	return line
+}
+
+// formatLabels renders labels as name=value pairs sorted by name, skipping the
+// excluded names
+func formatLabels(labels map[string]string, exclude ...string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		skip := false
+		for _, excluded := range exclude {
+			skip = skip || name == excluded
+		}
+		if !skip {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + labels[name]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNotification(status string) Notification {
+	return Notification{
+		GroupKey:          `{}:{alertname="HighLatency"}`,
+		Status:            status,
+		GroupLabels:       map[string]string{"alertname": "HighLatency", "service": "checkout"},
+		CommonLabels:      map[string]string{"alertname": "HighLatency", "service": "checkout"},
+		CommonAnnotations: map[string]string{"summary": "p99 latency above 2s"},
+		Alerts: []Alert{
+			{Status: status, Labels: map[string]string{"alertname": "HighLatency", "service": "checkout", "instance": "web-1"}, GeneratorURL: "http://prometheus/graph"},
+			{Status: StatusResolved, Labels: map[string]string{"alertname": "HighLatency", "service": "checkout", "instance": "web-2"}},
+		},
+	}
+}
+
+func TestRenderFiring(t *testing.T) {
+	content := Render(testNotification(StatusFiring), 7, nil)
+	lines := strings.Split(content, "\n")
+
+	assert.Equal(t, "🔥 [FIRING:1] HighLatency (service=checkout)", lines[0])
+	assert.Equal(t, "p99 latency above 2s", lines[1])
+	assert.Equal(t, "• HighLatency (instance=web-1) http://prometheus/graph", lines[2])
+	assert.Equal(t, "• [resolved] HighLatency (instance=web-2)", lines[3])
+	assert.Equal(t, "Acknowledge with /ack 7", lines[4])
+}
+
+func TestRenderAcknowledgedAndResolved(t *testing.T) {
+	ack := &Acknowledgement{User: "alice", At: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)}
+	content := Render(testNotification(StatusFiring), 7, ack)
+	assert.True(t, strings.HasSuffix(content, "Acknowledged by alice at 2024-03-01 12:30 UTC"))
+	assert.NotContains(t, content, "/ack")
+
+	content = Render(testNotification(StatusResolved), 7, nil)
+	assert.True(t, strings.HasPrefix(content, "✅ [RESOLVED] HighLatency"))
+	assert.NotContains(t, content, "/ack")
+	assert.NotContains(t, content, "[resolved]")
+	assert.False(t, strings.HasSuffix(content, "\n"))
+}
+
+func TestRenderTruncates(t *testing.T) {
+	n := testNotification(StatusFiring)
+	n.Alerts = nil
+	for i := 0; i < maxListedAlerts+3; i++ {
+		n.Alerts = append(n.Alerts, Alert{Status: StatusFiring, Labels: map[string]string{"alertname": "HighLatency"}})
+	}
+	n.TruncatedAlerts = 5
+
+	content := Render(n, 1, nil)
+	assert.Contains(t, content, "[FIRING:13]")
+	assert.Contains(t, content, "…and 3 more\n")
+	assert.Contains(t, content, "…and 5 more not sent by Alertmanager\n")
+}
+
+func TestTitle(t *testing.T) {
+	assert.Equal(t, "HighLatency", testNotification(StatusFiring).Title())
+	assert.Equal(t, "Alert", Notification{}.Title())
+}
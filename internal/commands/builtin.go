@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"fmt"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	statusPrefix = "Status: "
+
+	// maxReminderNameLength bounds reminder names derived from their text, in characters
+	maxReminderNameLength = 60
+)
+
+func init() {
+	Register(Command{
+		Name:        "help",
+		Usage:       "/help [command]",
+		Description: "List commands or show how to use one",
+		Run:         runHelp,
+	})
+	Register(Command{
+		Name:        "status",
+		Usage:       "/status [update]",
+		Description: "Post a status update, or show the latest one",
+		Run:         runStatus,
+	})
+	Register(Command{
+		Name:        "topic",
+		Usage:       "/topic [new topic]",
+		Description: "Set the group's topic, or show it",
+		Run:         runTopic,
+	})
+	Register(Command{
+		Name:        "remind",
+		Usage:       `/remind in <duration> <text> | /remind "<cron>" <text>`,
+		Description: "Post a message later, or on a recurring schedule",
+		Run:         runRemind,
+	})
+}
+
+// runHelp lists every command, or describes one
+func runHelp(ctx *Context) (string, error) {
+	if len(ctx.Args) > 0 {
+		cmd, ok := ctx.Registry.Lookup(strings.TrimPrefix(ctx.Args[0], "/"))
+		if !ok {
+			return fmt.Sprintf("Unknown command /%s.", strings.TrimPrefix(ctx.Args[0], "/")), nil
+		}
+		return fmt.Sprintf("%s\n%s", cmd.Usage, cmd.Description), nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Available commands:")
+	for _, cmd := range ctx.Registry.List() {
+		fmt.Fprintf(&b, "\n%s - %s", cmd.Usage, cmd.Description)
+	}
+	return b.String(), nil
+}
+
+// runStatus posts a status update to the group or shows the latest one
+func runStatus(ctx *Context) (string, error) {
+	if ctx.Text != "" {
+		if _, err := ctx.Post(statusPrefix + ctx.Text); err != nil {
+			return "", err
+		}
+		return "Status posted.", nil
+	}
+
+	var latest models.Message
+	err := database.DB.Where("group_id = ? AND content LIKE ?", ctx.GroupID, statusPrefix+"%").
+		Order("created_at DESC").
+		Limit(1).
+		Find(&latest).Error
+	if err != nil {
+		return "", err
+	}
+	if latest.ID == 0 {
+		return "No status has been posted in this group.", nil
+	}
+	return fmt.Sprintf("%s (by %s at %s)", latest.Content, latest.User, latest.CreatedAt.UTC().Format(time.RFC3339)), nil
+}
+
+// runTopic sets or shows the group's topic, which is its description
+func runTopic(ctx *Context) (string, error) {
+	var group models.Group
+	if err := database.DB.First(&group, ctx.GroupID).Error; err != nil {
+		return "", err
+	}
+
+	if ctx.Text == "" {
+		if group.Description == "" {
+			return "This group has no topic.", nil
+		}
+		return "Topic: " + group.Description, nil
+	}
+
+	if err := database.DB.Model(&group).Update("description", ctx.Text).Error; err != nil {
+		return "", err
+	}
+	if _, err := ctx.Post("changed the topic to: " + ctx.Text); err != nil {
+		return "", err
+	}
+	return "Topic updated.", nil
+}
+
+// runRemind schedules a one-off message or creates a recurring reminder
+func runRemind(ctx *Context) (string, error) {
+	if len(ctx.Args) < 2 {
+		return "", ErrUsage
+	}
+
+	if strings.EqualFold(ctx.Args[0], "in") {
+		if len(ctx.Args) < 3 {
+			return "", ErrUsage
+		}
+		delay, err := parseDelay(ctx.Args[1])
+		if err != nil || delay <= 0 {
+			return "", ErrUsage
+		}
+
+		scheduled, err := ctx.Schedule(strings.Join(ctx.Args[2:], " "), time.Now().Add(delay))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("I'll post that at %s.", scheduled.SendAt.Format(time.RFC3339)), nil
+	}
+
+	schedule, content := ctx.Args[0], strings.Join(ctx.Args[1:], " ")
+	name := content
+	if runes := []rune(name); len(runes) > maxReminderNameLength {
+		name = string(runes[:maxReminderNameLength])
+	}
+	reminder, err := ctx.Remind(models.ReminderRequest{
+		Name:     name,
+		Schedule: schedule,
+		User:     ctx.User,
+		Content:  content,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Reminder %d created; it next runs at %s.", reminder.ID, reminder.NextRunAt.Format(time.RFC3339)), nil
+}
+
+// parseDelay parses a Go duration, also accepting whole days such as "2d"
+func parseDelay(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,214 @@
+// Package commands dispatches slash commands typed into chat messages to
+// registered handlers. Other packages add commands to the Default registry with
+// Register, typically from an init function.
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sre-chat-api/internal/models"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrUsage is returned by a command whose arguments are invalid; the caller is
+// shown the command's usage
+var ErrUsage = errors.New("invalid arguments")
+
+// Context carries a command invocation
+type Context struct {
+	context.Context
+
+	// User is the caller and GroupID the group the command was typed in
+	User    string
+	GroupID uint
+
+	// Args are the arguments split on whitespace, honoring quotes, and Text is
+	// everything after the command name as typed
+	Args []string
+	Text string
+
+	// Post publishes a message to the group as the caller
+	Post func(content string) (models.Message, error)
+
+	// PostAs publishes a message to any group under another name, for
+	// announcements made on behalf of the system
+	PostAs func(groupID uint, user, content string) (models.Message, error)
+
+	// Announce notifies clients that a command changed an existing message
+	Announce func(message models.Message)
+
+	// Deliver notifies clients about a new message a command stored itself
+	Deliver func(message models.Message)
+
+	// Schedule posts a message to the group as the caller at sendAt, validated
+	// like a message sent with send_at
+	Schedule func(content string, sendAt time.Time) (models.ScheduledMessage, error)
+
+	// Remind creates a recurring reminder in the group, validated and started like
+	// one created through the reminders API
+	Remind func(req models.ReminderRequest) (models.Reminder, error)
+
+	// Registry is the registry the command was dispatched from
+	Registry *Registry
+}
+
+// Command is a slash command
+type Command struct {
+	// Name is the command without the leading slash
+	Name string
+	// Usage shows the arguments, e.g. "/topic [new topic]"
+	Usage string
+	// Description is a one-line summary shown by /help
+	Description string
+	// Run executes the command and returns the reply shown only to the caller
+	Run func(ctx *Context) (string, error)
+}
+
+// Registry holds the available commands
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]Command)}
+}
+
+// Default is the registry used by the API; the built-in commands register themselves here
+var Default = NewRegistry()
+
+// Register adds a command to the Default registry, panicking if the name is taken
+func Register(cmd Command) {
+	if err := Default.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds a command to the registry
+func (r *Registry) Register(cmd Command) error {
+	name := strings.ToLower(cmd.Name)
+	if name == "" || strings.ContainsAny(name, " /") {
+		return fmt.Errorf("invalid command name %q", cmd.Name)
+	}
+	if cmd.Run == nil {
+		return fmt.Errorf("command /%s has no Run function", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.commands[name]; exists {
+		return fmt.Errorf("command /%s is already registered", name)
+	}
+	cmd.Name = name
+	if cmd.Usage == "" {
+		cmd.Usage = "/" + name
+	}
+	r.commands[name] = cmd
+	return nil
+}
+
+// Lookup returns the named command
+func (r *Registry) Lookup(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmd, ok := r.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// List returns all commands sorted by name
+func (r *Registry) List() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		list = append(list, cmd)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// IsCommand reports whether content invokes a slash command. A leading "//"
+// escapes the slash so the message is posted as typed.
+func IsCommand(content string) bool {
+	return strings.HasPrefix(content, "/") && !strings.HasPrefix(content, "//")
+}
+
+// Dispatch runs the command in content and returns its name and reply. Errors,
+// including unknown commands and bad arguments, are turned into replies for the caller.
+func (r *Registry) Dispatch(ctx *Context, content string) (string, string) {
+	name, text := strings.TrimPrefix(strings.TrimSpace(content), "/"), ""
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, text = name[:i], name[i:]
+	}
+	name = strings.ToLower(name)
+
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return name, fmt.Sprintf("Unknown command /%s. Type /help for a list of commands.", name)
+	}
+
+	args, err := SplitArgs(text)
+	if err != nil {
+		return name, fmt.Sprintf("%v\nUsage: %s", err, cmd.Usage)
+	}
+	ctx.Args = args
+	ctx.Text = strings.TrimSpace(text)
+	ctx.Registry = r
+
+	reply, err := cmd.Run(ctx)
+	if errors.Is(err, ErrUsage) {
+		return name, "Usage: " + cmd.Usage
+	}
+	if err != nil {
+		return name, fmt.Sprintf("/%s failed: %v", name, err)
+	}
+	return name, reply
+}
+
+// SplitArgs splits text on whitespace, keeping single- or double-quoted sections together
+func SplitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range text {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"errors"
+	"sre-chat-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	args, err := SplitArgs(`  "0 9 * * MON-FRI" post the 'on-call checklist' `)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0 9 * * MON-FRI", "post", "the", "on-call checklist"}, args)
+
+	_, err = SplitArgs(`"unterminated`)
+	assert.Error(t, err)
+}
+
+func TestIsCommand(t *testing.T) {
+	assert.True(t, IsCommand("/status all good"))
+	assert.False(t, IsCommand("//not a command"))
+	assert.False(t, IsCommand("status"))
+}
+
+func TestRegistryDispatch(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(Command{
+		Name:        "Echo",
+		Usage:       "/echo <text>",
+		Description: "Repeat the text",
+		Run: func(ctx *Context) (string, error) {
+			if len(ctx.Args) == 0 {
+				return "", ErrUsage
+			}
+			return ctx.User + ": " + ctx.Text, nil
+		},
+	}))
+	assert.Error(t, registry.Register(Command{Name: "echo", Run: func(*Context) (string, error) { return "", nil }}))
+	assert.Error(t, registry.Register(Command{Name: "bad name", Run: func(*Context) (string, error) { return "", nil }}))
+
+	name, reply := registry.Dispatch(&Context{User: "alice"}, "/ECHO  hello   world")
+	assert.Equal(t, "echo", name)
+	assert.Equal(t, "alice: hello   world", reply)
+
+	_, reply = registry.Dispatch(&Context{User: "alice"}, "/echo")
+	assert.Equal(t, "Usage: /echo <text>", reply)
+
+	name, reply = registry.Dispatch(&Context{User: "alice"}, "/nope")
+	assert.Equal(t, "nope", name)
+	assert.Contains(t, reply, "Unknown command /nope")
+}
+
+func TestDefaultCommands(t *testing.T) {
+	for _, name := range []string{"help", "status", "topic", "remind"} {
+		_, ok := Default.Lookup(name)
+		assert.True(t, ok, name)
+	}
+
+	_, reply := Default.Dispatch(&Context{}, "/help")
+	assert.Contains(t, reply, "/topic [new topic]")
+
+	_, reply = Default.Dispatch(&Context{}, "/remind in")
+	assert.Contains(t, reply, "Usage: /remind")
+}
+
+func TestRemindUsesContext(t *testing.T) {
+	var scheduled models.ScheduledMessage
+	var requested models.ReminderRequest
+	ctx := &Context{
+		User:    "alice",
+		GroupID: 3,
+		Schedule: func(content string, sendAt time.Time) (models.ScheduledMessage, error) {
+			scheduled = models.ScheduledMessage{User: "alice", Content: content, SendAt: sendAt}
+			return scheduled, nil
+		},
+		Remind: func(req models.ReminderRequest) (models.Reminder, error) {
+			requested = req
+			if req.Schedule == "nonsense" {
+				return models.Reminder{}, errors.New("invalid schedule")
+			}
+			next := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+			return models.Reminder{ID: 4, NextRunAt: &next}, nil
+		},
+	}
+
+	before := time.Now()
+	_, reply := Default.Dispatch(ctx, "/remind in 2d check the backups")
+	assert.Equal(t, "check the backups", scheduled.Content)
+	assert.WithinDuration(t, before.Add(48*time.Hour), scheduled.SendAt, time.Minute)
+	assert.Contains(t, reply, "I'll post that at")
+
+	// Recurring reminders leave the time zone to the shared default
+	_, reply = Default.Dispatch(ctx, `/remind "0 9 * * MON" weekly review`)
+	assert.Equal(t, models.ReminderRequest{Name: "weekly review", Schedule: "0 9 * * MON", User: "alice", Content: "weekly review"}, requested)
+	assert.Equal(t, "Reminder 4 created; it next runs at 2026-01-05T09:00:00Z.", reply)
+
+	_, reply = Default.Dispatch(ctx, "/remind nonsense hi")
+	assert.Equal(t, "/remind failed: invalid schedule", reply)
+}
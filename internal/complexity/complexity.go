@@ -0,0 +1,238 @@
+// Package complexity estimates how deep and how expensive a GraphQL operation is
+// before it runs, so that operations exceeding configured limits can be rejected.
+package complexity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/ast"
+)
+
+// maxComplexity caps estimates so that huge page sizes cannot overflow them
+const maxComplexity = 1 << 30
+
+// Cost is the estimated size of an operation
+type Cost struct {
+	// Operation is the type of the operation: query, mutation or subscription
+	Operation string
+	// Depth is how deeply its fields nest; top-level fields have depth 1
+	Depth int
+	// Complexity is the number of fields it is expected to resolve
+	Complexity int
+}
+
+// Measure estimates the cost of the operation named operationName, or the only
+// operation of the query when the name is empty.
+//
+// Each field counts once per item it is resolved for. A field with a first
+// argument multiplies the cost of its selections by it, and list fields directly
+// below it are bounded by that page. Other lists of objects are assumed to hold
+// listSize items. Introspection fields count once and do not add depth.
+func Measure(schema *ast.Schema, query, operationName string, variables map[string]any, listSize int) (Cost, error) {
+	doc, err := parse(query)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	var op *operation
+	for _, candidate := range doc.operations {
+		if candidate.name == operationName || (operationName == "" && len(doc.operations) == 1) {
+			op = candidate
+			break
+		}
+	}
+	if op == nil {
+		if operationName == "" {
+			return Cost{}, fmt.Errorf("more than one operation in query, operationName is required")
+		}
+		return Cost{}, fmt.Errorf("no operation named %q", operationName)
+	}
+
+	vars := make(map[string]any, len(op.defaults)+len(variables))
+	for name, value := range op.defaults {
+		vars[name] = value
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+
+	w := walker{
+		schema:    schema,
+		fragments: doc.fragments,
+		variables: vars,
+		listSize:  listSize,
+		visiting:  make(map[string]bool),
+		measured:  make(map[fragmentKey]measure),
+	}
+	depth, complexity := w.selections(op.selections, schema.RootOperationTypes[op.kind], false)
+	return Cost{Operation: op.kind, Depth: depth, Complexity: complexity}, nil
+}
+
+// walker measures selections against the schema
+type walker struct {
+	schema    *ast.Schema
+	fragments map[string]*fragment
+	variables map[string]any
+	listSize  int
+	// visiting holds the fragments being measured, to stop at cycles
+	visiting map[string]bool
+	// measured holds the cost of fragments already measured, so that fragments
+	// spread many times are walked once
+	measured map[fragmentKey]measure
+}
+
+// fragmentKey identifies a fragment measured on a type, inside a page or not
+type fragmentKey struct {
+	name  string
+	typ   string
+	paged bool
+}
+
+// measure is the depth and complexity of a measured fragment
+type measure struct {
+	depth, complexity int
+}
+
+// selections returns the depth and complexity of a selection set on typ. paged is
+// set when the selections belong to a field with a first argument.
+func (w *walker) selections(sels []*selection, typ ast.NamedType, paged bool) (depth, complexity int) {
+	for _, sel := range sels {
+		var d, c int
+		switch {
+		case sel.spread != "":
+			d, c = w.spread(sel.spread, typ, paged)
+		case sel.name == "":
+			d, c = w.selections(sel.selections, w.typeOf(sel.typeCondition, typ), paged)
+		case strings.HasPrefix(sel.name, "__"):
+			if sel.name != "__typename" {
+				c = 1
+			}
+		default:
+			d, c = w.field(sel, typ, paged)
+		}
+		depth = max(depth, d)
+		complexity = min(complexity+c, maxComplexity)
+	}
+	return depth, complexity
+}
+
+// spread returns the depth and complexity of a fragment spread on typ
+func (w *walker) spread(name string, typ ast.NamedType, paged bool) (depth, complexity int) {
+	frag, ok := w.fragments[name]
+	if !ok || w.visiting[name] {
+		return 0, 0
+	}
+	fragType := w.typeOf(frag.typeCondition, typ)
+	key := fragmentKey{name: name, paged: paged}
+	if fragType != nil {
+		key.typ = fragType.TypeName()
+	}
+	if m, ok := w.measured[key]; ok {
+		return m.depth, m.complexity
+	}
+
+	w.visiting[name] = true
+	depth, complexity = w.selections(frag.selections, fragType, paged)
+	delete(w.visiting, name)
+	w.measured[key] = measure{depth: depth, complexity: complexity}
+	return depth, complexity
+}
+
+// field returns the depth and complexity of one field of typ
+func (w *walker) field(sel *selection, typ ast.NamedType, paged bool) (depth, complexity int) {
+	def := fieldDefinition(typ, sel.name)
+	var child ast.NamedType
+	list := false
+	if def != nil {
+		child, list = unwrap(def.Type)
+	}
+
+	multiplier, childPaged := 1, false
+	if first, ok := w.first(sel, def); ok {
+		multiplier, childPaged = max(first, 0), true
+	} else if list && !paged && len(sel.selections) > 0 {
+		multiplier = w.listSize
+	}
+
+	d, c := w.selections(sel.selections, child, childPaged)
+	return d + 1, min(1+multiplier*c, maxComplexity)
+}
+
+// first returns the page size a field was asked for, falling back to the default
+// of its first argument
+func (w *walker) first(sel *selection, def *ast.FieldDefinition) (int, bool) {
+	if value, ok := sel.args["first"]; ok {
+		if name, ok := value.(variable); ok {
+			if v, ok := w.variables[string(name)]; ok {
+				return number(v)
+			}
+		} else if n, ok := number(value); ok {
+			return n, true
+		}
+	}
+	if def == nil {
+		return 0, false
+	}
+	arg := def.Arguments.Get("first")
+	if arg == nil {
+		return 0, false
+	}
+	if arg.Default == nil {
+		return w.listSize, true
+	}
+	return number(arg.Default.Deserialize(nil))
+}
+
+// typeOf returns the named type, or fallback for inline fragments without a type
+// condition
+func (w *walker) typeOf(name string, fallback ast.NamedType) ast.NamedType {
+	if name == "" {
+		return fallback
+	}
+	return w.schema.Types[name]
+}
+
+// fieldDefinition looks up a field of an object or interface type
+func fieldDefinition(typ ast.NamedType, name string) *ast.FieldDefinition {
+	switch t := typ.(type) {
+	case *ast.ObjectTypeDefinition:
+		return t.Fields.Get(name)
+	case *ast.InterfaceTypeDefinition:
+		return t.Fields.Get(name)
+	}
+	return nil
+}
+
+// unwrap returns the named type of a field type and whether it is a list
+func unwrap(typ ast.Type) (ast.NamedType, bool) {
+	list := false
+	for {
+		switch t := typ.(type) {
+		case *ast.NonNull:
+			typ = t.OfType
+		case *ast.List:
+			list = true
+			typ = t.OfType
+		case ast.NamedType:
+			return t, list
+		default:
+			return nil, list
+		}
+	}
+}
+
+// number converts an integer argument or variable value
+func number(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(min(v, maxComplexity)), true
+	case float64:
+		return int(min(v, maxComplexity)), true
+	}
+	return 0, false
+}
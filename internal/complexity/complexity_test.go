@@ -0,0 +1,143 @@
+package complexity
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `
+type Query {
+	groups: [Group!]!
+	messages(first: Int = 50, after: ID): MessageConnection!
+}
+
+type Group {
+	id: ID!
+	name: String!
+	messages(first: Int): MessageConnection!
+}
+
+type MessageConnection {
+	nodes: [Message!]!
+	hasNextPage: Boolean!
+}
+
+type Message {
+	id: ID!
+	content: String!
+	tags: [String!]!
+	reactions: [Reaction!]!
+}
+
+type Reaction {
+	emoji: String!
+	count: Int!
+}
+`
+
+func TestMeasure(t *testing.T) {
+	schema := graphql.MustParseSchema(testSchema, nil).AST()
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      Cost
+	}{
+		{"scalars", `{ groups { id name } }`, nil, Cost{Depth: 2, Complexity: 1 + 10*2}},
+		// Pages multiply their selections; the list of a page is bounded by it
+		{"page", `{ messages(first: 5) { nodes { id content } hasNextPage } }`, nil, Cost{Depth: 3, Complexity: 1 + 5*(1+2+1)}},
+		{"default page size", `{ messages { nodes { id } } }`, nil, Cost{Depth: 3, Complexity: 1 + 50*2}},
+		{"page size without default", `{ groups { messages { nodes { id } } } }`, nil, Cost{Depth: 4, Complexity: 1 + 10*(1+10*2)}},
+		{"variable", `query Q($n: Int) { messages(first: $n) { nodes { id } } }`, map[string]any{"n": float64(3)}, Cost{Depth: 3, Complexity: 1 + 3*2}},
+		{"variable default", `query Q($n: Int = 2) { messages(first: $n) { nodes { id } } }`, nil, Cost{Depth: 3, Complexity: 1 + 2*2}},
+		// Lists below pages multiply again; lists of scalars count once
+		{"nested list", `{ messages(first: 2) { nodes { tags reactions { emoji count } } } }`, nil, Cost{Depth: 4, Complexity: 1 + 2*(1+1+(1+10*2))}},
+		{"fragments", `
+			query { groups { ...G ... on Group { id } } }
+			fragment G on Group { name messages(first: 1) { hasNextPage } }
+		`, nil, Cost{Depth: 3, Complexity: 1 + 10*(1+2+1)}},
+		{"aliases and directives", `{ a: groups @include(if: true) { id } b: groups { name } }`, nil, Cost{Depth: 2, Complexity: 2 * 11}},
+		{"introspection", `{ __typename __schema { types { fields { type { ofType { name } } } } } }`, nil, Cost{Depth: 0, Complexity: 1}},
+		{"comments and strings", "# list\n{ messages(after: \"}\\\"\", first: 1) { nodes { id } } }", nil, Cost{Depth: 3, Complexity: 3}},
+	}
+
+	for _, tt := range tests {
+		cost, err := Measure(schema, tt.query, "", tt.variables, 10)
+		require.NoError(t, err, tt.name)
+		tt.want.Operation = "query"
+		assert.Equal(t, tt.want, cost, tt.name)
+	}
+}
+
+func TestMeasureOperations(t *testing.T) {
+	schema := graphql.MustParseSchema(testSchema, nil).AST()
+	query := `query A { groups { id } } query B { messages(first: 1) { hasNextPage } }`
+
+	cost, err := Measure(schema, query, "B", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, Cost{Operation: "query", Depth: 2, Complexity: 2}, cost)
+
+	cost, err = Measure(schema, `mutation M { groups { id } }`, "M", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "mutation", cost.Operation)
+
+	_, err = Measure(schema, query, "", nil, 10)
+	assert.Error(t, err)
+	_, err = Measure(schema, query, "C", nil, 10)
+	assert.Error(t, err)
+}
+
+func TestMeasureHugePages(t *testing.T) {
+	schema := graphql.MustParseSchema(testSchema, nil).AST()
+	cost, err := Measure(schema, `{ messages(first: 2000000000) { nodes { reactions { emoji } } } }`, "", nil, 1000000)
+	require.NoError(t, err)
+	assert.Equal(t, maxComplexity, cost.Complexity)
+}
+
+func TestMeasureFragmentFanOut(t *testing.T) {
+	schema := graphql.MustParseSchema(testSchema, nil).AST()
+
+	// Each fragment spreads the next four times; walking every spread would take
+	// about 4^30 steps
+	var query strings.Builder
+	query.WriteString("{ groups { ...F0 } }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&query, "fragment F%d on Group { id", i)
+		for j := 0; j < 4; j++ {
+			fmt.Fprintf(&query, " ...F%d", i+1)
+		}
+		query.WriteString(" }\n")
+	}
+	query.WriteString("fragment F30 on Group { name }\n")
+
+	done := make(chan struct{})
+	var cost Cost
+	var err error
+	go func() {
+		cost, err = Measure(schema, query.String(), "", nil, 10)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("measuring a fragment fan-out did not finish")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, 2, cost.Depth)
+	assert.Equal(t, maxComplexity, cost.Complexity)
+}
+
+func TestMeasureErrors(t *testing.T) {
+	schema := graphql.MustParseSchema(testSchema, nil).AST()
+	for _, query := range []string{"", "{", "{ groups { id }", "query ($n Int) { groups }", `{ groups(name: "x) }`, "fragment F on Group { id }", "{ groups % }"} {
+		_, err := Measure(schema, query, "", nil, 10)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,435 @@
+package complexity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// document is the part of a GraphQL query document that matters for its cost
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind string
+	name string
+	// defaults holds the integer defaults of variables
+	defaults   map[string]any
+	selections []*selection
+}
+
+type fragment struct {
+	typeCondition string
+	selections    []*selection
+}
+
+// selection is a field when name is set, a fragment spread when spread is set, and
+// an inline fragment otherwise
+type selection struct {
+	name          string
+	args          map[string]any
+	spread        string
+	typeCondition string
+	selections    []*selection
+}
+
+// variable is an argument given by a variable
+type variable string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// lex splits a query into tokens, dropping whitespace, commas and comments
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case strings.HasPrefix(src[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokenPunct, "..."})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tokens = append(tokens, token{tokenPunct, string(c)})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenName, src[start:i]})
+		case c == '-' || isDigit(c):
+			start, kind := i, tokenInt
+			i++
+			for i < len(src) && (isDigit(src[i]) || strings.IndexByte(".eE+-", src[i]) >= 0) {
+				if !isDigit(src[i]) {
+					kind = tokenFloat
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind, src[start:i]})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := i + 3
+			for ; end < len(src) && !strings.HasPrefix(src[end:], `"""`); end++ {
+				if strings.HasPrefix(src[end:], `\"""`) {
+					end += 3
+				}
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{tokenString, src[i+3 : end]})
+			i = end + 3
+		case c == '"':
+			end := i + 1
+			for ; end < len(src) && src[end] != '"'; end++ {
+				if src[end] == '\\' {
+					end++
+				} else if src[end] == '\n' || src[end] == '\r' {
+					break
+				}
+			}
+			if end >= len(src) || src[end] != '"' {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{tokenString, src[i+1 : end]})
+			i = end + 1
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// parser reads an executable document from tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses the operations and fragments of a query document
+func parse(query string) (*document, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragment)}
+
+	for p.peek().kind != tokenEOF {
+		switch tok := p.peek(); {
+		case tok.kind == tokenPunct && tok.value == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels})
+		case tok.kind == tokenName && tok.value == "fragment":
+			p.next()
+			name := p.next()
+			if name.kind != tokenName || !p.keyword("on") {
+				return nil, fmt.Errorf("invalid fragment definition")
+			}
+			typeCondition := p.next()
+			if typeCondition.kind != tokenName {
+				return nil, fmt.Errorf("invalid fragment definition")
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[name.value] = &fragment{typeCondition: typeCondition.value, selections: sels}
+		case tok.kind == tokenName && (tok.value == "query" || tok.value == "mutation" || tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, fmt.Errorf("unexpected %q", tok.value)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("query has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// punct consumes the given punctuator if it is next
+func (p *parser) punct(value string) bool {
+	if tok := p.peek(); tok.kind == tokenPunct && tok.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// keyword consumes the given name if it is next
+func (p *parser) keyword(value string) bool {
+	if tok := p.peek(); tok.kind == tokenName && tok.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(value string) error {
+	if !p.punct(value) {
+		return fmt.Errorf("expected %q, found %q", value, p.peek().value)
+	}
+	return nil
+}
+
+// operation parses an operation definition starting at its type
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.next().value, defaults: make(map[string]any)}
+	if p.peek().kind == tokenName {
+		op.name = p.next().value
+	}
+
+	if p.punct("(") {
+		for !p.punct(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			name := p.next()
+			if name.kind != tokenName {
+				return nil, fmt.Errorf("invalid variable definition")
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if err := p.typeRef(); err != nil {
+				return nil, err
+			}
+			if p.punct("=") {
+				value, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if value != nil {
+					op.defaults[name.value] = value
+				}
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+// typeRef skips a variable type such as [Int!]!
+func (p *parser) typeRef() error {
+	if p.punct("[") {
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if p.next().kind != tokenName {
+		return fmt.Errorf("invalid type")
+	}
+	p.punct("!")
+	return nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*selection
+	for !p.punct("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	return sels, nil
+}
+
+func (p *parser) selection() (*selection, error) {
+	sel := &selection{}
+
+	if p.punct("...") {
+		if tok := p.peek(); tok.kind == tokenName && tok.value != "on" {
+			sel.spread = p.next().value
+			return sel, p.directives()
+		}
+		if p.keyword("on") {
+			typeCondition := p.next()
+			if typeCondition.kind != tokenName {
+				return nil, fmt.Errorf("invalid inline fragment")
+			}
+			sel.typeCondition = typeCondition.value
+		}
+		if err := p.directives(); err != nil {
+			return nil, err
+		}
+		sels, err := p.selectionSet()
+		sel.selections = sels
+		return sel, err
+	}
+
+	name := p.next()
+	if name.kind != tokenName {
+		return nil, fmt.Errorf("expected a field, found %q", name.value)
+	}
+	sel.name = name.value
+	if p.punct(":") {
+		if name = p.next(); name.kind != tokenName {
+			return nil, fmt.Errorf("expected a field, found %q", name.value)
+		}
+		sel.name = name.value
+	}
+
+	args, err := p.arguments()
+	if err != nil {
+		return nil, err
+	}
+	sel.args = args
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokenPunct && tok.value == "{" {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// arguments parses an argument list, keeping integer and variable values
+func (p *parser) arguments() (map[string]any, error) {
+	if !p.punct("(") {
+		return nil, nil
+	}
+	args := make(map[string]any)
+	for !p.punct(")") {
+		name := p.next()
+		if name.kind != tokenName {
+			return nil, fmt.Errorf("expected an argument, found %q", name.value)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		args[name.value] = value
+	}
+	return args, nil
+}
+
+// directives skips directives such as @include(if: $flag)
+func (p *parser) directives() error {
+	for p.punct("@") {
+		if p.next().kind != tokenName {
+			return fmt.Errorf("invalid directive")
+		}
+		if _, err := p.arguments(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value parses a value, returning integers as int64, variables as variable and
+// nil for anything else
+func (p *parser) value() (any, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenPunct && tok.value == "$":
+		name := p.next()
+		if name.kind != tokenName {
+			return nil, fmt.Errorf("invalid variable")
+		}
+		return variable(name.value), nil
+	case tok.kind == tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", tok.value)
+		}
+		return n, nil
+	case tok.kind == tokenFloat || tok.kind == tokenString || tok.kind == tokenName:
+		return nil, nil
+	case tok.kind == tokenPunct && tok.value == "[":
+		for !p.punct("]") {
+			if p.peek().kind == tokenEOF {
+				return nil, fmt.Errorf("unterminated list")
+			}
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case tok.kind == tokenPunct && tok.value == "{":
+		for !p.punct("}") {
+			if p.next().kind != tokenName {
+				return nil, fmt.Errorf("invalid object value")
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.value)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the application
@@ -11,6 +12,22 @@ type Config struct {
 	Server          ServerConfig
 	Database        DatabaseConfig
 	MigrationConfig MigrationConfig
+	Admin           AdminConfig
+	Purge           PurgeConfig
+	Retention       RetentionConfig
+	Storage         StorageConfig
+	Unfurl          UnfurlConfig
+	Presence        PresenceConfig
+	Pins            PinsConfig
+	Scheduler       SchedulerConfig
+	Reminders       RemindersConfig
+	Webhooks        WebhooksConfig
+	Alertmanager    AlertmanagerConfig
+	Oncall          OncallConfig
+	Polls           PollsConfig
+	RateLimit       RateLimitConfig
+	GRPC            GRPCConfig
+	GraphQL         GraphQLConfig
 }
 
 // MigrationConfig holds migration configuration
@@ -18,9 +35,164 @@ type MigrationConfig struct {
 	Enabled bool
 }
 
+// AdminConfig holds admin API configuration
+type AdminConfig struct {
+	// Token is required as a bearer token on admin endpoints when set
+	Token string
+	// Open leaves the admin endpoints open when no token is set; otherwise they
+	// are refused
+	Open bool
+}
+
+// PurgeConfig holds configuration for purging soft-deleted messages
+type PurgeConfig struct {
+	// AfterDays is how long a message stays soft-deleted before it is purged (0 disables auto-purge)
+	AfterDays       int
+	IntervalMinutes int
+}
+
+// RetentionConfig holds configuration for the retention policy job
+type RetentionConfig struct {
+	// IntervalMinutes is how often policies are enforced (0 disables the job)
+	IntervalMinutes int
+	// BatchSize is the maximum number of rows removed per DELETE statement
+	BatchSize int
+	// BatchPauseMillis is the pause between batches to let other writers through
+	BatchPauseMillis int
+}
+
+// StorageConfig holds attachment storage configuration
+type StorageConfig struct {
+	// Driver selects the blob store implementation (currently only "local")
+	Driver    string
+	LocalPath string
+	// MaxUploadMB is the maximum size of a single uploaded file
+	MaxUploadMB  int
+	AllowedTypes []string
+	// SigningKey signs download URLs; a random key is generated when empty
+	SigningKey       string
+	URLExpiryMinutes int
+}
+
+// UnfurlConfig holds configuration for server-side link previews
+type UnfurlConfig struct {
+	Enabled        bool
+	TimeoutSeconds int
+	CacheTTLHours  int
+}
+
+// PresenceConfig holds configuration for presence and typing indicators
+type PresenceConfig struct {
+	// GraceSeconds is how long a user stays online after their last stream disconnects
+	GraceSeconds int
+	// TypingTTLSeconds is how long clients should show a typing indicator
+	TypingTTLSeconds int
+}
+
+// PinsConfig holds configuration for pinned messages
+type PinsConfig struct {
+	// MaxPerGroup is how many messages a group may have pinned at once
+	MaxPerGroup int
+}
+
+// SchedulerConfig holds configuration for delivering scheduled messages
+type SchedulerConfig struct {
+	// IntervalSeconds is how often due messages are polled for (0 disables delivery)
+	IntervalSeconds int
+	// BatchSize is the maximum number of messages claimed per poll
+	BatchSize int
+	// MaxAttempts is how often delivery is retried before a message is marked failed
+	MaxAttempts int
+}
+
+// RemindersConfig holds configuration for recurring reminders
+type RemindersConfig struct {
+	// Enabled lets this replica take part in the leader election that runs reminders
+	Enabled bool
+	// LeaseSeconds is how long a leader keeps the lease without renewing it
+	LeaseSeconds int
+}
+
+// WebhooksConfig holds configuration for delivering outgoing webhooks
+type WebhooksConfig struct {
+	// IntervalSeconds is how often the outbox is polled for due deliveries (0 disables delivery)
+	IntervalSeconds int
+	// BatchSize is the maximum number of deliveries claimed per poll
+	BatchSize int
+	// TimeoutSeconds bounds each delivery request
+	TimeoutSeconds int
+	// MaxAttempts is how often a delivery is tried before it is dead-lettered
+	MaxAttempts int
+	// BackoffSeconds is the delay before the first retry; it doubles after each
+	// further failure up to BackoffMaxSeconds
+	BackoffSeconds    int
+	BackoffMaxSeconds int
+	// AllowPrivate lets webhooks deliver to loopback, private and link-local addresses
+	AllowPrivate bool
+}
+
+// AlertmanagerConfig holds configuration for the Alertmanager webhook receiver
+type AlertmanagerConfig struct {
+	// Group is the name of the group alerts are posted to; it is created on first use
+	Group string
+	// User is the name alert messages are posted as
+	User string
+	// Token is required as a bearer token on the receiver when set
+	Token string
+	// Open leaves the receiver open when no token is set; otherwise it is refused
+	Open bool
+}
+
+// OncallConfig holds configuration for on-call handoff announcements
+type OncallConfig struct {
+	// IntervalSeconds is how often schedules are checked for handoffs (0 disables announcements)
+	IntervalSeconds int
+	// User is the name handoff messages are posted as
+	User string
+}
+
+// PollsConfig holds configuration for closing polls when their closes_at passes
+type PollsConfig struct {
+	// IntervalSeconds is how often polls past their closes_at are closed (0 disables closing them)
+	IntervalSeconds int
+}
+
+// RateLimitConfig holds configuration for per-client rate limits on posting
+type RateLimitConfig struct {
+	// MessagesPerMinute limits messages posted per client IP (0 disables the limit)
+	MessagesPerMinute int
+	// MessagesBurst is how many messages a client IP can post at once
+	MessagesBurst int
+	// BotPerMinute limits bot API requests per bot (0 disables the limit)
+	BotPerMinute int
+	// BotBurst is how many bot API requests a bot can make at once
+	BotBurst int
+}
+
+// GRPCConfig holds configuration for the gRPC API
+type GRPCConfig struct {
+	Enabled bool
+	// Port is served separately from the REST API's
+	Port string
+}
+
+// GraphQLConfig holds configuration for the GraphQL API
+type GraphQLConfig struct {
+	// MaxDepth bounds how deeply fields of an operation may nest
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of fields an operation resolves
+	MaxComplexity int
+	// ListSize is the number of items assumed for lists without a first argument
+	// when estimating complexity
+	ListSize int
+}
+
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port string
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header gives the client IP; none are trusted by default
+	TrustedProxies []string
 }
 
 // DatabaseConfig holds database configuration
@@ -37,7 +209,8 @@ type DatabaseConfig struct {
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			TrustedProxies: getEnvAsList("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -50,6 +223,85 @@ func Load() (*Config, error) {
 		MigrationConfig: MigrationConfig{
 			Enabled: getEnvAsBool("MIGRATION_ENABLED", true),
 		},
+		Admin: AdminConfig{
+			Token: getEnv("ADMIN_TOKEN", ""),
+			Open:  getEnvAsBool("ADMIN_OPEN", false),
+		},
+		Purge: PurgeConfig{
+			AfterDays:       getEnvAsInt("PURGE_DELETED_AFTER_DAYS", 0),
+			IntervalMinutes: getEnvAsInt("PURGE_INTERVAL_MINUTES", 60),
+		},
+		Retention: RetentionConfig{
+			IntervalMinutes:  getEnvAsInt("RETENTION_INTERVAL_MINUTES", 60),
+			BatchSize:        getEnvAsInt("RETENTION_BATCH_SIZE", 1000),
+			BatchPauseMillis: getEnvAsInt("RETENTION_BATCH_PAUSE_MS", 100),
+		},
+		Storage: StorageConfig{
+			Driver:           getEnv("STORAGE_DRIVER", "local"),
+			LocalPath:        getEnv("STORAGE_LOCAL_PATH", "./data/attachments"),
+			MaxUploadMB:      getEnvAsInt("ATTACHMENT_MAX_UPLOAD_MB", 10),
+			AllowedTypes:     getEnvAsList("ATTACHMENT_ALLOWED_TYPES", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}),
+			SigningKey:       getEnv("ATTACHMENT_SIGNING_KEY", ""),
+			URLExpiryMinutes: getEnvAsInt("ATTACHMENT_URL_EXPIRY_MINUTES", 60),
+		},
+		Unfurl: UnfurlConfig{
+			Enabled:        getEnvAsBool("UNFURL_ENABLED", true),
+			TimeoutSeconds: getEnvAsInt("UNFURL_TIMEOUT_SECONDS", 5),
+			CacheTTLHours:  getEnvAsInt("UNFURL_CACHE_TTL_HOURS", 24),
+		},
+		Presence: PresenceConfig{
+			GraceSeconds:     getEnvAsInt("PRESENCE_GRACE_SECONDS", 30),
+			TypingTTLSeconds: getEnvAsInt("TYPING_TTL_SECONDS", 5),
+		},
+		Pins: PinsConfig{
+			MaxPerGroup: getEnvAsInt("PINS_MAX_PER_GROUP", 50),
+		},
+		Scheduler: SchedulerConfig{
+			IntervalSeconds: getEnvAsInt("SCHEDULER_INTERVAL_SECONDS", 5),
+			BatchSize:       getEnvAsInt("SCHEDULER_BATCH_SIZE", 100),
+			MaxAttempts:     getEnvAsInt("SCHEDULER_MAX_ATTEMPTS", 5),
+		},
+		Reminders: RemindersConfig{
+			Enabled:      getEnvAsBool("REMINDERS_ENABLED", true),
+			LeaseSeconds: getEnvAsInt("REMINDERS_LEASE_SECONDS", 30),
+		},
+		Webhooks: WebhooksConfig{
+			IntervalSeconds:   getEnvAsInt("WEBHOOKS_INTERVAL_SECONDS", 2),
+			BatchSize:         getEnvAsInt("WEBHOOKS_BATCH_SIZE", 50),
+			TimeoutSeconds:    getEnvAsInt("WEBHOOKS_TIMEOUT_SECONDS", 10),
+			MaxAttempts:       getEnvAsInt("WEBHOOKS_MAX_ATTEMPTS", 8),
+			BackoffSeconds:    getEnvAsInt("WEBHOOKS_BACKOFF_SECONDS", 10),
+			BackoffMaxSeconds: getEnvAsInt("WEBHOOKS_BACKOFF_MAX_SECONDS", 3600),
+			AllowPrivate:      getEnvAsBool("WEBHOOKS_ALLOW_PRIVATE", false),
+		},
+		Alertmanager: AlertmanagerConfig{
+			Group: getEnv("ALERTMANAGER_GROUP", "Alerts"),
+			User:  getEnv("ALERTMANAGER_USER", "alertmanager"),
+			Token: getEnv("ALERTMANAGER_TOKEN", ""),
+			Open:  getEnvAsBool("ALERTMANAGER_OPEN", false),
+		},
+		Oncall: OncallConfig{
+			IntervalSeconds: getEnvAsInt("ONCALL_INTERVAL_SECONDS", 30),
+			User:            getEnv("ONCALL_USER", "oncall"),
+		},
+		Polls: PollsConfig{
+			IntervalSeconds: getEnvAsInt("POLLS_INTERVAL_SECONDS", 5),
+		},
+		RateLimit: RateLimitConfig{
+			MessagesPerMinute: getEnvAsInt("RATE_LIMIT_MESSAGES_PER_MINUTE", 60),
+			MessagesBurst:     getEnvAsInt("RATE_LIMIT_MESSAGES_BURST", 20),
+			BotPerMinute:      getEnvAsInt("RATE_LIMIT_BOT_PER_MINUTE", 300),
+			BotBurst:          getEnvAsInt("RATE_LIMIT_BOT_BURST", 50),
+		},
+		GRPC: GRPCConfig{
+			Enabled: getEnvAsBool("GRPC_ENABLED", true),
+			Port:    getEnv("GRPC_PORT", "9090"),
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      getEnvAsInt("GRAPHQL_MAX_DEPTH", 10),
+			MaxComplexity: getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", 5000),
+			ListSize:      getEnvAsInt("GRAPHQL_LIST_SIZE", 10),
+		},
 	}
 
 	return cfg, nil
@@ -79,6 +331,22 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsList gets a comma-separated environment variable as a list or returns a default value
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // getEnvAsBool gets an environment variable as boolean or returns a default value
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,229 @@
+// Package cron parses standard five-field cron expressions and computes when they
+// next fire in a given time zone.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next run so impossible dates such as
+// February 30th fail instead of looping forever
+const maxSearchYears = 5
+
+// Schedule is a parsed cron expression. Fields are bitsets of the matching values.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record unrestricted day fields; when both day fields are
+	// restricted a day matching either one fires, as in standard cron
+	domStar, dowStar bool
+}
+
+// field describes the valid range and names of one cron field
+type field struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// macros are the supported @ shorthands
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression with minute, hour, day of month, month and day of
+// week fields. Fields accept *, lists, ranges, steps and English month and weekday
+// abbreviations; the @hourly style macros are also accepted.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// 7 is an alias for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return &s, nil
+}
+
+// allHours is the hour field of a schedule that fires every hour
+const allHours = 1<<24 - 1
+
+// Next returns the first time after t, in t's location, at which the schedule fires.
+// It returns the zero time if the schedule never fires. When DST ends, a schedule
+// with restricted hours fires only on the first pass through the repeated hour, as
+// in standard cron; schedules that fire every hour keep firing through both.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// Elapsed time rather than time.Date, which may pick the second pass
+			// through an hour that repeats when DST ends
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 || (s.hour != allHours && repeated(t)) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// repeated reports whether t's wall clock time already occurred earlier, in the hour
+// that repeats when DST ends. DST shifts are at most two hours.
+func repeated(t time.Time) bool {
+	for _, shift := range []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour} {
+		earlier := t.Add(-shift)
+		if earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() && earlier.Day() == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// dayMatches reports whether t's day satisfies the day of month and day of week fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses one comma-separated cron field into a bitset
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		partBits, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+// parseRange parses a single *, value, range or stepped range
+func parseRange(expr string, f field) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	step := uint(1)
+	if hasStep {
+		parsed, err := strconv.ParseUint(stepExpr, 10, 8)
+		if err != nil || parsed == 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, f.name)
+		}
+		step = uint(parsed)
+	}
+
+	var start, end uint
+	switch {
+	case rangeExpr == "*" || rangeExpr == "?":
+		start, end = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		low, high, _ := strings.Cut(rangeExpr, "-")
+		var err error
+		if start, err = parseValue(low, f); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(high, f); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+		}
+	default:
+		value, err := parseValue(rangeExpr, f)
+		if err != nil {
+			return 0, err
+		}
+		// A single value with a step runs from that value to the end of the range
+		start, end = value, value
+		if hasStep {
+			end = f.max
+		}
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << v
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or name within a field's range
+func parseValue(expr string, f field) (uint, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil || uint(value) < f.min || uint(value) > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field", expr, f.name)
+	}
+	return uint(value), nil
+}
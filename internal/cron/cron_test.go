@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "* * * * funday"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		// Weekdays at 09:00, from a Friday evening
+		{"0 9 * * MON-FRI", time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 1, 10, 7, 30, 0, time.UTC), time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 12 1 * sun", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on the day DST starts in Berlin
+		{"30 2 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 4, 1, 2, 30, 0, 0, berlin)},
+		{"0 9 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 3, 31, 9, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.True(t, tt.want.Equal(schedule.Next(tt.after)), "%s: got %s, want %s", tt.expr, schedule.Next(tt.after), tt.want)
+	}
+}
+
+func TestNextFallBack(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 02:00 to 03:00 happens twice on 2024-10-27 in Berlin, first in CEST then in CET
+	start := time.Date(2024, 10, 27, 0, 0, 0, 0, berlin)
+
+	// Restricted hours fire once, on the first pass
+	schedule, err := Parse("30 2 * * *")
+	require.NoError(t, err)
+	first := schedule.Next(start)
+	assert.Equal(t, time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), first.UTC())
+	assert.True(t, time.Date(2024, 10, 28, 2, 30, 0, 0, berlin).Equal(schedule.Next(first)), "got %s", schedule.Next(first))
+
+	// Every-hour schedules fire in both passes
+	schedule, err = Parse("30 * * * *")
+	require.NoError(t, err)
+	next := schedule.Next(first)
+	assert.Equal(t, time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC), next.UTC())
+	assert.Equal(t, 2, next.Hour())
+}
+
+func TestNextNever(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
@@ -46,16 +46,70 @@ func Migrate(enabled bool) error {
 	err := DB.AutoMigrate(
 		&models.Group{},
 		&models.Message{},
+		&models.RetentionPolicy{},
+		&models.Reaction{},
+		&models.Attachment{},
+		&models.LinkPreview{},
+		&models.Mention{},
+		&models.ReadPosition{},
+		&models.Pin{},
+		&models.ScheduledMessage{},
+		&models.Reminder{},
+		&models.ReminderRun{},
+		&models.LeaderLease{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollVote{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.IncomingWebhook{},
+		&models.Alert{},
+		&models.Incident{},
+		&models.OncallSchedule{},
+		&models.OncallOverride{},
+		&models.Bot{},
+		&models.BotToken{},
+		&models.BotMembership{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := CreateSearchIndex(); err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
 
+// CreateSearchIndex adds the full-text search column and GIN index on messages.
+// Only PostgreSQL supports tsvector; other databases fall back to LIKE matching.
+func CreateSearchIndex() error {
+	if DB == nil {
+		return fmt.Errorf("database connection not established")
+	}
+
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	statements := []string{
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('english', coalesce(content, ''))) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SeedDefaultGroup creates the default "SRE Bootcamp" group if it doesn't exist
 func SeedDefaultGroup() error {
 	if DB == nil {
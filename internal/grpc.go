@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"sre-chat-api/internal/handlers"
+	"sre-chat-api/internal/middleware"
+	"sre-chat-api/internal/ratelimit"
+	"sre-chat-api/pkg/chatpb"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+)
+
+// grpcKeepalive pings idle connections so that proxies keep subscriptions open,
+// like the keepalive events of SSE streams
+const grpcKeepalive = 30 * time.Second
+
+// setupGRPCServer configures the gRPC server with the ChatService. Posting is rate
+// limited by the same limiter as POST /api/v1/messages.
+func setupGRPCServer(logger *zap.Logger, chatHandler *handlers.GRPCHandler, messageLimiter *ratelimit.Limiter) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			middleware.GRPCUnaryLogger(logger),
+			middleware.GRPCUnaryRecovery(logger),
+			middleware.GRPCRateLimit(messageLimiter, chatpb.ChatService_CreateMessage_FullMethodName),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.GRPCStreamLogger(logger),
+			middleware.GRPCStreamRecovery(logger),
+		),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: grpcKeepalive}),
+	)
+
+	chatpb.RegisterChatServiceServer(server, chatHandler)
+
+	// Let tools such as grpcurl discover the service
+	reflection.Register(server)
+
+	return server
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminHandler handles admin-only HTTP requests
+type AdminHandler struct {
+	sseHandler *SSEHandler
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(sseHandler *SSEHandler) *AdminHandler {
+	return &AdminHandler{
+		sseHandler: sseHandler,
+	}
+}
+
+// GetDeletedMessages handles GET /api/v1/admin/groups/:id/messages/deleted
+func (h *AdminHandler) GetDeletedMessages(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var messages []models.Message
+	if err := database.DB.Unscoped().
+		Where("group_id = ? AND deleted_at IS NOT NULL", uint(groupID)).
+		Order("deleted_at DESC").
+		Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// RestoreMessage handles POST /api/v1/admin/messages/:id/restore
+func (h *AdminHandler) RestoreMessage(c *gin.Context) {
+	message, ok := findDeletedMessage(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Unscoped().Model(&message).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore message"})
+		return
+	}
+
+	database.DB.Preload("Group").First(&message, message.ID)
+	enrichMessages(&message)
+
+	// Notify SSE clients so the message reappears
+	if h.sseHandler != nil {
+		h.sseHandler.NotifyEvent(EventMessageRestored, message)
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// PurgeMessage handles DELETE /api/v1/admin/messages/:id
+func (h *AdminHandler) PurgeMessage(c *gin.Context) {
+	message, ok := findDeletedMessage(c)
+	if !ok {
+		return
+	}
+
+	_, keys, err := jobs.PurgeMessages(func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND deleted_at IS NOT NULL", message.ID)
+	}, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge message"})
+		return
+	}
+	if err := storage.DeleteAll(c.Request.Context(), keys); err != nil {
+		log.Printf("Failed to delete attachments of purged message %d: %v", message.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message purged successfully"})
+}
+
+// findDeletedMessage loads the soft-deleted message identified by the :id param,
+// writing an error response and returning false if it cannot be found
+func findDeletedMessage(c *gin.Context) (models.Message, bool) {
+	var message models.Message
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return message, false
+	}
+
+	if err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&message, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deleted message not found"})
+			return message, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return message, false
+	}
+
+	return message, true
+}
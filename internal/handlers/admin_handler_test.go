@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupAdminRouter(db *gorm.DB) *gin.Engine {
+	router := setupRouter(db)
+	adminHandler := NewAdminHandler(nil) // nil SSE handler for tests
+
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.GET("/groups/:id/messages/deleted", adminHandler.GetDeletedMessages)
+		admin.POST("/messages/:id/restore", adminHandler.RestoreMessage)
+		admin.DELETE("/messages/:id", adminHandler.PurgeMessage)
+	}
+
+	return router
+}
+
+func createDeletedMessage(t *testing.T, db *gorm.DB) models.Message {
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	message := models.Message{
+		GroupID: group.ID,
+		User:    "testuser",
+		Content: "Deleted message",
+	}
+	db.Create(&message)
+	db.Delete(&message)
+	return message
+}
+
+func TestGetDeletedMessages(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupAdminRouter(db)
+	message := createDeletedMessage(t, db)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/groups/1/messages/deleted", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var messages []models.Message
+	json.Unmarshal(w.Body.Bytes(), &messages)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, message.ID, messages[0].ID)
+}
+
+func TestRestoreMessage(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupAdminRouter(db)
+	message := createDeletedMessage(t, db)
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/messages/1/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Verify message is visible again
+	var restored models.Message
+	assert.NoError(t, db.First(&restored, message.ID).Error)
+
+	// Restoring a live message is not allowed
+	req, _ = http.NewRequest("POST", "/api/v1/admin/messages/1/restore", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPurgeMessage(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupAdminRouter(db)
+	message := createDeletedMessage(t, db)
+
+	store, _ := storage.NewLocalStore(t.TempDir())
+	storage.Store = store
+	ctx := context.Background()
+	store.Put(ctx, "messages/x/file.txt", strings.NewReader("x"), "text/plain")
+	db.Create(&models.Attachment{MessageID: message.ID, Filename: "file.txt", ContentType: "text/plain", Size: 1, StorageKey: "messages/x/file.txt"})
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/admin/messages/"+strconv.Itoa(int(message.ID)), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Verify message is gone even when including soft-deleted rows
+	var purged models.Message
+	result := db.Unscoped().First(&purged, message.ID)
+	assert.Error(t, result.Error)
+
+	// Its attachments' blobs go with it
+	_, err := store.Get(ctx, "messages/x/file.txt")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sre-chat-api/internal/alerts"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAlertLimit = 50
+	maxAlertLimit     = 500
+)
+
+var (
+	errAlertResolved     = errors.New("alert is resolved")
+	errAlertAcknowledged = errors.New("alert is already acknowledged")
+)
+
+func init() {
+	commands.Register(commands.Command{
+		Name:        "ack",
+		Usage:       "/ack [alert id]",
+		Description: "Acknowledge a firing alert, by default the latest one in this group",
+		Run:         runAck,
+	})
+}
+
+// AlertHandler handles Alertmanager notifications and alert acknowledgements
+type AlertHandler struct {
+	messageHandler *MessageHandler
+	group          string
+	user           string
+	token          string
+	open           bool
+}
+
+// NewAlertHandler creates a new alert handler. Alert messages are delivered and
+// announced through the message handler.
+func NewAlertHandler(messageHandler *MessageHandler, cfg config.AlertmanagerConfig) *AlertHandler {
+	return &AlertHandler{
+		messageHandler: messageHandler,
+		group:          cfg.Group,
+		user:           cfg.User,
+		token:          cfg.Token,
+		open:           cfg.Open,
+	}
+}
+
+// Receive handles POST /api/v1/alertmanager
+// The first notification of a group key posts a message; later ones edit it until
+// the group resolves. A group that fires again after resolving gets a new message.
+// Without a token the receiver is refused unless it is explicitly left open.
+func (h *AlertHandler) Receive(c *gin.Context) {
+	if h.token == "" {
+		if !h.open {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Alertmanager receiver is disabled; set ALERTMANAGER_TOKEN to enable it"})
+			return
+		}
+	} else {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(h.token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+	}
+
+	var notification alerts.Notification
+	if err := c.ShouldBindJSON(&notification); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	raw, err := json.Marshal(notification)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group models.Group
+	err = database.DB.Where(models.Group{Name: h.group}).
+		Attrs(models.Group{Description: "Alerts from Alertmanager"}).
+		FirstOrCreate(&group).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find alert group"})
+		return
+	}
+
+	var alert models.Alert
+	var message models.Message
+	posted := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("group_key = ?", notification.GroupKey).First(&alert).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		refiring := err == nil && alert.Status == models.AlertResolved && notification.Status == alerts.StatusFiring
+		if err == gorm.ErrRecordNotFound || refiring {
+			alert = models.Alert{ID: alert.ID, GroupKey: notification.GroupKey, GroupID: group.ID, CreatedAt: alert.CreatedAt}
+		}
+
+		alert.Title = notification.Title()
+		alert.Status = notification.Status
+		alert.Receiver = notification.Receiver
+		alert.Notification = raw
+		// Saving first gives a new alert the ID shown in its message
+		if err := tx.Save(&alert).Error; err != nil {
+			return err
+		}
+
+		if alert.MessageID != 0 {
+			err := tx.First(&message, alert.MessageID).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+		content := alerts.Render(notification, alert.ID, alertAcknowledgement(alert))
+
+		// Repost if the previous message was deleted
+		if message.ID == 0 {
+			message = models.Message{GroupID: alert.GroupID, User: h.user, Content: content}
+			if err := messages.Create(tx, &message); err != nil {
+				return err
+			}
+			posted = true
+			return tx.Model(&alert).Update("message_id", message.ID).Error
+		}
+
+		message.Content = content
+		if err := tx.Save(&message).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.EventMessageUpdated, message)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record alert"})
+		return
+	}
+
+	database.DB.Preload("Group").First(&message, message.ID)
+	enrichMessages(&message)
+	if posted {
+		h.messageHandler.Deliver(message)
+	} else {
+		h.messageHandler.Announce(message)
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetAlerts handles GET /api/v1/alerts
+// Alerts are listed newest first and can be filtered by status and group_id.
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	query := database.DB.Model(&models.Alert{})
+	if status := c.Query("status"); status != "" {
+		if status != models.AlertFiring && status != models.AlertResolved {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		query = query.Where("group_id = ?", uint(id))
+	}
+
+	limit := defaultAlertLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxAlertLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var list []models.Alert
+	if err := query.Order("updated_at DESC, id DESC").Limit(limit).Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// AcknowledgeAlert handles POST /api/v1/alerts/:id/ack
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	alert, message, err := acknowledgeAlert(uint(id), user)
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		case errAlertResolved:
+			c.JSON(http.StatusConflict, gin.H{"error": "Alert is already resolved"})
+		case errAlertAcknowledged:
+			c.JSON(http.StatusConflict, gin.H{"error": "Alert was already acknowledged by " + alert.AcknowledgedBy})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge alert"})
+		}
+		return
+	}
+
+	if message.ID != 0 {
+		h.messageHandler.Announce(message)
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// runAck acknowledges an alert from chat
+func runAck(ctx *commands.Context) (string, error) {
+	var id uint
+	switch len(ctx.Args) {
+	case 0:
+		var latest models.Alert
+		err := database.DB.Where("group_id = ? AND status = ? AND acknowledged_at IS NULL", ctx.GroupID, models.AlertFiring).
+			Order("updated_at DESC").
+			Limit(1).
+			Find(&latest).Error
+		if err != nil {
+			return "", err
+		}
+		if latest.ID == 0 {
+			return "No unacknowledged alerts are firing in this group.", nil
+		}
+		id = latest.ID
+	case 1:
+		parsed, err := strconv.ParseUint(strings.TrimPrefix(ctx.Args[0], "#"), 10, 32)
+		if err != nil {
+			return "", commands.ErrUsage
+		}
+		id = uint(parsed)
+	default:
+		return "", commands.ErrUsage
+	}
+
+	alert, message, err := acknowledgeAlert(id, ctx.User)
+	switch err {
+	case nil:
+	case gorm.ErrRecordNotFound:
+		return fmt.Sprintf("Alert %d not found.", id), nil
+	case errAlertResolved:
+		return fmt.Sprintf("Alert %d (%s) is already resolved.", id, alert.Title), nil
+	case errAlertAcknowledged:
+		return fmt.Sprintf("Alert %d (%s) was already acknowledged by %s.", id, alert.Title, alert.AcknowledgedBy), nil
+	default:
+		return "", err
+	}
+
+	if message.ID != 0 && ctx.Announce != nil {
+		ctx.Announce(message)
+	}
+	return fmt.Sprintf("Acknowledged alert %d (%s).", alert.ID, alert.Title), nil
+}
+
+// acknowledgeAlert records the user's acknowledgement of a firing alert and
+// re-renders its message. The returned message is empty if it was deleted.
+func acknowledgeAlert(id uint, user string) (models.Alert, models.Message, error) {
+	var alert models.Alert
+	var message models.Message
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alert, id).Error; err != nil {
+			return err
+		}
+		if alert.Status == models.AlertResolved {
+			return errAlertResolved
+		}
+		if alert.AcknowledgedAt != nil {
+			return errAlertAcknowledged
+		}
+
+		now := time.Now()
+		alert.AcknowledgedBy = user
+		alert.AcknowledgedAt = &now
+		if err := tx.Model(&alert).Updates(map[string]interface{}{
+			"acknowledged_by": user,
+			"acknowledged_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var notification alerts.Notification
+		if err := json.Unmarshal(alert.Notification, &notification); err != nil {
+			return err
+		}
+
+		if err := tx.First(&message, alert.MessageID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		message.Content = alerts.Render(notification, alert.ID, alertAcknowledgement(alert))
+		if err := tx.Save(&message).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.EventMessageUpdated, message)
+	})
+	if err != nil {
+		return alert, models.Message{}, err
+	}
+
+	if message.ID != 0 {
+		database.DB.Preload("Group").First(&message, message.ID)
+		enrichMessages(&message)
+	}
+	return alert, message, nil
+}
+
+// alertAcknowledgement returns who acknowledged the alert, or nil if nobody has
+func alertAcknowledgement(alert models.Alert) *alerts.Acknowledgement {
+	if alert.AcknowledgedAt == nil {
+		return nil
+	}
+	return &alerts.Acknowledgement{User: alert.AcknowledgedBy, At: *alert.AcknowledgedAt}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/alerts"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertmanagerReceiver(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	alertHandler := NewAlertHandler(NewMessageHandler(nil, nil, nil), config.AlertmanagerConfig{
+		Group: "Alerts",
+		User:  "alertmanager",
+		Token: "am-secret",
+	})
+	router.POST("/api/v1/alertmanager", alertHandler.Receive)
+	router.GET("/api/v1/alerts", alertHandler.GetAlerts)
+	router.POST("/api/v1/alerts/:id/ack", alertHandler.AcknowledgeAlert)
+
+	notify := func(status, token string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(alerts.Notification{
+			GroupKey:    `{}:{alertname="HighLatency"}`,
+			Status:      status,
+			GroupLabels: map[string]string{"alertname": "HighLatency"},
+			Alerts: []alerts.Alert{
+				{Status: status, Labels: map[string]string{"alertname": "HighLatency", "instance": "web-1"}},
+			},
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/alertmanager", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	messages := func() []models.Message {
+		var list []models.Message
+		db.Order("id").Find(&list)
+		return list
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, notify(alerts.StatusFiring, "wrong").Code)
+
+	// The first notification posts a message to the alert group, created on first use
+	w := notify(alerts.StatusFiring, "am-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var alert models.Alert
+	json.Unmarshal(w.Body.Bytes(), &alert)
+
+	var group models.Group
+	db.Where("name = ?", "Alerts").First(&group)
+	assert.Equal(t, group.ID, alert.GroupID)
+
+	list := messages()
+	if !assert.Len(t, list, 1) {
+		return
+	}
+	assert.Equal(t, "alertmanager", list[0].User)
+	assert.Equal(t, group.ID, list[0].GroupID)
+	assert.Contains(t, list[0].Content, fmt.Sprintf("/ack %d", alert.ID))
+
+	// Repeat notifications edit the same message
+	assert.Equal(t, http.StatusOK, notify(alerts.StatusFiring, "am-secret").Code)
+	assert.Len(t, messages(), 1)
+
+	// Acknowledging from chat edits the message and replies to the caller only
+	jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: "alice", Content: "/ack", GroupID: group.ID})
+	req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.CommandResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, fmt.Sprintf("Acknowledged alert %d (HighLatency).", alert.ID), response.Text)
+
+	list = messages()
+	assert.Len(t, list, 1)
+	assert.Contains(t, list[0].Content, "Acknowledged by alice")
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/alerts/%d/ack", alert.ID), nil)
+	req.Header.Set("X-User", "bob")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Resolving edits the message
+	assert.Equal(t, http.StatusOK, notify(alerts.StatusResolved, "am-secret").Code)
+	list = messages()
+	assert.Len(t, list, 1)
+	assert.True(t, strings.HasPrefix(list[0].Content, "✅ [RESOLVED] HighLatency"))
+
+	// Firing again after resolving posts a new, unacknowledged message
+	assert.Equal(t, http.StatusOK, notify(alerts.StatusFiring, "am-secret").Code)
+	list = messages()
+	if assert.Len(t, list, 2) {
+		assert.Contains(t, list[1].Content, "/ack")
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/alerts?status=firing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var firing []models.Alert
+	json.Unmarshal(w.Body.Bytes(), &firing)
+	if assert.Len(t, firing, 1) {
+		assert.Empty(t, firing[0].AcknowledgedBy)
+		assert.Equal(t, list[1].ID, firing[0].MessageID)
+	}
+}
+
+func TestAlertmanagerReceiverRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	alertHandler := NewAlertHandler(NewMessageHandler(nil, nil, nil), config.AlertmanagerConfig{Group: "Alerts", User: "alertmanager"})
+	router.POST("/api/v1/alertmanager", alertHandler.Receive)
+
+	// Without a token, and without being left open, the receiver refuses everyone
+	req, _ := http.NewRequest("POST", "/api/v1/alertmanager", strings.NewReader(`{"groupKey":"forged","status":"firing"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "ALERTMANAGER_TOKEN")
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxFilesPerUpload limits how many files a single upload request may carry
+	maxFilesPerUpload = 10
+
+	// maxFilenameLength limits stored filenames
+	maxFilenameLength = 255
+
+	// thumbnailVariant is the download variant serving an image's thumbnail
+	thumbnailVariant = "thumbnail"
+)
+
+// AttachmentHandler handles file attachment HTTP requests
+type AttachmentHandler struct {
+	sseHandler   *SSEHandler
+	maxBytes     int64
+	allowedTypes map[string]bool
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(sseHandler *SSEHandler, cfg config.StorageConfig) *AttachmentHandler {
+	allowedTypes := make(map[string]bool, len(cfg.AllowedTypes))
+	for _, contentType := range cfg.AllowedTypes {
+		allowedTypes[contentType] = true
+	}
+
+	return &AttachmentHandler{
+		sseHandler:   sseHandler,
+		maxBytes:     int64(cfg.MaxUploadMB) << 20,
+		allowedTypes: allowedTypes,
+	}
+}
+
+// upload is a validated file waiting to be stored
+type upload struct {
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// UploadAttachments handles POST /api/v1/messages/:id/attachments
+// Files are sent as multipart form data in one or more "file" fields.
+func (h *AttachmentHandler) UploadAttachments(c *gin.Context) {
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	// Cap the whole request body, leaving room for multipart overhead
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes*maxFilesPerUpload+1<<20)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if len(files) > maxFilesPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d files per upload", maxFilesPerUpload)})
+		return
+	}
+
+	// Validate every file before storing any of them
+	uploads := make([]upload, 0, len(files))
+	for _, fh := range files {
+		if fh.Size > h.maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File %s exceeds the %d MB limit", fh.Filename, h.maxBytes>>20)})
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, h.maxBytes+1))
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		u, ok := h.prepareUpload(c, fh.Filename, data)
+		if !ok {
+			return
+		}
+		uploads = append(uploads, u)
+	}
+
+	attachments, ok := h.attach(c, message, uploads)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachments)
+}
+
+// prepareUpload validates a file's size and detected content type. It writes an
+// error response and returns false if the file is rejected.
+func (h *AttachmentHandler) prepareUpload(c *gin.Context, filename string, data []byte) (upload, bool) {
+	if int64(len(data)) > h.maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File %s exceeds the %d MB limit", filename, h.maxBytes>>20)})
+		return upload{}, false
+	}
+
+	// Trust the content, not the client's Content-Type header
+	contentType := detectContentType(data)
+	if !h.allowedTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("File type %s is not allowed", contentType)})
+		return upload{}, false
+	}
+
+	return upload{
+		filename:    sanitizeFilename(filename),
+		contentType: contentType,
+		data:        data,
+	}, true
+}
+
+// attach stores validated uploads on a message and notifies SSE clients. It writes
+// an error response and returns false if storing fails.
+func (h *AttachmentHandler) attach(c *gin.Context, message models.Message, uploads []upload) ([]models.Attachment, bool) {
+	attachments, err := storeAttachments(c, database.DB, message.ID, uploads)
+	if err != nil {
+		log.Printf("Failed to store attachments for message %d: %v", message.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachments"})
+		return nil, false
+	}
+
+	signAttachmentURLs(attachments)
+
+	if h.sseHandler != nil {
+		h.sseHandler.Publish(SSEMessage{Type: EventAttachmentAdded, Message: &message, Data: attachments})
+	}
+
+	return attachments, true
+}
+
+// DownloadAttachment handles GET /api/v1/attachments/:id/download
+// The URL must carry a valid signature produced when the attachment was listed.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	variant := c.Query("variant")
+	if variant != "" && variant != thumbnailVariant {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant"})
+		return
+	}
+
+	if storage.Signer == nil || !storage.Signer.Verify(uint(id), variant, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired signature"})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := database.DB.First(&attachment, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	key, contentType, size := attachment.StorageKey, attachment.ContentType, attachment.Size
+	if variant == thumbnailVariant {
+		if attachment.ThumbnailKey == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+			return
+		}
+		key, contentType, size = attachment.ThumbnailKey, storage.ThumbnailContentType, -1
+	}
+
+	blob, err := storage.Store.Get(c.Request.Context(), key)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer blob.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.DataFromReader(http.StatusOK, size, contentType, blob, nil)
+}
+
+// attachmentKeys returns the blob keys of the attachments and their thumbnails
+func attachmentKeys(attachments []models.Attachment) []string {
+	keys := make([]string, 0, 2*len(attachments))
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	return keys
+}
+
+// storeAttachments writes the uploads and their thumbnails to the blob store and
+// records them with db, removing the blobs again if anything fails
+func storeAttachments(c *gin.Context, db *gorm.DB, messageID uint, uploads []upload) ([]models.Attachment, error) {
+	ctx := c.Request.Context()
+	prefix := fmt.Sprintf("messages/%d", messageID)
+
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			storage.Store.Delete(ctx, key)
+		}
+	}
+
+	attachments := make([]models.Attachment, 0, len(uploads))
+	for _, u := range uploads {
+		key, err := storage.NewKey(prefix, extensionFor(u.contentType, u.filename))
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if err := storage.Store.Put(ctx, key, bytes.NewReader(u.data), u.contentType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, key)
+
+		attachment := models.Attachment{
+			MessageID:   messageID,
+			Filename:    u.filename,
+			ContentType: u.contentType,
+			Size:        int64(len(u.data)),
+			StorageKey:  key,
+		}
+
+		if storage.CanThumbnail(u.contentType) {
+			thumbnail, width, height, err := storage.GenerateThumbnail(u.data)
+			attachment.Width, attachment.Height = width, height
+			if err != nil {
+				// The original is still usable without a thumbnail
+				log.Printf("Failed to generate thumbnail for %s: %v", u.filename, err)
+			} else {
+				thumbKey, err := storage.NewKey(prefix+"/thumbnails", ".jpg")
+				if err == nil {
+					err = storage.Store.Put(ctx, thumbKey, bytes.NewReader(thumbnail), storage.ThumbnailContentType)
+				}
+				if err != nil {
+					cleanup()
+					return nil, err
+				}
+				stored = append(stored, thumbKey)
+				attachment.ThumbnailKey = thumbKey
+			}
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	if err := db.Create(&attachments).Error; err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// attachAttachments fills in the attachments of the given messages with signed URLs
+func attachAttachments(messages ...*models.Message) error {
+	ids := make([]uint, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+
+	var attachments []models.Attachment
+	if err := database.DB.Where("message_id IN ?", ids).Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	signAttachmentURLs(attachments)
+
+	byMessage := make(map[uint][]models.Attachment)
+	for _, attachment := range attachments {
+		byMessage[attachment.MessageID] = append(byMessage[attachment.MessageID], attachment)
+	}
+	for _, message := range messages {
+		message.Attachments = byMessage[message.ID]
+	}
+
+	return nil
+}
+
+// signAttachmentURLs sets the signed download URLs of the attachments
+func signAttachmentURLs(attachments []models.Attachment) {
+	if storage.Signer == nil {
+		return
+	}
+
+	for i := range attachments {
+		path := fmt.Sprintf("/api/v1/attachments/%d/download", attachments[i].ID)
+		attachments[i].URL = storage.Signer.SignedURL(path, attachments[i].ID, "")
+		if attachments[i].ThumbnailKey != "" {
+			attachments[i].ThumbnailURL = storage.Signer.SignedURL(path, attachments[i].ID, thumbnailVariant)
+		}
+	}
+}
+
+// detectContentType sniffs the media type of data without any parameters
+func detectContentType(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// extensionFor picks a file extension for stored blobs, preferring the uploaded
+// filename's extension when it matches the detected type
+func extensionFor(contentType, filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != "" && mime.TypeByExtension(ext) != "" {
+		if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil && mediaType == contentType {
+			return ext
+		}
+	}
+
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// sanitizeFilename strips any path from an uploaded filename and bounds its length
+func sanitizeFilename(filename string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	if len(name) > maxFilenameLength {
+		name = name[len(name)-maxFilenameLength:]
+	}
+	return name
+}
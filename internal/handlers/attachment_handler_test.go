@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	assert.Equal(t, "passwd", sanitizeFilename("../../etc/passwd"))
+	assert.Equal(t, "report.pdf", sanitizeFilename(`C:\Users\alice\report.pdf`))
+	assert.Equal(t, "file", sanitizeFilename(""))
+}
+
+func TestUploadAndDownloadAttachment(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	store, _ := storage.NewLocalStore(t.TempDir())
+	storage.Store = store
+	storage.Signer = storage.NewURLSigner([]byte("test-key"), time.Minute)
+
+	attachmentHandler := NewAttachmentHandler(nil, config.StorageConfig{
+		MaxUploadMB:  1,
+		AllowedTypes: []string{"image/png"},
+	})
+	router.POST("/api/v1/messages/:id/attachments", attachmentHandler.UploadAttachments)
+	router.GET("/api/v1/attachments/:id/download", attachmentHandler.DownloadAttachment)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	db.Create(&models.Message{GroupID: group.ID, User: "testuser", Content: "Dashboard screenshot"})
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 600, 300)))
+
+	upload := func(filename string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write(data)
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", "/api/v1/messages/1/attachments", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("graph.png", img.Bytes())
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var attachments []models.Attachment
+	json.Unmarshal(w.Body.Bytes(), &attachments)
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, "image/png", attachments[0].ContentType)
+	assert.Equal(t, 600, attachments[0].Width)
+	assert.NotEmpty(t, attachments[0].ThumbnailURL)
+
+	// Signed URLs download the file and thumbnail
+	for _, url := range []string{attachments[0].URL, attachments[0].ThumbnailURL} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Unsigned downloads are rejected
+	req, _ := http.NewRequest("GET", "/api/v1/attachments/1/download", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Disallowed types are rejected by content, not filename
+	w = upload("notes.png", []byte("just some text"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Context keys set by BotHandler.Authenticate
+const (
+	botContextKey       = "bot"
+	botScopesContextKey = "bot_scopes"
+)
+
+// botTokenPrefix makes bot tokens recognizable, e.g. in secret scanners
+const botTokenPrefix = "bot_"
+
+const (
+	defaultBotMessageLimit = 50
+	maxBotMessageLimit     = 200
+)
+
+// botScopes lists the scopes a bot token can be granted
+var botScopes = []string{
+	models.BotScopeMessagesRead,
+	models.BotScopeMessagesWrite,
+	models.BotScopeEvents,
+	models.BotScopeGroups,
+}
+
+// BotHandler handles bot administration and the bot API
+type BotHandler struct {
+	sseHandler     *SSEHandler
+	messageHandler *MessageHandler
+}
+
+// NewBotHandler creates a new bot handler. Bot messages are posted through the
+// message handler and bot event streams are served by the SSE handler.
+func NewBotHandler(sseHandler *SSEHandler, messageHandler *MessageHandler) *BotHandler {
+	return &BotHandler{
+		sseHandler:     sseHandler,
+		messageHandler: messageHandler,
+	}
+}
+
+// GetBots handles GET /api/v1/admin/bots
+func (h *BotHandler) GetBots(c *gin.Context) {
+	var bots []models.Bot
+	if err := database.DB.Order("name").Find(&bots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bots)
+}
+
+// CreateBot handles POST /api/v1/admin/bots
+func (h *BotHandler) CreateBot(c *gin.Context) {
+	var req models.CreateBotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimPrefix(strings.TrimSpace(req.Name), "@")
+	if name == "" || strings.ContainsAny(name, " \t\n@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bot names cannot contain spaces or @"})
+		return
+	}
+
+	taken, err := messages.IsBotName(database.DB, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "A bot with this name already exists"})
+		return
+	}
+
+	bot := models.Bot{Name: name, Description: req.Description, Owner: req.Owner}
+	if err := database.DB.Create(&bot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bot)
+}
+
+// DeleteBot handles DELETE /api/v1/admin/bots/:id
+// Its tokens and memberships are deleted with it; its messages are kept.
+func (h *BotHandler) DeleteBot(c *gin.Context) {
+	bot, ok := findBot(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&bot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bot"})
+		return
+	}
+	if h.sseHandler != nil {
+		h.sseHandler.SetBotGroups(bot.Name, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bot deleted successfully"})
+}
+
+// GetBotTokens handles GET /api/v1/admin/bots/:id/tokens
+func (h *BotHandler) GetBotTokens(c *gin.Context) {
+	bot, ok := findBot(c)
+	if !ok {
+		return
+	}
+
+	var tokens []models.BotToken
+	if err := database.DB.Where("bot_id = ?", bot.ID).Order("id").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateBotToken handles POST /api/v1/admin/bots/:id/tokens
+// The token is only returned in this response.
+func (h *BotHandler) CreateBotToken(c *gin.Context) {
+	bot, ok := findBot(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateBotTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !validBotScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope " + scope + "; valid scopes are " + strings.Join(botScopes, ", ")})
+			return
+		}
+	}
+
+	token, err := newBotToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	botToken := models.BotToken{
+		BotID:     bot.ID,
+		Name:      req.Name,
+		TokenHash: hashBotToken(token),
+		Scopes:    req.Scopes,
+	}
+	if err := database.DB.Create(&botToken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	botToken.Token = token
+	c.JSON(http.StatusCreated, botToken)
+}
+
+// DeleteBotToken handles DELETE /api/v1/admin/bot-tokens/:id
+func (h *BotHandler) DeleteBotToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	result := database.DB.Delete(&models.BotToken{}, uint(id))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+// Authenticate is a middleware that requires a bot token as bearer token and makes
+// the bot and the token's scopes available to the bot API handlers
+func (h *BotHandler) Authenticate(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !strings.HasPrefix(token, botTokenPrefix) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Bot token required"})
+		c.Abort()
+		return
+	}
+
+	var botToken models.BotToken
+	err := database.DB.Preload("Bot").Where("token_hash = ?", hashBotToken(token)).First(&botToken).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid bot token"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		c.Abort()
+		return
+	}
+
+	database.DB.Model(&botToken).UpdateColumn("last_used_at", time.Now())
+
+	c.Set(botContextKey, botToken.Bot)
+	c.Set(botScopesContextKey, botToken.Scopes)
+	c.Next()
+}
+
+// RequireBotScope creates a middleware that rejects bot tokens without the scope
+func RequireBotScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, granted := range c.GetStringSlice(botScopesContextKey) {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token lacks the " + scope + " scope"})
+		c.Abort()
+	}
+}
+
+// BotRateLimitKey identifies the authenticated bot for rate limiting
+func BotRateLimitKey(c *gin.Context) string {
+	return "bot:" + currentBot(c).Name
+}
+
+// GetMe handles GET /api/v1/bot/me
+func (h *BotHandler) GetMe(c *gin.Context) {
+	bot := currentBot(c)
+
+	groups, err := botGroupIDs(bot.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bot":       bot,
+		"scopes":    c.GetStringSlice(botScopesContextKey),
+		"group_ids": groups,
+	})
+}
+
+// GetGroups handles GET /api/v1/bot/groups
+func (h *BotHandler) GetGroups(c *gin.Context) {
+	var memberships []models.BotMembership
+	if err := database.DB.Preload("Group").
+		Where("bot_id = ?", currentBot(c).ID).
+		Order("group_id").
+		Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, memberships)
+}
+
+// JoinGroup handles POST /api/v1/bot/groups/:id/join
+func (h *BotHandler) JoinGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	bot := currentBot(c)
+
+	membership := models.BotMembership{BotID: bot.ID, GroupID: group.ID}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join group"})
+		return
+	}
+	h.refreshStreams(bot)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined group", "group_id": group.ID})
+}
+
+// LeaveGroup handles POST /api/v1/bot/groups/:id/leave
+func (h *BotHandler) LeaveGroup(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	bot := currentBot(c)
+
+	if err := database.DB.Where("bot_id = ? AND group_id = ?", bot.ID, group.ID).Delete(&models.BotMembership{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave group"})
+		return
+	}
+	h.refreshStreams(bot)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left group", "group_id": group.ID})
+}
+
+// PostMessage handles POST /api/v1/bot/messages
+// The bot must have joined the group. Content is stored verbatim; slash commands
+// are not run for bots.
+func (h *BotHandler) PostMessage(c *gin.Context) {
+	var req models.BotMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bot := currentBot(c)
+	if !requireBotMembership(c, bot, req.GroupID) {
+		return
+	}
+
+	message, err := h.messageHandler.publishMessage(models.Message{
+		GroupID: req.GroupID,
+		User:    bot.Name,
+		Content: req.Content,
+		Bot:     true,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetMessages handles GET /api/v1/bot/groups/:id/messages
+// Messages are returned oldest first; pass after_id to page forward.
+func (h *BotHandler) GetMessages(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	if !requireBotMembership(c, currentBot(c), group.ID) {
+		return
+	}
+
+	query := database.DB.Where("group_id = ?", group.ID)
+	if value := c.Query("after_id"); value != "" {
+		afterID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after_id"})
+			return
+		}
+		query = query.Where("id > ?", uint(afterID))
+	}
+
+	limit := defaultBotMessageLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxBotMessageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var messages []models.Message
+	if err := query.Order("id").Limit(limit).Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+	if err := enrichMessageSlice(messages); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// StreamEvents handles GET /api/v1/bot/events
+// Only events of the groups the bot has joined are sent, following joins and leaves.
+func (h *BotHandler) StreamEvents(c *gin.Context) {
+	bot := currentBot(c)
+	groups, err := botGroupIDs(bot.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		return
+	}
+
+	h.sseHandler.StreamBotEvents(c, bot.Name, groups)
+}
+
+// refreshStreams updates the open event streams of a bot after it joined or left a group
+func (h *BotHandler) refreshStreams(bot models.Bot) {
+	if h.sseHandler == nil {
+		return
+	}
+	if groups, err := botGroupIDs(bot.ID); err == nil {
+		h.sseHandler.SetBotGroups(bot.Name, groups)
+	}
+}
+
+// currentBot returns the bot authenticated by BotHandler.Authenticate
+func currentBot(c *gin.Context) models.Bot {
+	bot, _ := c.MustGet(botContextKey).(models.Bot)
+	return bot
+}
+
+// requireBotMembership writes a 403 response and returns false unless the bot has
+// joined the group
+func requireBotMembership(c *gin.Context, bot models.Bot, groupID uint) bool {
+	var count int64
+	if err := database.DB.Model(&models.BotMembership{}).
+		Where("bot_id = ? AND group_id = ?", bot.ID, groupID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if count == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Bot has not joined this group"})
+		return false
+	}
+	return true
+}
+
+// botGroupIDs returns the groups a bot has joined
+func botGroupIDs(botID uint) ([]uint, error) {
+	groups := []uint{}
+	err := database.DB.Model(&models.BotMembership{}).Where("bot_id = ?", botID).Order("group_id").Pluck("group_id", &groups).Error
+	return groups, err
+}
+
+func validBotScope(scope string) bool {
+	for _, valid := range botScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// newBotToken generates a random bot token
+func newBotToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return botTokenPrefix + hex.EncodeToString(b), nil
+}
+
+// hashBotToken returns the stored form of a bot token
+func hashBotToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findBot loads the bot named by the :id parameter, writing the error response and
+// returning false if it cannot
+func findBot(c *gin.Context) (models.Bot, bool) {
+	var bot models.Bot
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bot ID"})
+		return bot, false
+	}
+
+	if err := database.DB.First(&bot, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bot not found"})
+			return bot, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return bot, false
+	}
+
+	return bot, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBotAPI(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	botHandler := NewBotHandler(nil, NewMessageHandler(nil, nil, commands.Default))
+	router.POST("/api/v1/admin/bots", botHandler.CreateBot)
+	router.POST("/api/v1/admin/bots/:id/tokens", botHandler.CreateBotToken)
+	bot := router.Group("/api/v1/bot", botHandler.Authenticate)
+	bot.GET("/me", botHandler.GetMe)
+	bot.POST("/groups/:id/join", RequireBotScope(models.BotScopeGroups), botHandler.JoinGroup)
+	bot.GET("/groups/:id/messages", RequireBotScope(models.BotScopeMessagesRead), botHandler.GetMessages)
+	bot.POST("/messages", RequireBotScope(models.BotScopeMessagesWrite), botHandler.PostMessage)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("POST", "/api/v1/admin/bots", "", models.CreateBotRequest{Name: "deploybot", Owner: "alice"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.Bot
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	// Bot names are unique regardless of case
+	w = request("POST", "/api/v1/admin/bots", "", models.CreateBotRequest{Name: "DeployBot"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	tokenPath := fmt.Sprintf("/api/v1/admin/bots/%d/tokens", created.ID)
+	w = request("POST", tokenPath, "", models.CreateBotTokenRequest{Name: "ci", Scopes: []string{"messages:everything"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request("POST", tokenPath, "", models.CreateBotTokenRequest{
+		Name:   "ci",
+		Scopes: []string{models.BotScopeGroups, models.BotScopeMessagesWrite},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var token models.BotToken
+	json.Unmarshal(w.Body.Bytes(), &token)
+	assert.Contains(t, token.Token, botTokenPrefix)
+
+	// Tokens are required and checked
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/v1/bot/me", "", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/v1/bot/me", "bot_invalid", nil).Code)
+	assert.Equal(t, http.StatusOK, request("GET", "/api/v1/bot/me", token.Token, nil).Code)
+
+	// Posting requires membership of the group
+	message := models.BotMessageRequest{GroupID: group.ID, Content: "Deployed v1.2.3"}
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/bot/messages", token.Token, message).Code)
+
+	groupPath := fmt.Sprintf("/api/v1/bot/groups/%d", group.ID)
+	assert.Equal(t, http.StatusOK, request("POST", groupPath+"/join", token.Token, nil).Code)
+
+	w = request("POST", "/api/v1/bot/messages", token.Token, message)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var posted models.Message
+	json.Unmarshal(w.Body.Bytes(), &posted)
+	assert.True(t, posted.Bot)
+	assert.Equal(t, "deploybot", posted.User)
+
+	// The token was not granted messages:read
+	assert.Equal(t, http.StatusForbidden, request("GET", groupPath+"/messages", token.Token, nil).Code)
+
+	// Humans cannot post under a bot's name, through any API
+	w = request("POST", "/api/v1/messages", "", models.CreateMessageRequest{GroupID: group.ID, User: "DeployBot", Content: "hi"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	pollHandler := NewPollHandler(nil, NewMessageHandler(nil, nil, nil))
+	router.POST("/api/v1/polls", pollHandler.CreatePoll)
+	w = request("POST", "/api/v1/polls", "", models.CreatePollRequest{GroupID: group.ID, User: "deploybot", Question: "Ship it?", Options: []string{"Yes", "No"}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	reminderHandler := NewReminderHandler(nil)
+	router.POST("/api/v1/groups/:id/reminders", reminderHandler.CreateReminder)
+	w = request("POST", fmt.Sprintf("/api/v1/groups/%d/reminders", group.ID), "", models.ReminderRequest{Name: "Deploys", Schedule: "0 9 * * *", User: "deploybot", Content: "Deploy day"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Jobs posting later are refused at the shared posting path
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return messages.Create(tx, &models.Message{GroupID: group.ID, User: "deploybot", Content: "hi"})
+	})
+	assert.ErrorIs(t, err, messages.ErrReservedName)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/unfurl"
+)
+
+// enrichMessages fills in the fields of messages that are not stored on the
+// messages table, such as reaction counts, attachments, poll results and link previews
+func enrichMessages(messages ...*models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if err := attachReactions(messages...); err != nil {
+		return err
+	}
+	if err := attachAttachments(messages...); err != nil {
+		return err
+	}
+	if err := attachPolls(messages...); err != nil {
+		return err
+	}
+	return attachLinkPreviews(messages...)
+}
+
+// attachLinkPreviews fills in the cached previews of the links in each message
+func attachLinkPreviews(messages ...*models.Message) error {
+	urlsByMessage := make(map[*models.Message][]string, len(messages))
+	var urls []string
+	for _, message := range messages {
+		messageURLs := unfurl.ExtractURLs(message.Content)
+		urlsByMessage[message] = messageURLs
+		urls = append(urls, messageURLs...)
+	}
+
+	previews, err := unfurl.Lookup(urls)
+	if err != nil {
+		return err
+	}
+
+	byURL := make(map[string]models.LinkPreview, len(previews))
+	for _, preview := range previews {
+		byURL[preview.URL] = preview
+	}
+
+	for _, message := range messages {
+		message.LinkPreviews = nil
+		for _, u := range urlsByMessage[message] {
+			if preview, ok := byURL[u]; ok {
+				message.LinkPreviews = append(message.LinkPreviews, preview)
+			}
+		}
+	}
+
+	return nil
+}
+
+// enrichMessageSlice enriches a slice of messages in place
+func enrichMessageSlice(messages []models.Message) error {
+	pointers := make([]*models.Message, len(messages))
+	for i := range messages {
+		pointers[i] = &messages[i]
+	}
+	return enrichMessages(pointers...)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestError is a failure answered with an HTTP status and error message. Logic
+// shared by the REST, gRPC and GraphQL APIs returns it so that they all report the
+// same errors.
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func newRequestError(status int, message string) error {
+	return &requestError{status: status, message: message}
+}
+
+// errReservedName answers posting under a bot's name without the bot's token
+var errReservedName = newRequestError(http.StatusForbidden, "This name belongs to a bot; post with its token through the bot API")
+
+// checkPoster returns errReservedName if messages cannot be posted as user because
+// it is a bot's name. Posting checks this anyway; requests that post later, like
+// scheduled messages and reminders, check it up front.
+func checkPoster(user string) error {
+	reserved, err := messages.IsBotName(database.DB, user)
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Database error")
+	}
+	if reserved {
+		return errReservedName
+	}
+	return nil
+}
+
+// publishError converts an error from publishing a message into the error answered
+// to the client, reporting failure for anything but a rejected name
+func publishError(err error, failure string) error {
+	if errors.Is(err, messages.ErrReservedName) {
+		return errReservedName
+	}
+	return newRequestError(http.StatusInternalServerError, failure)
+}
+
+// respondError writes the error response for an error of the shared logic
+func respondError(c *gin.Context, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// messageFilters holds the optional filters shared by message listing and search
+type messageFilters struct {
+	groupID  uint
+	user     string
+	since    *time.Time
+	until    *time.Time
+	before   *time.Time // exclusive end of a date-only until, the day after it
+	contains string
+	hasLink  *bool
+}
+
+// maxMessagePageLimit caps the limit of one page of GET /messages
+const maxMessagePageLimit = 1000
+
+// messagePage selects one page of a message listing by keyset: the messages posted
+// after after_id and before before_id, at most limit of them
+type messagePage struct {
+	afterID  uint
+	beforeID uint
+	limit    int
+}
+
+// messageFields is the set of JSON field names that can be requested with fields=
+var messageFields = jsonFieldNames(reflect.TypeOf(models.Message{}))
+
+// parseMessageFilters reads the filter query parameters from the request
+func parseMessageFilters(c *gin.Context) (messageFilters, error) {
+	var filters messageFilters
+
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			return filters, fmt.Errorf("Invalid group_id")
+		}
+		filters.groupID = uint(id)
+	}
+
+	filters.user = c.Query("user")
+	filters.contains = c.Query("contains")
+
+	var err error
+	if filters.since, err = parseTimeParam(c.Query("since")); err != nil {
+		return filters, fmt.Errorf("Invalid since")
+	}
+	until, err := parseTimeParam(c.Query("until"))
+	if err != nil {
+		return filters, fmt.Errorf("Invalid until")
+	}
+	if until != nil && isDateParam(c.Query("until")) {
+		// A date includes the whole day
+		end := until.Add(24 * time.Hour)
+		filters.before = &end
+	} else {
+		filters.until = until
+	}
+
+	if value := c.Query("has_link"); value != "" {
+		hasLink, err := strconv.ParseBool(value)
+		if err != nil {
+			return filters, fmt.Errorf("Invalid has_link")
+		}
+		filters.hasLink = &hasLink
+	}
+
+	return filters, nil
+}
+
+// apply narrows a message query by the filters
+func (f messageFilters) apply(query *gorm.DB) *gorm.DB {
+	if f.groupID != 0 {
+		query = query.Where("messages.group_id = ?", f.groupID)
+	}
+	if f.user != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Table: "messages", Name: "user"}, Value: f.user})
+	}
+	if f.since != nil {
+		query = query.Where("messages.created_at >= ?", *f.since)
+	}
+	if f.until != nil {
+		query = query.Where("messages.created_at <= ?", *f.until)
+	}
+	if f.before != nil {
+		query = query.Where("messages.created_at < ?", *f.before)
+	}
+	if f.contains != "" {
+		query = query.Where("LOWER(messages.content) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(f.contains))+"%")
+	}
+	if f.hasLink != nil {
+		linkCondition := "(messages.content LIKE '%http://%' OR messages.content LIKE '%https://%')"
+		if *f.hasLink {
+			query = query.Where(linkCondition)
+		} else {
+			query = query.Not(linkCondition)
+		}
+	}
+	return query
+}
+
+// parseMessagePage reads the after_id, before_id and limit query parameters. Without
+// a limit the whole listing is returned.
+func parseMessagePage(c *gin.Context) (messagePage, error) {
+	var page messagePage
+
+	for _, param := range []struct {
+		name   string
+		target *uint
+	}{{"after_id", &page.afterID}, {"before_id", &page.beforeID}} {
+		if value := c.Query(param.name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return page, fmt.Errorf("Invalid %s", param.name)
+			}
+			*param.target = uint(id)
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxMessagePageLimit {
+			return page, fmt.Errorf("Invalid limit")
+		}
+		page.limit = limit
+	}
+
+	return page, nil
+}
+
+// apply narrows a message query, ordered by created_at and id, to the page. The
+// cursors compare by the same key as the order so that pages never skip or repeat
+// messages posted in the same instant.
+func (p messagePage) apply(query *gorm.DB) *gorm.DB {
+	if p.afterID != 0 {
+		query = query.Where("(messages.created_at, messages.id) > (SELECT created_at, id FROM messages WHERE id = ?)", p.afterID)
+	}
+	if p.beforeID != 0 {
+		query = query.Where("(messages.created_at, messages.id) < (SELECT created_at, id FROM messages WHERE id = ?)", p.beforeID)
+	}
+	if p.limit > 0 {
+		query = query.Limit(p.limit)
+	}
+	return query
+}
+
+// parseSortDirection reads the sort query parameter, defaulting to ascending
+func parseSortDirection(c *gin.Context) (string, error) {
+	return sortDirection(c.DefaultQuery("sort", "asc"))
+}
+
+// sortDirection turns "asc" or "desc" into the SQL sort direction
+func sortDirection(sort string) (string, error) {
+	switch strings.ToLower(sort) {
+	case "asc":
+		return "ASC", nil
+	case "desc":
+		return "DESC", nil
+	default:
+		return "", fmt.Errorf("Invalid sort, must be asc or desc")
+	}
+}
+
+// parseFields reads the comma-separated fields query parameter. It returns nil when
+// all fields should be returned.
+func parseFields(c *gin.Context) ([]string, error) {
+	value := c.Query("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !messageFields[field] {
+			return nil, fmt.Errorf("Invalid field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// selectFields projects messages down to the requested JSON fields
+func selectFields(messages []models.Message, fields []string) ([]map[string]json.RawMessage, error) {
+	projected := make([]map[string]json.RawMessage, 0, len(messages))
+	for _, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				selected[field] = value
+			}
+		}
+		projected = append(projected, selected)
+	}
+
+	return projected, nil
+}
+
+// containsField reports whether field is among the requested fields
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldNames returns the JSON names of a struct type's exported fields
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp or YYYY-MM-DD date query parameter
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &t, nil
+}
+
+// isDateParam reports whether a time query parameter is a YYYY-MM-DD date
+func isDateParam(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
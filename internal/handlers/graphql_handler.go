@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sre-chat-api/internal/complexity"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/ratelimit"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// maxGraphQLQueryLength bounds the size of a query document in bytes
+const maxGraphQLQueryLength = 64 << 10
+
+// graphqlWSProtocol is the WebSocket subprotocol of GraphQL subscriptions, as
+// spoken by the graphql-ws client library
+const graphqlWSProtocol = "graphql-transport-ws"
+
+// graphqlWSInitTimeout is how long a WebSocket may stay open without connection_init
+const graphqlWSInitTimeout = 10 * time.Second
+
+// graphqlWSPingInterval is how often idle WebSockets are pinged to keep them open
+const graphqlWSPingInterval = 30 * time.Second
+
+// maxGraphQLWSOperations bounds the operations running at once on one WebSocket
+const maxGraphQLWSOperations = 20
+
+// GraphQLHandler serves the GraphQL API. Queries and mutations run the logic of the
+// REST handlers, and subscriptions over WebSocket receive the events of SSE streams.
+type GraphQLHandler struct {
+	schema   *graphql.Schema
+	cfg      config.GraphQLConfig
+	upgrader websocket.Upgrader
+}
+
+// NewGraphQLHandler creates a new GraphQL handler. createMessage shares the limiter
+// of POST /api/v1/messages.
+func NewGraphQLHandler(cfg config.GraphQLConfig, sseHandler *SSEHandler, messageHandler *MessageHandler, groupHandler *GroupHandler, reactionHandler *ReactionHandler, limiter *ratelimit.Limiter) *GraphQLHandler {
+	resolver := &graphqlResolver{
+		sseHandler:      sseHandler,
+		messageHandler:  messageHandler,
+		groupHandler:    groupHandler,
+		reactionHandler: reactionHandler,
+		limiter:         limiter,
+	}
+	return &GraphQLHandler{
+		schema: graphql.MustParseSchema(graphqlSchema, resolver,
+			graphql.UseStringDescriptions(),
+			graphql.SubscribeResolverTimeout(10*time.Second),
+		),
+		cfg: cfg,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{graphqlWSProtocol},
+			// Like SSE streams, subscriptions are open to any origin
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// graphqlRequest is a GraphQL request as sent over HTTP and WebSocket
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Serve handles POST /graphql, and GET /graphql for queries and WebSocket
+// subscriptions. The caller is identified like in the REST API (X-User header or
+// user query parameter).
+func (h *GraphQLHandler) Serve(c *gin.Context) {
+	if c.Request.Method == http.MethodGet && websocket.IsWebSocketUpgrade(c.Request) {
+		h.serveWebSocket(c)
+		return
+	}
+
+	var req graphqlRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				respondGraphQLError(c, http.StatusBadRequest, "Invalid variables")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		respondGraphQLError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	operation, errs := h.check(req)
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, &graphql.Response{Errors: errs})
+		return
+	}
+	switch {
+	case operation == "subscription":
+		respondGraphQLError(c, http.StatusBadRequest, "Subscriptions are served over WebSocket with the "+graphqlWSProtocol+" protocol")
+		return
+	case operation == "mutation" && c.Request.Method == http.MethodGet:
+		c.Header("Allow", http.MethodPost)
+		respondGraphQLError(c, http.StatusMethodNotAllowed, "Mutations must be sent with POST")
+		return
+	}
+
+	ctx := withGraphQLCaller(c.Request.Context(), graphqlCaller{user: currentUser(c), ip: c.ClientIP()})
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+func respondGraphQLError(c *gin.Context, status int, message string) {
+	c.JSON(status, &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}})
+}
+
+// check enforces the length, depth and complexity limits on a request and returns
+// the type of its operation. Requests that cannot be measured are refused, with the
+// schema's validation errors when it rejects them too.
+func (h *GraphQLHandler) check(req graphqlRequest) (string, []*gqlerrors.QueryError) {
+	if req.Query == "" {
+		return "", []*gqlerrors.QueryError{{Message: "query is required"}}
+	}
+	if len(req.Query) > maxGraphQLQueryLength {
+		return "", []*gqlerrors.QueryError{limitError("QUERY_TOO_LONG", "query length %d exceeds the limit of %d bytes", len(req.Query), maxGraphQLQueryLength)}
+	}
+
+	cost, err := complexity.Measure(h.schema.AST(), req.Query, req.OperationName, req.Variables, h.cfg.ListSize)
+	if err != nil {
+		if errs := h.schema.ValidateWithVariables(req.Query, req.Variables); len(errs) > 0 {
+			return "", errs
+		}
+		return "", []*gqlerrors.QueryError{limitError("QUERY_NOT_MEASURABLE", "query cannot be measured: %v", err)}
+	}
+	if h.cfg.MaxDepth > 0 && cost.Depth > h.cfg.MaxDepth {
+		return cost.Operation, []*gqlerrors.QueryError{limitError("QUERY_TOO_DEEP", "query depth %d exceeds the limit of %d", cost.Depth, h.cfg.MaxDepth)}
+	}
+	if h.cfg.MaxComplexity > 0 && cost.Complexity > h.cfg.MaxComplexity {
+		return cost.Operation, []*gqlerrors.QueryError{limitError("QUERY_TOO_COMPLEX", "query complexity %d exceeds the limit of %d", cost.Complexity, h.cfg.MaxComplexity)}
+	}
+	return cost.Operation, nil
+}
+
+func limitError(code, format string, args ...interface{}) *gqlerrors.QueryError {
+	return &gqlerrors.QueryError{
+		Message:    fmt.Sprintf(format, args...),
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// graphqlWSMessage is a message of the graphql-transport-ws protocol
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphqlWSConn is one WebSocket connection and the operations running on it
+type graphqlWSConn struct {
+	handler *GraphQLHandler
+	conn    *websocket.Conn
+	caller  graphqlCaller
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	operations map[string]context.CancelFunc
+}
+
+// serveWebSocket runs the graphql-transport-ws protocol: after connection_init is
+// acknowledged, each subscribe message runs an operation whose results are sent as
+// next messages until it completes or the client sends complete.
+func (h *GraphQLHandler) serveWebSocket(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the request
+		return
+	}
+	defer conn.Close()
+
+	if conn.Subprotocol() != graphqlWSProtocol {
+		closeWebSocket(conn, 4406, "Subprotocol not acceptable")
+		return
+	}
+
+	ws := &graphqlWSConn{
+		handler:    h,
+		conn:       conn,
+		caller:     graphqlCaller{user: currentUser(c), ip: c.ClientIP()},
+		operations: make(map[string]context.CancelFunc),
+	}
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	initTimer := time.AfterFunc(graphqlWSInitTimeout, func() {
+		closeWebSocket(conn, 4408, "Connection initialisation timeout")
+	})
+	defer initTimer.Stop()
+
+	go ws.keepAlive(ctx)
+
+	acknowledged := false
+	for {
+		var msg graphqlWSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok && ctx.Err() == nil {
+				closeWebSocket(conn, 4400, "Invalid message")
+			}
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if acknowledged {
+				closeWebSocket(conn, 4429, "Too many initialisation requests")
+				return
+			}
+			initTimer.Stop()
+			// Browsers cannot set headers on WebSockets, so the user may be given here
+			var payload struct {
+				User string `json:"user"`
+			}
+			if len(msg.Payload) > 0 {
+				json.Unmarshal(msg.Payload, &payload)
+			}
+			if payload.User != "" {
+				ws.caller.user = payload.User
+			}
+			acknowledged = true
+			ws.send(graphqlWSMessage{Type: "connection_ack"})
+		case "ping":
+			ws.send(graphqlWSMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acknowledged {
+				closeWebSocket(conn, 4401, "Unauthorized")
+				return
+			}
+			var req graphqlRequest
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				closeWebSocket(conn, 4400, "Invalid subscribe message")
+				return
+			}
+			if !ws.start(ctx, msg.ID, req) {
+				closeWebSocket(conn, 4409, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+		case "complete":
+			ws.stop(msg.ID)
+		default:
+			closeWebSocket(conn, 4400, "Unknown message type "+msg.Type)
+			return
+		}
+	}
+}
+
+// start runs an operation, reporting false when its ID is already in use. Beyond
+// maxGraphQLWSOperations running operations, new ones fail with an error message.
+func (ws *graphqlWSConn) start(ctx context.Context, id string, req graphqlRequest) bool {
+	ws.mu.Lock()
+	if _, ok := ws.operations[id]; ok {
+		ws.mu.Unlock()
+		return false
+	}
+	if len(ws.operations) >= maxGraphQLWSOperations {
+		ws.mu.Unlock()
+		ws.sendErrors(id, []*gqlerrors.QueryError{limitError("TOO_MANY_OPERATIONS", "at most %d operations may run at once on a connection", maxGraphQLWSOperations)})
+		return true
+	}
+	ctx, cancel := context.WithCancel(withGraphQLCaller(ctx, ws.caller))
+	ws.operations[id] = cancel
+	ws.mu.Unlock()
+
+	go func() {
+		defer ws.stop(id)
+
+		operation, errs := ws.handler.check(req)
+		if len(errs) > 0 {
+			ws.sendErrors(id, errs)
+			return
+		}
+
+		if operation != "subscription" {
+			resp := ws.handler.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+			if resp.Data == nil && len(resp.Errors) > 0 {
+				ws.sendErrors(id, resp.Errors)
+				return
+			}
+			ws.sendResult(id, resp)
+			ws.send(graphqlWSMessage{ID: id, Type: "complete"})
+			return
+		}
+
+		responses, subscribeErr := ws.handler.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+		if subscribeErr != nil {
+			ws.sendErrors(id, []*gqlerrors.QueryError{{Message: subscribeErr.Error()}})
+			return
+		}
+		first := true
+		for r := range responses {
+			resp := r.(*graphql.Response)
+			// Errors without data before any result mean the operation did not start
+			if first && resp.Data == nil && len(resp.Errors) > 0 {
+				ws.sendErrors(id, resp.Errors)
+				return
+			}
+			first = false
+			ws.sendResult(id, resp)
+		}
+		if ctx.Err() == nil {
+			ws.send(graphqlWSMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// stop cancels an operation; completing one that already finished is a no-op
+func (ws *graphqlWSConn) stop(id string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if cancel, ok := ws.operations[id]; ok {
+		cancel()
+		delete(ws.operations, id)
+	}
+}
+
+func (ws *graphqlWSConn) sendResult(id string, resp *graphql.Response) {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Failed to encode GraphQL result: %v", err)
+		return
+	}
+	ws.send(graphqlWSMessage{ID: id, Type: "next", Payload: payload})
+}
+
+func (ws *graphqlWSConn) sendErrors(id string, errs []*gqlerrors.QueryError) {
+	payload, err := json.Marshal(errs)
+	if err != nil {
+		log.Printf("Failed to encode GraphQL errors: %v", err)
+		return
+	}
+	ws.send(graphqlWSMessage{ID: id, Type: "error", Payload: payload})
+}
+
+// send writes a message; failed writes surface as read errors that end the connection
+func (ws *graphqlWSConn) send(msg graphqlWSMessage) {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	ws.conn.WriteJSON(msg)
+}
+
+// keepAlive pings the client until ctx is done
+func (ws *graphqlWSConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(graphqlWSPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// closeWebSocket closes a connection with a graphql-transport-ws close code
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	conn.Close()
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGraphQLConfig = config.GraphQLConfig{MaxDepth: 6, MaxComplexity: 500, ListSize: 10}
+
+// setupGraphQL routes /graphql to a handler backed by sseHandler
+func setupGraphQL(sseHandler *SSEHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	messageHandler := NewMessageHandler(sseHandler, nil, commands.Default)
+	handler := NewGraphQLHandler(testGraphQLConfig, sseHandler, messageHandler, NewGroupHandler(sseHandler), NewReactionHandler(sseHandler), nil)
+
+	router := gin.New()
+	router.POST("/graphql", handler.Serve)
+	router.GET("/graphql", handler.Serve)
+	return router
+}
+
+type graphqlTestResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, router *gin.Engine, user, query string, variables map[string]interface{}) (int, graphqlTestResponse) {
+	body, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp graphqlTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	router := setupGraphQL(nil)
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"too deep", `{ groups { messages { nodes { group { messages { nodes { id } } } } } } }`, "QUERY_TOO_DEEP"},
+		{"too complex", `{ messages(first: 1000) { nodes { id } } }`, "QUERY_TOO_COMPLEX"},
+		{"too long", "{ groups { id } }" + strings.Repeat(" ", maxGraphQLQueryLength), "QUERY_TOO_LONG"},
+		// graphql-go accepts names that cannot be measured; they must not skip the limits
+		{"unmeasurable", `{ é: groups { messages { nodes { group { messages { nodes { id } } } } } } }`, "QUERY_NOT_MEASURABLE"},
+	}
+	for _, tt := range tests {
+		status, resp := postGraphQL(t, router, "", tt.query, nil)
+		assert.Equal(t, http.StatusBadRequest, status, tt.name)
+		require.Len(t, resp.Errors, 1, tt.name)
+		assert.Equal(t, tt.code, resp.Errors[0].Extensions["code"], tt.name)
+	}
+
+	// Introspection is not held to the depth limit
+	status, resp := postGraphQL(t, router, "", `{ __schema { types { name fields { type { ofType { ofType { name } } } } } } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+
+	// Page sizes are validated before anything is read
+	status, resp = postGraphQL(t, router, "", `query ($n: Int) { messages(first: $n) { nodes { id } } }`, map[string]interface{}{"n": 0})
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "BAD_REQUEST", resp.Errors[0].Extensions["code"])
+
+	// Queries that cannot be measured report the schema's errors when it rejects them
+	status, resp = postGraphQL(t, router, "", `{ groups { id } `, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	require.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0].Message, "syntax error")
+
+	// Subscriptions need a WebSocket
+	status, _ = postGraphQL(t, router, "", `subscription { events { type } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// GET runs queries but not mutations
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`{ __typename }`), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"__typename":"Query"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteMessage(id: "1") }`), nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+// dialGraphQL opens a graphql-transport-ws connection to a test server
+func dialGraphQL(t *testing.T, router *gin.Engine) *websocket.Conn {
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{graphqlWSProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	return conn
+}
+
+// readWS reads messages until one of the given type arrives
+func readWS(t *testing.T, conn *websocket.Conn, messageType string) graphqlWSMessage {
+	for {
+		var msg graphqlWSMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg.Type == messageType {
+			return msg
+		}
+	}
+}
+
+// eventOf decodes the event of a next message
+func eventOf(t *testing.T, msg graphqlWSMessage) map[string]interface{} {
+	var payload struct {
+		Data struct {
+			Events map[string]interface{} `json:"events"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload), string(msg.Payload))
+	return payload.Data.Events
+}
+
+// nextGraphQLEvent reads events until one of the given type arrives
+func nextGraphQLEvent(t *testing.T, conn *websocket.Conn, eventType string) map[string]interface{} {
+	for {
+		if event := eventOf(t, readWS(t, conn, "next")); event["type"] == eventType {
+			return event
+		}
+	}
+}
+
+func TestGraphQLSubscription(t *testing.T) {
+	sseHandler := NewSSEHandler(config.PresenceConfig{})
+	conn := dialGraphQL(t, setupGraphQL(sseHandler))
+
+	require.NoError(t, conn.WriteJSON(graphqlWSMessage{Type: "connection_init", Payload: json.RawMessage(`{"user":"alice"}`)}))
+	readWS(t, conn, "connection_ack")
+
+	subscribe := func(id, query string) {
+		payload, err := json.Marshal(graphqlRequest{Query: query})
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteJSON(graphqlWSMessage{ID: id, Type: "subscribe", Payload: payload}))
+	}
+
+	// Events start like an SSE stream and are filtered by group
+	subscribe("1", `subscription { events(groupId: "1") { id type data } }`)
+	assert.Equal(t, "connected", eventOf(t, readWS(t, conn, "next"))["type"])
+	sseHandler.NotifyTyping("bob", 2)
+	sseHandler.NotifyTyping("bob", 1)
+	typing := nextGraphQLEvent(t, conn, EventTyping)
+	assert.Equal(t, "bob", typing["data"].(map[string]interface{})["user"])
+	assert.Nil(t, typing["id"])
+
+	// Events addressed to the user given in connection_init reach them
+	sseHandler.NotifyUser("alice", SSEMessage{Type: EventReadPosition, Data: models.ReadPosition{User: "alice", GroupID: 2, LastReadMessageID: 7}})
+	position := nextGraphQLEvent(t, conn, EventReadPosition)
+	assert.NotEmpty(t, position["id"])
+
+	// Queries run once and complete; limits apply as over HTTP
+	subscribe("2", `{ __typename }`)
+	result := readWS(t, conn, "next")
+	for result.ID != "2" {
+		result = readWS(t, conn, "next")
+	}
+	assert.JSONEq(t, `{"data":{"__typename":"Query"}}`, string(result.Payload))
+	assert.Equal(t, "2", readWS(t, conn, "complete").ID)
+
+	subscribe("3", `{ messages(first: 1000) { nodes { id } } }`)
+	failed := readWS(t, conn, "error")
+	assert.Equal(t, "3", failed.ID)
+	assert.Contains(t, string(failed.Payload), "QUERY_TOO_COMPLEX")
+
+	require.NoError(t, conn.WriteJSON(graphqlWSMessage{Type: "ping"}))
+	readWS(t, conn, "pong")
+
+	// Subscription IDs cannot be reused while running
+	subscribe("1", `subscription { events { type } }`)
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, 4409, closeErr.Code)
+}
+
+func TestGraphQLOperationLimit(t *testing.T) {
+	conn := dialGraphQL(t, setupGraphQL(NewSSEHandler(config.PresenceConfig{})))
+	require.NoError(t, conn.WriteJSON(graphqlWSMessage{Type: "connection_init"}))
+	readWS(t, conn, "connection_ack")
+
+	payload := json.RawMessage(`{"query":"subscription { events { type } }"}`)
+	for i := 1; i <= maxGraphQLWSOperations+1; i++ {
+		require.NoError(t, conn.WriteJSON(graphqlWSMessage{ID: strconv.Itoa(i), Type: "subscribe", Payload: payload}))
+	}
+	failed := readWS(t, conn, "error")
+	assert.Equal(t, strconv.Itoa(maxGraphQLWSOperations+1), failed.ID)
+	assert.Contains(t, string(failed.Payload), "TOO_MANY_OPERATIONS")
+}
+
+func TestGraphQLSubscribeBeforeInit(t *testing.T) {
+	conn := dialGraphQL(t, setupGraphQL(NewSSEHandler(config.PresenceConfig{})))
+
+	require.NoError(t, conn.WriteJSON(graphqlWSMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { events { type } }"}`)}))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, 4401, closeErr.Code)
+}
+
+func TestGraphQLMessages(t *testing.T) {
+	db := setupTestDB(t)
+	database.DB = db
+	router := setupGraphQL(NewSSEHandler(config.PresenceConfig{}))
+
+	var group models.Group
+	require.NoError(t, db.Where("name = ?", "SRE Bootcamp").First(&group).Error)
+
+	// Mutations mirror POST /api/v1/messages
+	var ids []string
+	for _, content := range []string{"first", "second", "third"} {
+		status, resp := postGraphQL(t, router, "", `mutation ($content: String!) {
+			createMessage(input: {user: "alice", content: $content}) { message { id content group { name } } }
+		}`, map[string]interface{}{"content": content})
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, resp.Errors)
+		var created struct {
+			Message struct {
+				ID      string
+				Content string
+				Group   struct{ Name string }
+			}
+		}
+		require.NoError(t, json.Unmarshal(resp.Data["createMessage"], &created))
+		assert.Equal(t, content, created.Message.Content)
+		assert.Equal(t, "SRE Bootcamp", created.Message.Group.Name)
+		ids = append(ids, created.Message.ID)
+	}
+
+	_, resp := postGraphQL(t, router, "", `mutation ($id: ID!) { addReaction(messageId: $id, user: "bob", emoji: "👍") { emoji } }`, map[string]interface{}{"id": ids[0]})
+	require.Empty(t, resp.Errors)
+
+	// Groups, pages of messages, reactions and authors in one request
+	query := `query ($group: ID!, $after: ID) {
+		group(id: $group) {
+			name
+			unreadCount
+			messages(first: 2, after: $after) {
+				nodes { id content author { name presence { status } } reactions { emoji count users } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+	type page struct {
+		Group struct {
+			Name        string
+			UnreadCount int
+			Messages    struct {
+				Nodes []struct {
+					ID        string
+					Content   string
+					Author    struct{ Name string }
+					Reactions []models.ReactionSummary
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
+			}
+		}
+	}
+
+	_, resp = postGraphQL(t, router, "alice", query, map[string]interface{}{"group": strconv.Itoa(int(group.ID))})
+	require.Empty(t, resp.Errors)
+	var first page
+	require.NoError(t, json.Unmarshal(resp.Data["group"], &first.Group))
+	require.Len(t, first.Group.Messages.Nodes, 2)
+	assert.Equal(t, "first", first.Group.Messages.Nodes[0].Content)
+	assert.Equal(t, "alice", first.Group.Messages.Nodes[0].Author.Name)
+	require.Len(t, first.Group.Messages.Nodes[0].Reactions, 1)
+	assert.Equal(t, []string{"bob"}, first.Group.Messages.Nodes[0].Reactions[0].Users)
+	assert.True(t, first.Group.Messages.PageInfo.HasNextPage)
+
+	_, resp = postGraphQL(t, router, "alice", query, map[string]interface{}{"group": strconv.Itoa(int(group.ID)), "after": *first.Group.Messages.PageInfo.EndCursor})
+	require.Empty(t, resp.Errors)
+	var second page
+	require.NoError(t, json.Unmarshal(resp.Data["group"], &second.Group))
+	require.Len(t, second.Group.Messages.Nodes, 1)
+	assert.Equal(t, ids[2], second.Group.Messages.Nodes[0].ID)
+	assert.False(t, second.Group.Messages.PageInfo.HasNextPage)
+
+	// Errors carry the status of the REST API
+	_, resp = postGraphQL(t, router, "", `mutation { createMessage(input: {user: "alice", content: "hi", groupId: "99999"}) { message { id } } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "NOT_FOUND", resp.Errors[0].Extensions["code"])
+
+	_, resp = postGraphQL(t, router, "", `mutation ($id: ID!) { deleteMessage(id: $id) }`, map[string]interface{}{"id": ids[0]})
+	require.Empty(t, resp.Errors)
+	_, resp = postGraphQL(t, router, "", `query ($id: ID!) { message(id: $id) { id } }`, map[string]interface{}{"id": ids[0]})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `null`, string(resp.Data["message"]))
+}
@@ -0,0 +1,752 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/ratelimit"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"gorm.io/gorm"
+)
+
+// graphqlResolver is the root resolver of the GraphQL schema. Queries and mutations
+// run the logic of the REST handlers; subscriptions read the SSE event hub.
+type graphqlResolver struct {
+	sseHandler      *SSEHandler
+	messageHandler  *MessageHandler
+	groupHandler    *GroupHandler
+	reactionHandler *ReactionHandler
+	// limiter limits createMessage per client IP when set
+	limiter *ratelimit.Limiter
+}
+
+// graphqlCaller identifies who runs a GraphQL operation
+type graphqlCaller struct {
+	user string
+	ip   string
+}
+
+type graphqlCallerKey struct{}
+
+func withGraphQLCaller(ctx context.Context, caller graphqlCaller) context.Context {
+	return context.WithValue(ctx, graphqlCallerKey{}, caller)
+}
+
+func callerFrom(ctx context.Context) graphqlCaller {
+	caller, _ := ctx.Value(graphqlCallerKey{}).(graphqlCaller)
+	return caller
+}
+
+// graphqlError is the error of a GraphQL field. Its code extension names the HTTP
+// status the REST API answers the same failure with, e.g. NOT_FOUND.
+type graphqlError struct {
+	status  int
+	message string
+}
+
+func (e *graphqlError) Error() string {
+	return e.message
+}
+
+// Extensions implements the extensions of GraphQL errors
+func (e *graphqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   strings.ToUpper(strings.ReplaceAll(http.StatusText(e.status), " ", "_")),
+		"status": e.status,
+	}
+}
+
+func (e *graphqlError) queryError() *gqlerrors.QueryError {
+	return &gqlerrors.QueryError{Message: e.message, Extensions: e.Extensions(), ResolverError: e}
+}
+
+// toGraphQLError turns an error of the logic shared with the REST API into a
+// GraphQL error, hiding unexpected errors
+func toGraphQLError(err error) error {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return &graphqlError{status: reqErr.status, message: reqErr.message}
+	}
+	return &graphqlError{status: http.StatusInternalServerError, message: "Internal server error"}
+}
+
+// parseGraphQLID parses the ID of a record, naming the record in its error
+func parseGraphQLID(id graphql.ID, record string) (uint, error) {
+	n, err := strconv.ParseUint(string(id), 10, 32)
+	if err != nil || n == 0 {
+		return 0, &graphqlError{status: http.StatusBadRequest, message: "Invalid " + record + " ID"}
+	}
+	return uint(n), nil
+}
+
+func formatID(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
+
+// Groups resolves Query.groups
+func (r *graphqlResolver) Groups(ctx context.Context) ([]*groupResolver, error) {
+	summaries, err := groupSummaries(callerFrom(ctx).user)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	groups := make([]*groupResolver, len(summaries))
+	for i, summary := range summaries {
+		groups[i] = r.summarizedGroup(summary)
+	}
+	return groups, nil
+}
+
+// Group resolves Query.group
+func (r *graphqlResolver) Group(ctx context.Context, args struct{ ID graphql.ID }) (*groupResolver, error) {
+	id, err := parseGraphQLID(args.ID, "group")
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := groupSummaries(callerFrom(ctx).user, id)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	return r.summarizedGroup(summaries[0]), nil
+}
+
+type messagesArgs struct {
+	GroupID  *graphql.ID
+	User     *string
+	Contains *string
+	HasLink  *bool
+	Since    *graphql.Time
+	Until    *graphql.Time
+	Sort     string
+	First    int32
+	After    *graphql.ID
+}
+
+// Messages resolves Query.messages
+func (r *graphqlResolver) Messages(args messagesArgs) (*messageConnection, error) {
+	var filters messageFilters
+	if args.GroupID != nil {
+		id, err := parseGraphQLID(*args.GroupID, "group")
+		if err != nil {
+			return nil, err
+		}
+		filters.groupID = id
+	}
+	if args.User != nil {
+		filters.user = *args.User
+	}
+	if args.Contains != nil {
+		filters.contains = *args.Contains
+	}
+	filters.hasLink = args.HasLink
+	if args.Since != nil {
+		filters.since = &args.Since.Time
+	}
+	if args.Until != nil {
+		filters.until = &args.Until.Time
+	}
+	return r.messageConnection(filters, args.Sort, args.First, args.After)
+}
+
+// messageConnection lists one page of the messages matching the filters. The page
+// is read one message past first to tell whether another page follows.
+func (r *graphqlResolver) messageConnection(filters messageFilters, sort string, first int32, after *graphql.ID) (*messageConnection, error) {
+	direction, err := sortDirection(sort)
+	if err != nil {
+		return nil, &graphqlError{status: http.StatusBadRequest, message: err.Error()}
+	}
+	limit := int(first)
+	if limit < 1 || limit > maxMessagePageLimit {
+		return nil, &graphqlError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid first, must be between 1 and %d", maxMessagePageLimit)}
+	}
+
+	page := messagePage{limit: limit + 1}
+	if after != nil {
+		id, err := parseGraphQLID(*after, "cursor")
+		if err != nil {
+			return nil, err
+		}
+		if direction == "DESC" {
+			page.beforeID = id
+		} else {
+			page.afterID = id
+		}
+	}
+
+	messages, err := listMessages(filters, page, direction, true, true)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	connection := &messageConnection{}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		connection.hasNextPage = true
+	}
+	connection.nodes = make([]*messageResolver, len(messages))
+	for i, message := range messages {
+		connection.nodes[i] = r.message(message)
+	}
+	if len(messages) > 0 {
+		cursor := formatID(messages[len(messages)-1].ID)
+		connection.endCursor = &cursor
+	}
+	return connection, nil
+}
+
+// Message resolves Query.message
+func (r *graphqlResolver) Message(args struct{ ID graphql.ID }) (*messageResolver, error) {
+	id, err := parseGraphQLID(args.ID, "message")
+	if err != nil {
+		return nil, err
+	}
+	message, err := getMessage(id)
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) && reqErr.status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, toGraphQLError(err)
+	}
+	return r.message(message), nil
+}
+
+type createMessageInput struct {
+	User    string
+	Content string
+	GroupID *graphql.ID
+	SendAt  *graphql.Time
+}
+
+// CreateMessage resolves Mutation.createMessage. It is limited per client IP like
+// POST /api/v1/messages.
+func (r *graphqlResolver) CreateMessage(ctx context.Context, args struct{ Input createMessageInput }) (*createMessagePayload, error) {
+	if r.limiter != nil {
+		if allowed, _ := r.limiter.Allow(callerFrom(ctx).ip); !allowed {
+			return nil, &graphqlError{status: http.StatusTooManyRequests, message: "Rate limit exceeded"}
+		}
+	}
+
+	req := models.CreateMessageRequest{User: args.Input.User, Content: args.Input.Content}
+	if args.Input.GroupID != nil {
+		id, err := parseGraphQLID(*args.Input.GroupID, "group")
+		if err != nil {
+			return nil, err
+		}
+		req.GroupID = id
+	}
+	if args.Input.SendAt != nil {
+		req.SendAt = &args.Input.SendAt.Time
+	}
+
+	result, err := r.messageHandler.createMessage(ctx, req)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	payload := &createMessagePayload{scheduled: result.scheduled, command: result.command}
+	if result.message != nil {
+		payload.message = r.message(*result.message)
+	}
+	return payload, nil
+}
+
+// UpdateMessage resolves Mutation.updateMessage
+func (r *graphqlResolver) UpdateMessage(args struct {
+	ID      graphql.ID
+	Content string
+}) (*messageResolver, error) {
+	id, err := parseGraphQLID(args.ID, "message")
+	if err != nil {
+		return nil, err
+	}
+	message, err := r.messageHandler.updateMessage(id, args.Content)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return r.message(message), nil
+}
+
+// DeleteMessage resolves Mutation.deleteMessage
+func (r *graphqlResolver) DeleteMessage(args struct{ ID graphql.ID }) (graphql.ID, error) {
+	id, err := parseGraphQLID(args.ID, "message")
+	if err != nil {
+		return "", err
+	}
+	if err := deleteMessage(id); err != nil {
+		return "", toGraphQLError(err)
+	}
+	return args.ID, nil
+}
+
+type reactionArgs struct {
+	MessageID graphql.ID
+	User      string
+	Emoji     string
+}
+
+// reactionRequest validates a reaction like the binding of ReactionRequest and
+// loads the message it belongs to
+func reactionRequest(args reactionArgs) (models.Message, models.ReactionRequest, error) {
+	id, err := parseGraphQLID(args.MessageID, "message")
+	if err != nil {
+		return models.Message{}, models.ReactionRequest{}, err
+	}
+	req := models.ReactionRequest{User: args.User, Emoji: args.Emoji}
+	if req.User == "" || req.Emoji == "" || utf8.RuneCountInString(req.Emoji) > 64 {
+		return models.Message{}, req, &graphqlError{status: http.StatusBadRequest, message: "user and emoji of at most 64 characters are required"}
+	}
+	message, err := loadMessage(id)
+	if err != nil {
+		return message, req, toGraphQLError(err)
+	}
+	return message, req, nil
+}
+
+// AddReaction resolves Mutation.addReaction
+func (r *graphqlResolver) AddReaction(args reactionArgs) (*reactionResolver, error) {
+	message, req, err := reactionRequest(args)
+	if err != nil {
+		return nil, err
+	}
+	reaction, _, err := r.reactionHandler.addReaction(message, req)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &reactionResolver{reaction}, nil
+}
+
+// RemoveReaction resolves Mutation.removeReaction
+func (r *graphqlResolver) RemoveReaction(args reactionArgs) (*reactionResolver, error) {
+	message, req, err := reactionRequest(args)
+	if err != nil {
+		return nil, err
+	}
+	reaction, err := r.reactionHandler.removeReaction(message, req)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &reactionResolver{reaction}, nil
+}
+
+// MarkRead resolves Mutation.markRead
+func (r *graphqlResolver) MarkRead(ctx context.Context, args struct {
+	GroupID   graphql.ID
+	MessageID graphql.ID
+}) (*readPositionResolver, error) {
+	user := callerFrom(ctx).user
+	if user == "" {
+		return nil, &graphqlError{status: http.StatusUnauthorized, message: "User identity required (X-User header or user query parameter)"}
+	}
+	groupID, err := parseGraphQLID(args.GroupID, "group")
+	if err != nil {
+		return nil, err
+	}
+	messageID, err := parseGraphQLID(args.MessageID, "message")
+	if err != nil {
+		return nil, err
+	}
+	position, err := r.groupHandler.markRead(user, groupID, messageID)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &readPositionResolver{position}, nil
+}
+
+// Events resolves Subscription.events. Like SSE streams, subscriptions start with a
+// connected event, followed by a resync event when lastEventId cannot be resumed, or
+// else the missed events.
+func (r *graphqlResolver) Events(ctx context.Context, args struct {
+	GroupID     *graphql.ID
+	LastEventID *string
+}) (<-chan *eventResolver, error) {
+	// Errors of subscription fields only keep their extensions as query errors
+	if r.sseHandler == nil {
+		return nil, (&graphqlError{status: http.StatusServiceUnavailable, message: "Event streams are disabled"}).queryError()
+	}
+
+	client := sseClient{user: callerFrom(ctx).user}
+	if args.GroupID != nil {
+		id, err := parseGraphQLID(*args.GroupID, "group")
+		if err != nil {
+			return nil, err.(*graphqlError).queryError()
+		}
+		client.groupID = id
+	}
+	lastEventID := ""
+	if args.LastEventID != nil {
+		lastEventID = *args.LastEventID
+	}
+
+	events, missed, resumed := r.sseHandler.subscribe(client, lastEventID)
+	initial := []SSEMessage{{Type: "connected"}}
+	if !resumed {
+		initial = append(initial, SSEMessage{Type: EventResync})
+	}
+
+	out := make(chan *eventResolver)
+	go func() {
+		defer close(out)
+		defer r.sseHandler.unsubscribe(events, client)
+
+		send := func(event SSEMessage) bool {
+			select {
+			case out <- &eventResolver{event: event, r: r}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, event := range append(initial, missed...) {
+			if !send(event) {
+				return
+			}
+		}
+		for {
+			select {
+			case event := <-events:
+				if !send(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (r *graphqlResolver) message(message models.Message) *messageResolver {
+	return &messageResolver{message: message, r: r}
+}
+
+func (r *graphqlResolver) summarizedGroup(summary models.GroupSummary) *groupResolver {
+	g := &groupResolver{group: summary.Group, r: r}
+	g.once.Do(func() { g.summary = summary })
+	return g
+}
+
+// groupResolver resolves a Group. The caller's read position is loaded on first use
+// unless the group was listed with it.
+type groupResolver struct {
+	group models.Group
+	r     *graphqlResolver
+
+	once    sync.Once
+	summary models.GroupSummary
+	err     error
+}
+
+func (g *groupResolver) ID() graphql.ID          { return formatID(g.group.ID) }
+func (g *groupResolver) Name() string            { return g.group.Name }
+func (g *groupResolver) Description() string     { return g.group.Description }
+func (g *groupResolver) CreatedAt() graphql.Time { return graphql.Time{Time: g.group.CreatedAt} }
+func (g *groupResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: g.group.UpdatedAt} }
+func (g *groupResolver) loadSummary(ctx context.Context) (models.GroupSummary, error) {
+	g.once.Do(func() {
+		g.summary.Group = g.group
+		user := callerFrom(ctx).user
+		if user == "" {
+			return
+		}
+		summaries, err := groupSummaries(user, g.group.ID)
+		if err != nil {
+			g.err = toGraphQLError(err)
+		} else if len(summaries) > 0 {
+			g.summary = summaries[0]
+		}
+	})
+	return g.summary, g.err
+}
+
+func (g *groupResolver) LastReadMessageID(ctx context.Context) (*graphql.ID, error) {
+	summary, err := g.loadSummary(ctx)
+	if err != nil || summary.LastReadMessageID == 0 {
+		return nil, err
+	}
+	id := formatID(summary.LastReadMessageID)
+	return &id, nil
+}
+
+func (g *groupResolver) UnreadCount(ctx context.Context) (int32, error) {
+	summary, err := g.loadSummary(ctx)
+	return int32(summary.UnreadCount), err
+}
+
+func (g *groupResolver) Messages(args struct {
+	Sort  string
+	First int32
+	After *graphql.ID
+}) (*messageConnection, error) {
+	return g.r.messageConnection(messageFilters{groupID: g.group.ID}, args.Sort, args.First, args.After)
+}
+
+// messageConnection is one page of messages
+type messageConnection struct {
+	nodes       []*messageResolver
+	hasNextPage bool
+	endCursor   *graphql.ID
+}
+
+func (c *messageConnection) Nodes() []*messageResolver { return c.nodes }
+func (c *messageConnection) PageInfo() *pageInfo {
+	return &pageInfo{hasNextPage: c.hasNextPage, endCursor: c.endCursor}
+}
+
+type pageInfo struct {
+	hasNextPage bool
+	endCursor   *graphql.ID
+}
+
+func (p *pageInfo) HasNextPage() bool      { return p.hasNextPage }
+func (p *pageInfo) EndCursor() *graphql.ID { return p.endCursor }
+
+// messageResolver resolves a Message with whatever relations were loaded
+type messageResolver struct {
+	message models.Message
+	r       *graphqlResolver
+}
+
+func (m *messageResolver) ID() graphql.ID          { return formatID(m.message.ID) }
+func (m *messageResolver) GroupID() graphql.ID     { return formatID(m.message.GroupID) }
+func (m *messageResolver) User() string            { return m.message.User }
+func (m *messageResolver) Content() string         { return m.message.Content }
+func (m *messageResolver) Bot() bool               { return m.message.Bot }
+func (m *messageResolver) System() bool            { return m.message.System }
+func (m *messageResolver) CreatedAt() graphql.Time { return graphql.Time{Time: m.message.CreatedAt} }
+func (m *messageResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: m.message.UpdatedAt} }
+
+func (m *messageResolver) Group() (*groupResolver, error) {
+	group := m.message.Group
+	if group.ID == 0 {
+		if err := database.DB.First(&group, m.message.GroupID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &graphqlError{status: http.StatusNotFound, message: "Group not found"}
+			}
+			return nil, &graphqlError{status: http.StatusInternalServerError, message: "Database error"}
+		}
+	}
+	return &groupResolver{group: group, r: m.r}, nil
+}
+
+func (m *messageResolver) Author() *authorResolver {
+	return &authorResolver{name: m.message.User, bot: m.message.Bot, r: m.r}
+}
+
+func (m *messageResolver) Reactions() []*reactionSummaryResolver {
+	reactions := make([]*reactionSummaryResolver, len(m.message.Reactions))
+	for i, reaction := range m.message.Reactions {
+		reactions[i] = &reactionSummaryResolver{reaction}
+	}
+	return reactions
+}
+
+func (m *messageResolver) Attachments() []*attachmentResolver {
+	attachments := make([]*attachmentResolver, len(m.message.Attachments))
+	for i, attachment := range m.message.Attachments {
+		attachments[i] = &attachmentResolver{attachment}
+	}
+	return attachments
+}
+
+func (m *messageResolver) LinkPreviews() []*linkPreviewResolver {
+	previews := make([]*linkPreviewResolver, len(m.message.LinkPreviews))
+	for i, preview := range m.message.LinkPreviews {
+		previews[i] = &linkPreviewResolver{preview}
+	}
+	return previews
+}
+
+func (m *messageResolver) Poll() *jsonValue {
+	if m.message.Poll == nil {
+		return nil
+	}
+	return &jsonValue{m.message.Poll}
+}
+
+// authorResolver resolves the Author of a message
+type authorResolver struct {
+	name string
+	bot  bool
+	r    *graphqlResolver
+}
+
+func (a *authorResolver) Name() string { return a.name }
+func (a *authorResolver) Bot() bool    { return a.bot }
+
+func (a *authorResolver) Presence() *presenceResolver {
+	presence := models.Presence{User: a.name, Status: models.PresenceOffline}
+	if a.r.sseHandler != nil {
+		presence = a.r.sseHandler.presence.presence([]string{a.name})[0]
+	}
+	return &presenceResolver{presence}
+}
+
+type presenceResolver struct{ presence models.Presence }
+
+func (p *presenceResolver) Status() string { return p.presence.Status }
+func (p *presenceResolver) LastSeen() *graphql.Time {
+	if p.presence.LastSeen == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *p.presence.LastSeen}
+}
+
+type reactionSummaryResolver struct{ summary models.ReactionSummary }
+
+func (s *reactionSummaryResolver) Emoji() string   { return s.summary.Emoji }
+func (s *reactionSummaryResolver) Count() int32    { return int32(s.summary.Count) }
+func (s *reactionSummaryResolver) Users() []string { return s.summary.Users }
+
+type reactionResolver struct{ reaction models.Reaction }
+
+func (r *reactionResolver) ID() graphql.ID          { return formatID(r.reaction.ID) }
+func (r *reactionResolver) MessageID() graphql.ID   { return formatID(r.reaction.MessageID) }
+func (r *reactionResolver) User() string            { return r.reaction.User }
+func (r *reactionResolver) Emoji() string           { return r.reaction.Emoji }
+func (r *reactionResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.reaction.CreatedAt} }
+
+type attachmentResolver struct{ attachment models.Attachment }
+
+func (a *attachmentResolver) ID() graphql.ID      { return formatID(a.attachment.ID) }
+func (a *attachmentResolver) Filename() string    { return a.attachment.Filename }
+func (a *attachmentResolver) ContentType() string { return a.attachment.ContentType }
+func (a *attachmentResolver) Size() int32         { return int32(a.attachment.Size) }
+func (a *attachmentResolver) Width() *int32       { return optionalInt(a.attachment.Width) }
+func (a *attachmentResolver) Height() *int32      { return optionalInt(a.attachment.Height) }
+func (a *attachmentResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: a.attachment.CreatedAt}
+}
+func (a *attachmentResolver) URL() string           { return a.attachment.URL }
+func (a *attachmentResolver) ThumbnailURL() *string { return optionalString(a.attachment.ThumbnailURL) }
+
+type linkPreviewResolver struct{ preview models.LinkPreview }
+
+func (p *linkPreviewResolver) URL() string          { return p.preview.URL }
+func (p *linkPreviewResolver) Title() *string       { return optionalString(p.preview.Title) }
+func (p *linkPreviewResolver) Description() *string { return optionalString(p.preview.Description) }
+func (p *linkPreviewResolver) ImageURL() *string    { return optionalString(p.preview.ImageURL) }
+func (p *linkPreviewResolver) SiteName() *string    { return optionalString(p.preview.SiteName) }
+func (p *linkPreviewResolver) Type() *string        { return optionalString(p.preview.Type) }
+
+// createMessagePayload resolves the outcome of createMessage
+type createMessagePayload struct {
+	message   *messageResolver
+	scheduled *models.ScheduledMessage
+	command   *models.CommandResponse
+}
+
+func (p *createMessagePayload) Message() *messageResolver { return p.message }
+
+func (p *createMessagePayload) Scheduled() *scheduledMessageResolver {
+	if p.scheduled == nil {
+		return nil
+	}
+	return &scheduledMessageResolver{*p.scheduled}
+}
+
+func (p *createMessagePayload) Command() *commandResponseResolver {
+	if p.command == nil {
+		return nil
+	}
+	return &commandResponseResolver{*p.command}
+}
+
+type scheduledMessageResolver struct{ scheduled models.ScheduledMessage }
+
+func (s *scheduledMessageResolver) ID() graphql.ID      { return formatID(s.scheduled.ID) }
+func (s *scheduledMessageResolver) GroupID() graphql.ID { return formatID(s.scheduled.GroupID) }
+func (s *scheduledMessageResolver) User() string        { return s.scheduled.User }
+func (s *scheduledMessageResolver) Content() string     { return s.scheduled.Content }
+func (s *scheduledMessageResolver) SendAt() graphql.Time {
+	return graphql.Time{Time: s.scheduled.SendAt}
+}
+func (s *scheduledMessageResolver) Status() string { return s.scheduled.Status }
+
+type commandResponseResolver struct{ response models.CommandResponse }
+
+func (c *commandResponseResolver) Command() string     { return c.response.Command }
+func (c *commandResponseResolver) GroupID() graphql.ID { return formatID(c.response.GroupID) }
+func (c *commandResponseResolver) Text() string        { return c.response.Text }
+func (c *commandResponseResolver) Ephemeral() bool     { return c.response.Ephemeral }
+
+type readPositionResolver struct{ position models.ReadPosition }
+
+func (p *readPositionResolver) User() string        { return p.position.User }
+func (p *readPositionResolver) GroupID() graphql.ID { return formatID(p.position.GroupID) }
+func (p *readPositionResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: p.position.UpdatedAt}
+}
+func (p *readPositionResolver) LastReadMessageID() graphql.ID {
+	return formatID(p.position.LastReadMessageID)
+}
+
+// eventResolver resolves an Event of the SSE hub
+type eventResolver struct {
+	event SSEMessage
+	r     *graphqlResolver
+}
+
+func (e *eventResolver) ID() *string  { return optionalString(e.event.id) }
+func (e *eventResolver) Type() string { return e.event.Type }
+
+func (e *eventResolver) Message() *messageResolver {
+	if e.event.Message == nil {
+		return nil
+	}
+	return e.r.message(*e.event.Message)
+}
+
+func (e *eventResolver) Data() *jsonValue {
+	if e.event.Data == nil {
+		return nil
+	}
+	return &jsonValue{e.event.Data}
+}
+
+// jsonValue is a value of the JSON scalar, written as its JSON encoding
+type jsonValue struct {
+	value interface{}
+}
+
+// ImplementsGraphQLType maps jsonValue to the JSON scalar
+func (jsonValue) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL accepts any input value
+func (v *jsonValue) UnmarshalGraphQL(input interface{}) error {
+	v.value = input
+	return nil
+}
+
+// MarshalJSON writes the value as JSON
+func (v jsonValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.value)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalInt(n int) *int32 {
+	if n == 0 {
+		return nil
+	}
+	v := int32(n)
+	return &v
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupHandler handles group listing and read position HTTP requests
+type GroupHandler struct {
+	sseHandler *SSEHandler
+}
+
+// NewGroupHandler creates a new group handler
+func NewGroupHandler(sseHandler *SSEHandler) *GroupHandler {
+	return &GroupHandler{
+		sseHandler: sseHandler,
+	}
+}
+
+// unreadRow is the unread message count of one group
+type unreadRow struct {
+	GroupID uint
+	Count   int64
+}
+
+// GetGroups handles GET /api/v1/groups
+// When the caller is identified, each group carries their read position and unread count.
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	summaries, err := groupSummaries(currentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+// groupSummaries lists groups, or the groups with the given IDs, with the read
+// positions and unread counts of user when set
+func groupSummaries(user string, ids ...uint) ([]models.GroupSummary, error) {
+	query := database.DB.Order("id")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var groups []models.Group
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Failed to fetch groups")
+	}
+
+	summaries := make([]models.GroupSummary, len(groups))
+	for i, group := range groups {
+		summaries[i].Group = group
+	}
+
+	user = strings.ToLower(user)
+	if user == "" || len(groups) == 0 {
+		return summaries, nil
+	}
+
+	var positions []models.ReadPosition
+	if err := database.DB.Where("read_positions.user = ?", user).Find(&positions).Error; err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Failed to fetch read positions")
+	}
+	lastRead := make(map[uint]uint, len(positions))
+	for _, position := range positions {
+		lastRead[position.GroupID] = position.LastReadMessageID
+	}
+
+	// The caller's own messages never count as unread
+	var rows []unreadRow
+	unreadQuery := database.DB.Model(&models.Message{}).
+		Select("messages.group_id, COUNT(*) AS count").
+		Joins("LEFT JOIN read_positions ON read_positions.group_id = messages.group_id AND read_positions.user = ?", user).
+		Where("messages.id > COALESCE(read_positions.last_read_message_id, 0)").
+		Where("LOWER(messages.user) <> ?", user)
+	if len(ids) > 0 {
+		unreadQuery = unreadQuery.Where("messages.group_id IN ?", ids)
+	}
+	if err := unreadQuery.Group("messages.group_id").Scan(&rows).Error; err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Failed to count unread messages")
+	}
+	unread := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		unread[row.GroupID] = row.Count
+	}
+
+	for i := range summaries {
+		summaries[i].LastReadMessageID = lastRead[summaries[i].ID]
+		summaries[i].UnreadCount = unread[summaries[i].ID]
+	}
+	return summaries, nil
+}
+
+// MarkRead handles PUT /api/v1/groups/:id/read
+// The read position only moves forward; older message IDs leave it unchanged.
+func (h *GroupHandler) MarkRead(c *gin.Context) {
+	user := strings.ToLower(currentUser(c))
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req models.ReadPositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	position, err := h.markRead(user, uint(id), req.MessageID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, position)
+}
+
+// markRead moves user's read position in a group forward to messageID
+func (h *GroupHandler) markRead(user string, groupID, messageID uint) (models.ReadPosition, error) {
+	user = strings.ToLower(user)
+
+	var message models.Message
+	if err := database.DB.Unscoped().Where("id = ? AND group_id = ?", messageID, groupID).First(&message).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.ReadPosition{}, newRequestError(http.StatusNotFound, "Message not found in group")
+		}
+		return models.ReadPosition{}, newRequestError(http.StatusInternalServerError, "Database error")
+	}
+
+	position := models.ReadPosition{User: user, GroupID: groupID, LastReadMessageID: message.ID}
+	result := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user"}, {Name: "group_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "read_positions.last_read_message_id < excluded.last_read_message_id"},
+		}},
+	}).Create(&position)
+	if result.Error != nil {
+		return models.ReadPosition{}, newRequestError(http.StatusInternalServerError, "Failed to update read position")
+	}
+	advanced := result.RowsAffected > 0
+
+	if err := database.DB.Where("read_positions.user = ? AND group_id = ?", user, groupID).First(&position).Error; err != nil {
+		return models.ReadPosition{}, newRequestError(http.StatusInternalServerError, "Database error")
+	}
+
+	// Keep the user's other devices in sync
+	if advanced && h.sseHandler != nil {
+		h.sseHandler.NotifyUser(user, SSEMessage{Type: EventReadPosition, Data: position})
+	}
+
+	return position, nil
+}
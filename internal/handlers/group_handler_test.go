@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPositions(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	groupHandler := NewGroupHandler(nil) // nil SSE handler for tests
+	router.GET("/api/v1/groups", groupHandler.GetGroups)
+	router.PUT("/api/v1/groups/:id/read", groupHandler.MarkRead)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	messages := []models.Message{
+		{GroupID: group.ID, User: "alice", Content: "first"},
+		{GroupID: group.ID, User: "alice", Content: "second"},
+		{GroupID: group.ID, User: "bob", Content: "third"},
+	}
+	db.Create(&messages)
+
+	getGroups := func() []models.GroupSummary {
+		req, _ := http.NewRequest("GET", "/api/v1/groups", nil)
+		req.Header.Set("X-User", "Bob")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var groups []models.GroupSummary
+		json.Unmarshal(w.Body.Bytes(), &groups)
+		return groups
+	}
+	markRead := func(messageID uint) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(models.ReadPositionRequest{MessageID: messageID})
+		req, _ := http.NewRequest("PUT", "/api/v1/groups/1/read", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "bob")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Bob's own message is not unread
+	groups := getGroups()
+	assert.Len(t, groups, 1)
+	assert.Equal(t, int64(2), groups[0].UnreadCount)
+
+	assert.Equal(t, http.StatusOK, markRead(messages[0].ID).Code)
+	groups = getGroups()
+	assert.Equal(t, messages[0].ID, groups[0].LastReadMessageID)
+	assert.Equal(t, int64(1), groups[0].UnreadCount)
+
+	// Moving backwards leaves the position unchanged
+	assert.Equal(t, http.StatusOK, markRead(messages[1].ID).Code)
+	w := markRead(messages[0].ID)
+	var position models.ReadPosition
+	json.Unmarshal(w.Body.Bytes(), &position)
+	assert.Equal(t, messages[1].ID, position.LastReadMessageID)
+
+	assert.Equal(t, http.StatusNotFound, markRead(999).Code)
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/pkg/chatpb"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCHandler serves the gRPC ChatService with the logic of MessageHandler and the
+// event hub of SSEHandler, so that gRPC and REST clients see the same messages and
+// events
+type GRPCHandler struct {
+	chatpb.UnimplementedChatServiceServer
+	sseHandler     *SSEHandler
+	messageHandler *MessageHandler
+}
+
+// NewGRPCHandler creates a new gRPC handler
+func NewGRPCHandler(sseHandler *SSEHandler, messageHandler *MessageHandler) *GRPCHandler {
+	return &GRPCHandler{
+		sseHandler:     sseHandler,
+		messageHandler: messageHandler,
+	}
+}
+
+// CreateMessage handles chat.v1.ChatService/CreateMessage
+func (h *GRPCHandler) CreateMessage(ctx context.Context, req *chatpb.CreateMessageRequest) (*chatpb.CreateMessageResponse, error) {
+	createReq := models.CreateMessageRequest{
+		User:    req.GetUser(),
+		Content: req.GetContent(),
+		GroupID: uint(req.GetGroupId()),
+	}
+	if req.SendAt != nil {
+		if err := req.SendAt.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid send_at")
+		}
+		sendAt := req.SendAt.AsTime()
+		createReq.SendAt = &sendAt
+	}
+
+	result, err := h.messageHandler.createMessage(ctx, createReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	switch {
+	case result.command != nil:
+		return &chatpb.CreateMessageResponse{Result: &chatpb.CreateMessageResponse_Command{Command: &chatpb.CommandResponse{
+			Command:   result.command.Command,
+			GroupId:   uint32(result.command.GroupID),
+			Text:      result.command.Text,
+			Ephemeral: result.command.Ephemeral,
+		}}}, nil
+	case result.scheduled != nil:
+		return &chatpb.CreateMessageResponse{Result: &chatpb.CreateMessageResponse_Scheduled{Scheduled: &chatpb.ScheduledMessage{
+			Id:        uint32(result.scheduled.ID),
+			GroupId:   uint32(result.scheduled.GroupID),
+			User:      result.scheduled.User,
+			Content:   result.scheduled.Content,
+			SendAt:    timestamp(result.scheduled.SendAt),
+			Status:    result.scheduled.Status,
+			CreatedAt: timestamp(result.scheduled.CreatedAt),
+		}}}, nil
+	}
+	return &chatpb.CreateMessageResponse{Result: &chatpb.CreateMessageResponse_Message{Message: messageToProto(*result.message)}}, nil
+}
+
+// ListMessages handles chat.v1.ChatService/ListMessages
+func (h *GRPCHandler) ListMessages(ctx context.Context, req *chatpb.ListMessagesRequest) (*chatpb.ListMessagesResponse, error) {
+	filters := messageFilters{
+		groupID:  uint(req.GetGroupId()),
+		user:     req.GetUser(),
+		contains: req.GetContains(),
+		hasLink:  req.HasLink,
+	}
+	for _, bound := range []struct {
+		name   string
+		value  *timestamppb.Timestamp
+		target **time.Time
+	}{{"since", req.Since, &filters.since}, {"until", req.Until, &filters.until}} {
+		if bound.value == nil {
+			continue
+		}
+		if err := bound.value.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid "+bound.name)
+		}
+		t := bound.value.AsTime()
+		*bound.target = &t
+	}
+
+	sort := req.GetSort()
+	if sort == "" {
+		sort = "asc"
+	}
+	direction, err := sortDirection(sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.GetLimit() < 0 || req.GetLimit() > maxMessagePageLimit {
+		return nil, status.Error(codes.InvalidArgument, "Invalid limit")
+	}
+	page := messagePage{
+		afterID:  uint(req.GetAfterId()),
+		beforeID: uint(req.GetBeforeId()),
+		limit:    int(req.GetLimit()),
+	}
+
+	messages, err := listMessages(filters, page, direction, true, true)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &chatpb.ListMessagesResponse{Messages: make([]*chatpb.Message, len(messages))}
+	for i, message := range messages {
+		resp.Messages[i] = messageToProto(message)
+	}
+	return resp, nil
+}
+
+// GetMessage handles chat.v1.ChatService/GetMessage
+func (h *GRPCHandler) GetMessage(ctx context.Context, req *chatpb.GetMessageRequest) (*chatpb.Message, error) {
+	message, err := getMessage(uint(req.GetId()))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return messageToProto(message), nil
+}
+
+// UpdateMessage handles chat.v1.ChatService/UpdateMessage
+func (h *GRPCHandler) UpdateMessage(ctx context.Context, req *chatpb.UpdateMessageRequest) (*chatpb.Message, error) {
+	message, err := h.messageHandler.updateMessage(uint(req.GetId()), req.GetContent())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return messageToProto(message), nil
+}
+
+// DeleteMessage handles chat.v1.ChatService/DeleteMessage
+func (h *GRPCHandler) DeleteMessage(ctx context.Context, req *chatpb.DeleteMessageRequest) (*chatpb.DeleteMessageResponse, error) {
+	if err := deleteMessage(uint(req.GetId())); err != nil {
+		return nil, grpcError(err)
+	}
+	return &chatpb.DeleteMessageResponse{Message: "Message deleted successfully"}, nil
+}
+
+// Subscribe handles chat.v1.ChatService/Subscribe, streaming the events SSE streams
+// receive. Like SSE streams, subscriptions start with a connected event, followed by
+// a resync event when last_event_id cannot be resumed, or else the missed events.
+func (h *GRPCHandler) Subscribe(req *chatpb.SubscribeRequest, stream grpc.ServerStreamingServer[chatpb.Event]) error {
+	if h.sseHandler == nil {
+		return status.Error(codes.Unavailable, "Event streams are disabled")
+	}
+
+	client := sseClient{user: grpcUser(stream.Context()), groupID: uint(req.GetGroupId())}
+	events, missed, resumed := h.sseHandler.subscribe(client, req.GetLastEventId())
+	defer h.sseHandler.unsubscribe(events, client)
+
+	initial := []SSEMessage{{Type: "connected"}}
+	if !resumed {
+		initial = append(initial, SSEMessage{Type: EventResync})
+	}
+	for _, event := range append(initial, missed...) {
+		if err := sendEvent(stream, event); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// sendEvent sends one event of the hub on a Subscribe stream
+func sendEvent(stream grpc.ServerStreamingServer[chatpb.Event], event SSEMessage) error {
+	pb := &chatpb.Event{Id: event.id, Type: event.Type}
+	if event.Message != nil {
+		pb.Message = messageToProto(*event.Message)
+	}
+	if event.Data != nil {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return status.Error(codes.Internal, "Failed to encode event")
+		}
+		pb.Data = &structpb.Value{}
+		if err := protojson.Unmarshal(data, pb.Data); err != nil {
+			return status.Error(codes.Internal, "Failed to encode event")
+		}
+	}
+	return stream.Send(pb)
+}
+
+// grpcError turns an error of the logic shared with the REST API into a status
+// whose code grpc-gateway maps back to the REST API's HTTP status
+func grpcError(err error) error {
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		return status.Error(codes.Internal, "Internal server error")
+	}
+
+	code := codes.Internal
+	switch reqErr.status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, reqErr.message)
+}
+
+// messageToProto converts a message with whatever relations were loaded
+func messageToProto(message models.Message) *chatpb.Message {
+	pb := &chatpb.Message{
+		Id:        uint32(message.ID),
+		GroupId:   uint32(message.GroupID),
+		User:      message.User,
+		Content:   message.Content,
+		CreatedAt: timestamp(message.CreatedAt),
+		UpdatedAt: timestamp(message.UpdatedAt),
+		Bot:       message.Bot,
+		System:    message.System,
+	}
+	if message.Group.ID != 0 {
+		pb.Group = &chatpb.Group{
+			Id:          uint32(message.Group.ID),
+			Name:        message.Group.Name,
+			Description: message.Group.Description,
+			CreatedAt:   timestamp(message.Group.CreatedAt),
+			UpdatedAt:   timestamp(message.Group.UpdatedAt),
+		}
+	}
+	for _, reaction := range message.Reactions {
+		pb.Reactions = append(pb.Reactions, &chatpb.ReactionSummary{
+			Emoji: reaction.Emoji,
+			Count: int32(reaction.Count),
+			Users: reaction.Users,
+		})
+	}
+	for _, attachment := range message.Attachments {
+		pb.Attachments = append(pb.Attachments, &chatpb.Attachment{
+			Id:           uint32(attachment.ID),
+			MessageId:    uint32(attachment.MessageID),
+			Filename:     attachment.Filename,
+			ContentType:  attachment.ContentType,
+			Size:         attachment.Size,
+			Width:        int32(attachment.Width),
+			Height:       int32(attachment.Height),
+			CreatedAt:    timestamp(attachment.CreatedAt),
+			Url:          attachment.URL,
+			ThumbnailUrl: attachment.ThumbnailURL,
+		})
+	}
+	for _, preview := range message.LinkPreviews {
+		pb.LinkPreviews = append(pb.LinkPreviews, &chatpb.LinkPreview{
+			Url:         preview.URL,
+			Title:       preview.Title,
+			Description: preview.Description,
+			ImageUrl:    preview.ImageURL,
+			SiteName:    preview.SiteName,
+			Type:        preview.Type,
+			FetchedAt:   timestamp(preview.FetchedAt),
+		})
+	}
+	return pb
+}
+
+// timestamp converts a time, leaving zero times unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/pkg/chatpb"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupGRPC serves the handler over an in-memory connection and returns a client
+func setupGRPC(t *testing.T, handler *GRPCHandler) chatpb.ChatServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	chatpb.RegisterChatServiceServer(server, handler)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return chatpb.NewChatServiceClient(conn)
+}
+
+// nextEvent receives events until one matches
+func nextEvent(t *testing.T, stream grpc.ServerStreamingClient[chatpb.Event], match func(*chatpb.Event) bool) *chatpb.Event {
+	for {
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		if match(event) {
+			return event
+		}
+	}
+}
+
+func eventType(eventType string) func(*chatpb.Event) bool {
+	return func(event *chatpb.Event) bool { return event.Type == eventType }
+}
+
+func TestGRPCSubscribe(t *testing.T) {
+	sseHandler := NewSSEHandler(config.PresenceConfig{})
+	client := setupGRPC(t, NewGRPCHandler(sseHandler, NewMessageHandler(sseHandler, nil, nil)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	aliceCtx := metadata.AppendToOutgoingContext(ctx, "x-user", "alice")
+
+	// A new subscription starts like an SSE stream
+	streamCtx, closeStream := context.WithCancel(aliceCtx)
+	stream, err := client.Subscribe(streamCtx, &chatpb.SubscribeRequest{GroupId: 1})
+	require.NoError(t, err)
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "connected", event.Type)
+
+	// Events come from the hub, filtered by group, with their data
+	sseHandler.NotifyTyping("bob", 2)
+	sseHandler.NotifyTyping("bob", 1)
+	typing := nextEvent(t, stream, eventType(EventTyping))
+	assert.Equal(t, "bob", typing.Data.GetStructValue().Fields["user"].GetStringValue())
+	assert.Equal(t, float64(1), typing.Data.GetStructValue().Fields["group_id"].GetNumberValue())
+	assert.Empty(t, typing.Id)
+
+	// Events addressed to the caller reach them whatever the group
+	sseHandler.NotifyUser("alice", SSEMessage{Type: EventReadPosition, Data: models.ReadPosition{User: "alice", GroupID: 2, LastReadMessageID: 7}})
+	position := nextEvent(t, stream, eventType(EventReadPosition))
+	require.NotEmpty(t, position.Id)
+	closeStream()
+
+	// Resuming replays what was missed, without a resync
+	sseHandler.NotifyUser("alice", SSEMessage{Type: EventReadPosition, Data: models.ReadPosition{User: "alice", GroupID: 2, LastReadMessageID: 8}})
+	time.Sleep(50 * time.Millisecond)
+	stream, err = client.Subscribe(aliceCtx, &chatpb.SubscribeRequest{GroupId: 1, LastEventId: position.Id})
+	require.NoError(t, err)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "connected", event.Type)
+	missed := nextEvent(t, stream, func(event *chatpb.Event) bool {
+		assert.NotEqual(t, EventResync, event.Type)
+		return event.Type == EventReadPosition
+	})
+	assert.Equal(t, float64(8), missed.Data.GetStructValue().Fields["last_read_message_id"].GetNumberValue())
+
+	// IDs of another process cannot be resumed
+	stream, err = client.Subscribe(aliceCtx, &chatpb.SubscribeRequest{LastEventId: "elsewhere-1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, EventResync, event.Type)
+}
+
+func TestMessageToProto(t *testing.T) {
+	pb := messageToProto(models.Message{ID: 3, GroupID: 1, User: "INC-7", Content: "Status: resolved", System: true})
+	assert.Equal(t, uint32(3), pb.Id)
+	assert.True(t, pb.System)
+	assert.False(t, pb.Bot)
+	assert.Nil(t, pb.Group)
+}
+
+func TestGRPCInvalidArguments(t *testing.T) {
+	client := setupGRPC(t, NewGRPCHandler(nil, NewMessageHandler(nil, nil, nil)))
+	ctx := context.Background()
+
+	_, err := client.CreateMessage(ctx, &chatpb.CreateMessageRequest{User: "alice"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	limit := int32(maxMessagePageLimit + 1)
+	_, err = client.ListMessages(ctx, &chatpb.ListMessagesRequest{Limit: limit})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.ListMessages(ctx, &chatpb.ListMessagesRequest{Sort: "sideways"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCMessages(t *testing.T) {
+	db := setupTestDB(t)
+	database.DB = db
+
+	sseHandler := NewSSEHandler(config.PresenceConfig{})
+	client := setupGRPC(t, NewGRPCHandler(sseHandler, NewMessageHandler(sseHandler, nil, commands.Default)))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var group models.Group
+	require.NoError(t, db.Where("name = ?", "SRE Bootcamp").First(&group).Error)
+
+	stream, err := client.Subscribe(ctx, &chatpb.SubscribeRequest{GroupId: uint32(group.ID)})
+	require.NoError(t, err)
+	nextEvent(t, stream, eventType("connected"))
+
+	// Messages default to the SRE Bootcamp group and reach subscribers
+	var ids []uint32
+	for _, content := range []string{"first", "second", "third"} {
+		resp, err := client.CreateMessage(ctx, &chatpb.CreateMessageRequest{User: "alice", Content: content})
+		require.NoError(t, err)
+		require.NotNil(t, resp.GetMessage())
+		assert.Equal(t, uint32(group.ID), resp.GetMessage().GroupId)
+		assert.Equal(t, "SRE Bootcamp", resp.GetMessage().Group.GetName())
+		ids = append(ids, resp.GetMessage().Id)
+	}
+	event := nextEvent(t, stream, eventType(EventMessage))
+	assert.Equal(t, "first", event.Message.Content)
+
+	// Slash commands reply instead of posting
+	resp, err := client.CreateMessage(ctx, &chatpb.CreateMessageRequest{User: "alice", Content: "/help"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetCommand().GetText())
+
+	// Listing pages like GET /api/v1/messages
+	page, err := client.ListMessages(ctx, &chatpb.ListMessagesRequest{GroupId: uint32(group.ID), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 2)
+	page, err = client.ListMessages(ctx, &chatpb.ListMessagesRequest{GroupId: uint32(group.ID), Limit: 2, AfterId: page.Messages[1].Id})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 1)
+	assert.Equal(t, ids[2], page.Messages[0].Id)
+
+	updated, err := client.UpdateMessage(ctx, &chatpb.UpdateMessageRequest{Id: ids[0], Content: "edited"})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updated.Content)
+	event = nextEvent(t, stream, eventType(EventMessageUpdated))
+	assert.Equal(t, "edited", event.Message.Content)
+
+	_, err = client.DeleteMessage(ctx, &chatpb.DeleteMessageRequest{Id: ids[0]})
+	require.NoError(t, err)
+	_, err = client.GetMessage(ctx, &chatpb.GetMessageRequest{Id: ids[0]})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateMessage(ctx, &chatpb.CreateMessageRequest{User: "alice", Content: "hello", GroupId: 99999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+// currentUser returns the caller's user name from the X-User header, falling back to
+// the user query parameter for clients such as EventSource that cannot set headers
+func currentUser(c *gin.Context) string {
+	if user := strings.TrimSpace(c.GetHeader("X-User")); user != "" {
+		return user
+	}
+	return strings.TrimSpace(c.Query("user"))
+}
+
+// grpcUser returns the gRPC caller's user name from the x-user metadata, the
+// counterpart of the X-User header
+func grpcUser(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if users := md.Get("x-user"); len(users) > 0 {
+		return strings.TrimSpace(users[0])
+	}
+	return ""
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/incidents"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// incidentUser is the name incident status changes are posted as
+const incidentUser = "incident"
+
+const (
+	defaultIncidentLimit = 50
+	maxIncidentLimit     = 500
+)
+
+// errIncidentUnchanged is returned when an update does not change any field
+var errIncidentUnchanged = errors.New("incident unchanged")
+
+func init() {
+	commands.Register(commands.Command{
+		Name:        "incident",
+		Usage:       "/incident declare [sev1-4] <title> | /incident status|severity|commander <value> | /incident resolve",
+		Description: "Declare an incident in its own group, or update or show the incident of this group",
+		Run:         runIncident,
+	})
+}
+
+// incidentDeliverer notifies clients about an incident message once it is stored
+type incidentDeliverer func(message models.Message)
+
+// incidentChange is one field changed by an incident update
+type incidentChange struct {
+	field string
+	from  string
+	to    string
+}
+
+// IncidentHandler handles incident HTTP requests
+type IncidentHandler struct {
+	messageHandler *MessageHandler
+}
+
+// NewIncidentHandler creates a new incident handler. Status changes are delivered
+// through the message handler.
+func NewIncidentHandler(messageHandler *MessageHandler) *IncidentHandler {
+	return &IncidentHandler{messageHandler: messageHandler}
+}
+
+// DeclareIncident handles POST /api/v1/incidents
+func (h *IncidentHandler) DeclareIncident(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	var req models.DeclareIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, err := declareIncident(user, req, h.messageHandler.Deliver)
+	if err != nil {
+		if err == incidents.ErrInvalidSeverity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to declare incident"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+// GetIncidents handles GET /api/v1/incidents
+// status filters by a status, or by "active" for every unresolved incident.
+func (h *IncidentHandler) GetIncidents(c *gin.Context) {
+	query := database.DB.Preload("Group")
+	switch status := c.Query("status"); status {
+	case "":
+	case "active":
+		query = query.Where("status <> ?", models.IncidentResolved)
+	default:
+		parsed, err := incidents.ParseStatus(status)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", parsed)
+	}
+
+	limit := defaultIncidentLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxIncidentLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var list []models.Incident
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetIncident handles GET /api/v1/incidents/:id
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	incident, ok := findIncident(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+// UpdateIncident handles PUT /api/v1/incidents/:id
+// Each changed field is announced in the incident group.
+func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	incident, ok := findIncident(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, err := updateIncident(incident.ID, user, req, h.messageHandler.Deliver)
+	if err != nil {
+		switch err {
+		case incidents.ErrInvalidSeverity, incidents.ErrInvalidStatus:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errIncidentUnchanged:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No changes"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+// GetTimeline handles GET /api/v1/incidents/:id/timeline
+// The timeline is returned as JSON, or as a postmortem document with format=markdown.
+func (h *IncidentHandler) GetTimeline(c *gin.Context) {
+	incident, ok := findIncident(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		return
+	}
+
+	timeline, err := incidentTimeline(incident)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build timeline"})
+		return
+	}
+
+	if format == "markdown" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d-timeline.md"`, incident.ID))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(incidents.Markdown(timeline)))
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// runIncident declares an incident, or shows or updates the incident of the
+// group it is typed in
+func runIncident(ctx *commands.Context) (string, error) {
+	if len(ctx.Args) == 0 {
+		incident, found, err := groupIncident(ctx.GroupID)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "This group is not an incident group. Declare one with /incident declare <title>.", nil
+		}
+		return fmt.Sprintf("Incident %d: %s", incident.ID, incidents.Topic(incident)), nil
+	}
+
+	subcommand, args := strings.ToLower(ctx.Args[0]), ctx.Args[1:]
+	if subcommand == "declare" {
+		return runDeclareIncident(ctx, args)
+	}
+
+	var req models.UpdateIncidentRequest
+	switch {
+	case subcommand == "resolve" && len(args) == 0:
+		status := models.IncidentResolved
+		req.Status = &status
+	case subcommand == "status" && len(args) == 1:
+		req.Status = &args[0]
+	case subcommand == "severity" && len(args) == 1:
+		req.Severity = &args[0]
+	case subcommand == "commander" && len(args) == 1:
+		commander := strings.TrimPrefix(args[0], "@")
+		req.Commander = &commander
+	default:
+		return "", commands.ErrUsage
+	}
+
+	incident, found, err := groupIncident(ctx.GroupID)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "This group is not an incident group.", nil
+	}
+
+	incident, err = updateIncident(incident.ID, ctx.User, req, ctx.Deliver)
+	switch err {
+	case nil:
+		return "Incident updated: " + incidents.Topic(incident), nil
+	case errIncidentUnchanged:
+		return "Nothing changed.", nil
+	default:
+		return "", err
+	}
+}
+
+// runDeclareIncident handles /incident declare, announcing the new incident in the
+// group the command was typed in
+func runDeclareIncident(ctx *commands.Context, args []string) (string, error) {
+	var req models.DeclareIncidentRequest
+	if len(args) > 1 {
+		if severity, err := incidents.ParseSeverity(args[0]); err == nil {
+			req.Severity, args = severity, args[1:]
+		}
+	}
+	req.Title = strings.Join(args, " ")
+	if req.Title == "" {
+		return "", commands.ErrUsage
+	}
+
+	incident, err := declareIncident(ctx.User, req, ctx.Deliver)
+	if err != nil {
+		return "", err
+	}
+
+	if incident.GroupID != ctx.GroupID {
+		announcement := fmt.Sprintf("declared %s incident %d: %s (continue in %s)", incident.Severity, incident.ID, incident.Title, incident.Group.Name)
+		if _, err := ctx.Post(announcement); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Incident %d declared in group %s (id %d).", incident.ID, incident.Group.Name, incident.GroupID), nil
+}
+
+// declareIncident creates an incident with its own group and posts the
+// declaration there in the same transaction, so an incident never exists without
+// it. Severity defaults to SEV3 and the commander to the caller.
+func declareIncident(user string, req models.DeclareIncidentRequest, deliver incidentDeliverer) (models.Incident, error) {
+	incident := models.Incident{
+		Title:      strings.TrimSpace(req.Title),
+		Severity:   models.SeverityThree,
+		Status:     models.IncidentInvestigating,
+		Commander:  strings.TrimSpace(req.Commander),
+		DeclaredBy: user,
+	}
+	if req.Severity != "" {
+		severity, err := incidents.ParseSeverity(req.Severity)
+		if err != nil {
+			return incident, err
+		}
+		incident.Severity = severity
+	}
+	if incident.Commander == "" {
+		incident.Commander = user
+	}
+
+	var declaration models.Message
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		base := incidents.GroupName(incident.Title, time.Now())
+		name := base
+		for i := 2; ; i++ {
+			var count int64
+			if err := tx.Model(&models.Group{}).Where("name = ?", name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				break
+			}
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		incident.Group = models.Group{Name: name, Description: incidents.Topic(incident)}
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+
+		declaration = incidentMessage(incident.GroupID, fmt.Sprintf("%s declared a %s incident: %s\nCommander: %s · Status: %s\nPin messages or tag them %s to add them to the timeline.",
+			user, incident.Severity, incident.Title, incident.Commander, incident.Status, incidents.TimelineTag))
+		return messages.Create(tx, &declaration)
+	})
+	if err != nil {
+		return incident, err
+	}
+
+	deliverIncidentMessages(deliver, declaration)
+	return incident, nil
+}
+
+// updateIncident applies the fields set in req, keeps the group topic in sync
+// and posts one message per changed field to the incident group
+func updateIncident(id uint, user string, req models.UpdateIncidentRequest, deliver incidentDeliverer) (models.Incident, error) {
+	var incident models.Incident
+	var changes []incidentChange
+	var posted []models.Message
+
+	var status, severity string
+	var err error
+	if req.Status != nil {
+		if status, err = incidents.ParseStatus(*req.Status); err != nil {
+			return incident, err
+		}
+	}
+	if req.Severity != nil {
+		if severity, err = incidents.ParseSeverity(*req.Severity); err != nil {
+			return incident, err
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&incident, id).Error; err != nil {
+			return err
+		}
+
+		if status != "" && status != incident.Status {
+			changes = append(changes, incidentChange{"status", incident.Status, status})
+			incident.Status = status
+			if status == models.IncidentResolved {
+				now := time.Now()
+				incident.ResolvedAt = &now
+			} else {
+				incident.ResolvedAt = nil
+			}
+		}
+		if severity != "" && severity != incident.Severity {
+			changes = append(changes, incidentChange{"severity", incident.Severity, severity})
+			incident.Severity = severity
+		}
+		if req.Commander != nil {
+			commander := strings.TrimSpace(*req.Commander)
+			if commander != "" && commander != incident.Commander {
+				changes = append(changes, incidentChange{"commander", incident.Commander, commander})
+				incident.Commander = commander
+			}
+		}
+		if len(changes) == 0 {
+			return errIncidentUnchanged
+		}
+
+		if err := tx.Omit("Group").Save(&incident).Error; err != nil {
+			return err
+		}
+		for _, change := range changes {
+			message := incidentMessage(incident.GroupID, describeIncidentChange(user, incident, change))
+			if err := messages.Create(tx, &message); err != nil {
+				return err
+			}
+			posted = append(posted, message)
+		}
+		return tx.Model(&models.Group{}).Where("id = ?", incident.GroupID).Update("description", incidents.Topic(incident)).Error
+	})
+	if err != nil {
+		return incident, err
+	}
+
+	deliverIncidentMessages(deliver, posted...)
+
+	database.DB.Preload("Group").First(&incident, incident.ID)
+	return incident, nil
+}
+
+// incidentMessage builds a system message of the incident user
+func incidentMessage(groupID uint, content string) models.Message {
+	return models.Message{GroupID: groupID, User: incidentUser, Content: content, System: true}
+}
+
+// deliverIncidentMessages notifies clients about committed incident messages
+func deliverIncidentMessages(deliver incidentDeliverer, posted ...models.Message) {
+	if deliver == nil {
+		return
+	}
+	for _, message := range posted {
+		database.DB.Preload("Group").First(&message, message.ID)
+		deliver(message)
+	}
+}
+
+// describeIncidentChange words a change for its announcement
+func describeIncidentChange(user string, incident models.Incident, change incidentChange) string {
+	switch {
+	case change.field == "status" && change.to == models.IncidentResolved:
+		return fmt.Sprintf("%s resolved the incident after %s", user, incident.ResolvedAt.Sub(incident.CreatedAt).Round(time.Minute))
+	case change.field == "status" && change.from == models.IncidentResolved:
+		return fmt.Sprintf("%s reopened the incident as %s", user, change.to)
+	case change.field == "commander":
+		return fmt.Sprintf("%s handed incident command from %s to %s", user, change.from, change.to)
+	default:
+		return fmt.Sprintf("%s changed the %s from %s to %s", user, change.field, change.from, change.to)
+	}
+}
+
+// incidentTimeline collects the incident's system messages, pinned messages and
+// messages tagged for the timeline, oldest first
+func incidentTimeline(incident models.Incident) (models.IncidentTimeline, error) {
+	timeline := models.IncidentTimeline{Incident: incident, Entries: []models.TimelineEntry{}}
+
+	var pinnedIDs []uint
+	err := database.DB.Model(&models.Pin{}).Where("group_id = ?", incident.GroupID).Pluck("message_id", &pinnedIDs).Error
+	if err != nil {
+		return timeline, err
+	}
+	isPinned := make(map[uint]bool, len(pinnedIDs))
+	for _, id := range pinnedIDs {
+		isPinned[id] = true
+	}
+
+	var entries []models.Message
+	err = database.DB.Where("group_id = ?", incident.GroupID).
+		Where(database.DB.Where("system = ?", true).
+			Or("id IN (?)", database.DB.Model(&models.Pin{}).Select("message_id").Where("group_id = ?", incident.GroupID)).
+			Or("LOWER(content) LIKE ?", "%"+incidents.TimelineTag+"%")).
+		Order("created_at, id").
+		Find(&entries).Error
+	if err != nil {
+		return timeline, err
+	}
+
+	for _, message := range entries {
+		kind := models.TimelineTagged
+		switch {
+		case message.System:
+			kind = models.TimelineSystem
+		case isPinned[message.ID]:
+			kind = models.TimelinePinned
+		}
+		timeline.Entries = append(timeline.Entries, models.TimelineEntry{
+			At:        message.CreatedAt,
+			MessageID: message.ID,
+			User:      message.User,
+			Kind:      kind,
+			Content:   message.Content,
+		})
+	}
+	return timeline, nil
+}
+
+// groupIncident returns the incident run in a group, if any
+func groupIncident(groupID uint) (models.Incident, bool, error) {
+	var incident models.Incident
+	err := database.DB.Preload("Group").Where("group_id = ?", groupID).Limit(1).Find(&incident).Error
+	return incident, incident.ID != 0, err
+}
+
+// findIncident loads the incident named by the :id parameter, writing the error
+// response and returning false if it cannot
+func findIncident(c *gin.Context) (models.Incident, bool) {
+	var incident models.Incident
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		return incident, false
+	}
+
+	if err := database.DB.Preload("Group").First(&incident, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return incident, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return incident, false
+	}
+
+	return incident, true
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncidentLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	incidentHandler := NewIncidentHandler(NewMessageHandler(nil, nil, nil))
+	router.GET("/api/v1/incidents", incidentHandler.GetIncidents)
+	router.PUT("/api/v1/incidents/:id", incidentHandler.UpdateIncident)
+	router.GET("/api/v1/incidents/:id/timeline", incidentHandler.GetTimeline)
+	router.POST("/api/v1/messages/:id/pin", NewPinHandler(nil, config.PinsConfig{MaxPerGroup: 10}).PinMessage)
+
+	var bootcamp models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&bootcamp)
+
+	command := func(user string, groupID uint, content string) models.CommandResponse {
+		jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: user, Content: content, GroupID: groupID})
+		req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.CommandResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	// Declaring creates a dedicated group and announces it where it was typed
+	response := command("alice", bootcamp.ID, "/incident declare sev2 Checkout latency")
+	assert.Contains(t, response.Text, "declared in group incident-")
+
+	var incident models.Incident
+	if !assert.NoError(t, db.Preload("Group").First(&incident).Error) {
+		return
+	}
+	assert.Equal(t, models.SeverityTwo, incident.Severity)
+	assert.Equal(t, models.IncidentInvestigating, incident.Status)
+	assert.Equal(t, "alice", incident.Commander)
+	assert.True(t, strings.HasPrefix(incident.Group.Name, "incident-"))
+	assert.Equal(t, "SEV2 · investigating · IC alice · Checkout latency", incident.Group.Description)
+
+	var announcement models.Message
+	db.Where("group_id = ?", bootcamp.ID).First(&announcement)
+	assert.Contains(t, announcement.Content, incident.Group.Name)
+
+	// Work in the incident group: one pinned and one tagged message, one ignored
+	pinned := postTestMessageIn(t, router, incident.GroupID, "bob", "Error rate at 12% since deploy 1.2.3")
+	postTestMessageIn(t, router, incident.GroupID, "bob", "Rolled back to 1.2.2 #timeline")
+	postTestMessageIn(t, router, incident.GroupID, "carol", "anyone want coffee?")
+	// Posting under the incident name does not make a system message
+	postTestMessageIn(t, router, incident.GroupID, "incident", "mallory resolved the incident")
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/messages/%d/pin", pinned.ID), nil)
+	req.Header.Set("X-User", "alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Status changes are posted as system messages
+	response = command("alice", incident.GroupID, "/incident status identified")
+	assert.Contains(t, response.Text, "identified")
+	response = command("alice", incident.GroupID, "/incident commander @bob")
+	assert.Contains(t, response.Text, "IC bob")
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/incidents/%d", incident.ID), strings.NewReader(`{"status": "resolved"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "bob")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &incident)
+	assert.NotNil(t, incident.ResolvedAt)
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/incidents/%d", incident.ID), strings.NewReader(`{"status": "closed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "bob")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The timeline holds the system messages, the pinned and the tagged message
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/incidents/%d/timeline", incident.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var timeline models.IncidentTimeline
+	json.Unmarshal(w.Body.Bytes(), &timeline)
+	kinds := make([]string, len(timeline.Entries))
+	for i, entry := range timeline.Entries {
+		kinds[i] = entry.Kind
+	}
+	assert.Equal(t, []string{
+		models.TimelineSystem, // declared
+		models.TimelinePinned,
+		models.TimelineTagged,
+		models.TimelineSystem, // identified
+		models.TimelineSystem, // commander
+		models.TimelineSystem, // resolved
+	}, kinds)
+	if len(timeline.Entries) == 6 {
+		assert.Contains(t, timeline.Entries[5].Content, "bob resolved the incident")
+	}
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/incidents/%d/timeline?format=markdown", incident.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/markdown")
+	assert.Contains(t, w.Body.String(), "| bob | tagged | Rolled back to 1.2.2 #timeline |")
+
+	req, _ = http.NewRequest("GET", "/api/v1/incidents?status=active", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func postTestMessageIn(t *testing.T, router http.Handler, groupID uint, user, content string) models.Message {
+	jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: user, Content: content, GroupID: groupID})
+	req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	return message
+}
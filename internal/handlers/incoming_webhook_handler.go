@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// incomingWebhookPath is where incoming webhook tokens are posted to
+const incomingWebhookPath = "/api/v1/hooks/"
+
+// IncomingWebhookHandler handles incoming webhook HTTP requests
+type IncomingWebhookHandler struct {
+	messageHandler *MessageHandler
+	attachments    *AttachmentHandler
+}
+
+// NewIncomingWebhookHandler creates a new incoming webhook handler. Messages are
+// posted and their files stored the same way as through the message and attachment
+// endpoints.
+func NewIncomingWebhookHandler(messageHandler *MessageHandler, attachments *AttachmentHandler) *IncomingWebhookHandler {
+	return &IncomingWebhookHandler{
+		messageHandler: messageHandler,
+		attachments:    attachments,
+	}
+}
+
+// GetIncomingWebhooks handles GET /api/v1/admin/groups/:id/incoming-webhooks
+func (h *IncomingWebhookHandler) GetIncomingWebhooks(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var hooks []models.IncomingWebhook
+	if err := database.DB.Where("group_id = ?", group.ID).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incoming webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateIncomingWebhook handles POST /api/v1/admin/groups/:id/incoming-webhooks
+// The response is the only place the webhook URL is returned.
+func (h *IncomingWebhookHandler) CreateIncomingWebhook(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateIncomingWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := newIncomingWebhookToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook token"})
+		return
+	}
+
+	hook := models.IncomingWebhook{
+		GroupID:   group.ID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hashIncomingWebhookToken(token),
+	}
+	if err := database.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create incoming webhook"})
+		return
+	}
+
+	hook.URL = requestBaseURL(c) + incomingWebhookPath + token
+	c.JSON(http.StatusCreated, hook)
+}
+
+// DeleteIncomingWebhook handles DELETE /api/v1/admin/incoming-webhooks/:id
+func (h *IncomingWebhookHandler) DeleteIncomingWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incoming webhook ID"})
+		return
+	}
+
+	result := database.DB.Delete(&models.IncomingWebhook{}, uint(id))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete incoming webhook"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incoming webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Incoming webhook deleted successfully"})
+}
+
+// Post handles POST /api/v1/hooks/:token
+// The message is posted as the webhook's name unless the payload overrides the
+// username. Text is stored verbatim, so slash commands are not run.
+func (h *IncomingWebhookHandler) Post(c *gin.Context) {
+	var hook models.IncomingWebhook
+	err := database.DB.Where("token_hash = ?", hashIncomingWebhookToken(c.Param("token"))).First(&hook).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incoming webhook not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Base64 inflates files by a third; leave room for the rest of the payload
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachments.maxBytes*maxFilesPerUpload*4/3+1<<20)
+
+	var payload models.IncomingWebhookPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Payload too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate every file before posting the message
+	uploads := make([]upload, 0, len(payload.Attachments))
+	for _, attachment := range payload.Attachments {
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Attachment %s is not valid base64", attachment.Filename)})
+			return
+		}
+		u, ok := h.attachments.prepareUpload(c, attachment.Filename, data)
+		if !ok {
+			return
+		}
+		uploads = append(uploads, u)
+	}
+
+	user := strings.TrimSpace(payload.Username)
+	if user == "" {
+		user = hook.Name
+	}
+
+	// Attachments are stored with the message, so it is never posted without them
+	var stored []models.Attachment
+	message, err := h.messageHandler.publishMessageWith(models.Message{
+		GroupID: hook.GroupID,
+		User:    user,
+		Content: payload.Text,
+	}, func(tx *gorm.DB, message models.Message) error {
+		if len(uploads) == 0 {
+			return nil
+		}
+		var err error
+		stored, err = storeAttachments(c, tx, message.ID, uploads)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to post incoming webhook %d: %v", hook.ID, err)
+		// The blobs of attachments stored before the transaction failed
+		storage.DeleteAll(context.WithoutCancel(c.Request.Context()), attachmentKeys(stored))
+		respondError(c, publishError(err, "Failed to create message"))
+		return
+	}
+
+	database.DB.Model(&hook).Update("last_used_at", time.Now())
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// newIncomingWebhookToken generates the secret part of an incoming webhook URL
+func newIncomingWebhookToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashIncomingWebhookToken returns the stored form of an incoming webhook token
+func hashIncomingWebhookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestBaseURL returns the scheme and host the request was addressed to,
+// honouring X-Forwarded-Proto from a TLS-terminating proxy
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
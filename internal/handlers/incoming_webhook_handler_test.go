@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a blob store that cannot store anything
+type failingStore struct {
+	storage.BlobStore
+}
+
+func (failingStore) Put(context.Context, string, io.Reader, string) error {
+	return errors.New("disk full")
+}
+
+func TestIncomingWebhooks(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	store, _ := storage.NewLocalStore(t.TempDir())
+	storage.Store = store
+	storage.Signer = storage.NewURLSigner([]byte("test-key"), time.Minute)
+
+	attachmentHandler := NewAttachmentHandler(nil, config.StorageConfig{
+		MaxUploadMB:  1,
+		AllowedTypes: []string{"text/plain"},
+	})
+	incomingHandler := NewIncomingWebhookHandler(NewMessageHandler(nil, nil, nil), attachmentHandler)
+	router.POST("/api/v1/admin/groups/:id/incoming-webhooks", incomingHandler.CreateIncomingWebhook)
+	router.GET("/api/v1/admin/groups/:id/incoming-webhooks", incomingHandler.GetIncomingWebhooks)
+	router.DELETE("/api/v1/admin/incoming-webhooks/:id", incomingHandler.DeleteIncomingWebhook)
+	router.POST("/api/v1/hooks/:token", incomingHandler.Post)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/admin/groups/%d/incoming-webhooks", group.ID), strings.NewReader(`{"name": "deploy-bot"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var hook models.IncomingWebhook
+	json.Unmarshal(w.Body.Bytes(), &hook)
+	if !assert.Contains(t, hook.URL, "/api/v1/hooks/") {
+		return
+	}
+	path := hook.URL[strings.Index(hook.URL, "/api/v1/hooks/"):]
+
+	post := func(path string, payload models.IncomingWebhookPayload) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Messages are posted as the webhook's name by default
+	w = post(path, models.IncomingWebhookPayload{Text: "Deploy of api v1.2.3 started"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	assert.Equal(t, "deploy-bot", message.User)
+	assert.Equal(t, group.ID, message.GroupID)
+
+	// The username can be overridden and files attached
+	w = post(path, models.IncomingWebhookPayload{
+		Text:     "Deploy finished",
+		Username: "ci",
+		Attachments: []models.IncomingAttachment{
+			{Filename: "deploy.log", Data: base64.StdEncoding.EncodeToString([]byte("all green\n"))},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &message)
+	assert.Equal(t, "ci", message.User)
+	if assert.Len(t, message.Attachments, 1) {
+		assert.Equal(t, "deploy.log", message.Attachments[0].Filename)
+	}
+
+	// Files are validated before anything is posted
+	w = post(path, models.IncomingWebhookPayload{
+		Text:        "Not posted",
+		Attachments: []models.IncomingAttachment{{Filename: "x.bin", Data: "not base64!"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A message whose files cannot be stored is not posted either
+	storage.Store = failingStore{store}
+	w = post(path, models.IncomingWebhookPayload{
+		Text:        "Not posted",
+		Attachments: []models.IncomingAttachment{{Filename: "deploy.log", Data: base64.StdEncoding.EncodeToString([]byte("all green\n"))}},
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	storage.Store = store
+
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// Unknown tokens are rejected
+	w = post("/api/v1/hooks/nope", models.IncomingWebhookPayload{Text: "hi"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The token itself is never stored or listed
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/admin/groups/%d/incoming-webhooks", group.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotContains(t, w.Body.String(), path[len("/api/v1/hooks/"):])
+	var hooks []models.IncomingWebhook
+	json.Unmarshal(w.Body.Bytes(), &hooks)
+	if assert.Len(t, hooks, 1) {
+		assert.NotNil(t, hooks[0].LastUsedAt)
+	}
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/v1/admin/incoming-webhooks/%d", hook.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = post(path, models.IncomingWebhookPayload{Text: "hi"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMentionLimit = 50
+	maxMentionLimit     = 200
+)
+
+// mentionPattern matches @name tokens that are not part of an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@(\w[\w.-]*)`)
+
+// MentionHandler handles the mention inbox HTTP requests
+type MentionHandler struct{}
+
+// NewMentionHandler creates a new mention handler
+func NewMentionHandler() *MentionHandler {
+	return &MentionHandler{}
+}
+
+// GetMentions handles GET /api/v1/me/mentions
+// Pass unread=true to only return mentions that have not been read.
+func (h *MentionHandler) GetMentions(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	limit := defaultMentionLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxMentionLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	query := database.DB.Preload("Message.Group").Where("mentions.user = ?", strings.ToLower(user))
+	if unread, _ := strconv.ParseBool(c.Query("unread")); unread {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var mentions []models.Mention
+	if err := query.Order("created_at DESC").Limit(limit).Find(&mentions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+		return
+	}
+
+	// Mentions of deleted messages have no message left to show
+	visible := make([]models.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		if mention.Message != nil {
+			visible = append(visible, mention)
+		}
+	}
+
+	var unreadCount int64
+	database.DB.Model(&models.Mention{}).
+		Joins("JOIN messages ON messages.id = mentions.message_id AND messages.deleted_at IS NULL").
+		Where("mentions.user = ? AND mentions.read_at IS NULL", strings.ToLower(user)).
+		Count(&unreadCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"mentions":     visible,
+		"unread_count": unreadCount,
+	})
+}
+
+// MarkMentionRead handles POST /api/v1/me/mentions/:id/read
+func (h *MentionHandler) MarkMentionRead(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mention ID"})
+		return
+	}
+
+	var mention models.Mention
+	if err := database.DB.Where("id = ? AND mentions.user = ?", uint(id), strings.ToLower(user)).First(&mention).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mention not found"})
+		return
+	}
+
+	if mention.ReadAt == nil {
+		now := time.Now()
+		mention.ReadAt = &now
+		if err := database.DB.Model(&mention).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mention"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, mention)
+}
+
+// MarkAllMentionsRead handles POST /api/v1/me/mentions/read
+func (h *MentionHandler) MarkAllMentionsRead(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	result := database.DB.Model(&models.Mention{}).
+		Where("mentions.user = ? AND read_at IS NULL", strings.ToLower(user)).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mentions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// processMentions stores the mentions in a newly posted message and notifies the
+// mentioned users over their SSE streams
+func processMentions(sseHandler *SSEHandler, message models.Message) {
+	storeMentions(sseHandler, message, resolveMentions(sseHandler, message, false))
+}
+
+// updateMentions brings the direct mentions of an edited message in line with its
+// content. Mentions through @here, @group and @oncall stay as they were resolved
+// when the message was posted, so that an edit neither drops users who have since
+// gone offline nor moves mentions to whoever is on call now.
+func updateMentions(sseHandler *SSEHandler, message models.Message) {
+	wanted := resolveMentions(sseHandler, message, true)
+
+	var existing []models.Mention
+	if err := database.DB.Where("message_id = ?", message.ID).Find(&existing).Error; err != nil {
+		log.Printf("Failed to load mentions for message %d: %v", message.ID, err)
+		return
+	}
+
+	// Drop direct mentions that were edited out
+	var stale []uint
+	for _, mention := range existing {
+		if _, ok := wanted[mention.User]; ok {
+			delete(wanted, mention.User)
+		} else if mention.Kind == models.MentionUser {
+			stale = append(stale, mention.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := database.DB.Delete(&models.Mention{}, stale).Error; err != nil {
+			log.Printf("Failed to remove mentions for message %d: %v", message.ID, err)
+		}
+	}
+
+	storeMentions(sseHandler, message, wanted)
+}
+
+// storeMentions records new mentions of users in a message and notifies them
+func storeMentions(sseHandler *SSEHandler, message models.Message, users map[string]string) {
+	for user, kind := range users {
+		mention := models.Mention{MessageID: message.ID, User: user, Kind: kind}
+		if err := database.DB.Create(&mention).Error; err != nil {
+			log.Printf("Failed to store mention of %s in message %d: %v", user, message.ID, err)
+			continue
+		}
+
+		if sseHandler != nil {
+			sseHandler.NotifyUser(user, SSEMessage{Type: EventMention, Message: &message, Data: mention})
+		}
+	}
+}
+
+// resolveMentions expands the mentions in a message to the lowercased users they
+// address and how they were addressed, never including the author. With direct
+// set, only users mentioned by name are resolved.
+func resolveMentions(sseHandler *SSEHandler, message models.Message, direct bool) map[string]string {
+	names, here, group := parseMentions(message.Content)
+	if direct {
+		here, group = false, false
+	}
+	author := strings.ToLower(message.User)
+	resolved := make(map[string]string)
+
+	add := func(user, kind string) {
+		user = strings.ToLower(user)
+		if user == "" || user == author {
+			return
+		}
+		// A direct mention wins over @here and @group
+		if _, ok := resolved[user]; !ok || kind == models.MentionUser {
+			resolved[user] = kind
+		}
+	}
+
+	if group {
+		var members []string
+		if err := database.DB.Raw(`SELECT DISTINCT "user" FROM messages WHERE group_id = ? AND deleted_at IS NULL`, message.GroupID).
+			Scan(&members).Error; err != nil {
+			log.Printf("Failed to load members of group %d: %v", message.GroupID, err)
+		}
+		for _, member := range members {
+			add(member, models.MentionGroup)
+		}
+	}
+
+	if here && sseHandler != nil {
+		for _, user := range sseHandler.ConnectedUsers(message.GroupID) {
+			add(user, models.MentionHere)
+		}
+	}
+
+	for _, name := range names {
+		if users, ok := oncallMentions(name, message.GroupID, message.CreatedAt); ok {
+			if direct {
+				continue
+			}
+			for _, user := range users {
+				add(user, models.MentionOncall)
+			}
+			continue
+		}
+		add(name, models.MentionUser)
+	}
+
+	return resolved
+}
+
+// parseMentions returns the distinct user names mentioned in content and whether it
+// mentions @here or @group
+func parseMentions(content string) (names []string, here bool, group bool) {
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// Trailing dots and dashes end the sentence, not the name
+		name := strings.TrimRight(match[1], ".-")
+		switch strings.ToLower(name) {
+		case "here":
+			here = true
+		case "group":
+			group = true
+		default:
+			if key := strings.ToLower(name); !seen[key] {
+				seen[key] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, here, group
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	names, here, group := parseMentions("@Alice can you look? cc @bob. mail oncall@example.com @here @alice")
+	assert.Equal(t, []string{"Alice", "bob"}, names)
+	assert.True(t, here)
+	assert.False(t, group)
+
+	names, here, group = parseMentions("ping @GROUP")
+	assert.Empty(t, names)
+	assert.False(t, here)
+	assert.True(t, group)
+}
+
+func TestMentions(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	mentionHandler := NewMentionHandler()
+	router.GET("/api/v1/me/mentions", mentionHandler.GetMentions)
+	router.POST("/api/v1/me/mentions/read", mentionHandler.MarkAllMentionsRead)
+	router.POST("/api/v1/me/mentions/:id/read", mentionHandler.MarkMentionRead)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	db.Create(&models.Message{GroupID: group.ID, User: "carol", Content: "hi all"})
+
+	jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: "alice", Content: "@Bob disk full, @group heads up"})
+	req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var mentions []models.Mention
+	db.Order("\"user\"").Find(&mentions)
+	assert.Len(t, mentions, 2)
+	assert.Equal(t, "bob", mentions[0].User)
+	assert.Equal(t, models.MentionUser, mentions[0].Kind)
+	assert.Equal(t, "carol", mentions[1].User)
+	assert.Equal(t, models.MentionGroup, mentions[1].Kind)
+
+	// The inbox requires an identity
+	req, _ = http.NewRequest("GET", "/api/v1/me/mentions", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/me/mentions?unread=true", nil)
+	req.Header.Set("X-User", "BOB")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var inbox struct {
+		Mentions    []models.Mention `json:"mentions"`
+		UnreadCount int64            `json:"unread_count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &inbox)
+	assert.Len(t, inbox.Mentions, 1)
+	assert.Equal(t, int64(1), inbox.UnreadCount)
+
+	req, _ = http.NewRequest("POST", "/api/v1/me/mentions/read", nil)
+	req.Header.Set("X-User", "bob")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Carol leaves the group; her @group mention stays as it was resolved at posting
+	db.Where("\"user\" = ?", "carol").Delete(&models.Message{})
+
+	// Editing the mention out removes it, and new direct mentions are added
+	jsonBody, _ = json.Marshal(models.UpdateMessageRequest{Content: "disk full, @dave @group heads up"})
+	req, _ = http.NewRequest("PUT", "/api/v1/messages/2", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mentions = nil
+	db.Order("\"user\"").Find(&mentions)
+	assert.Len(t, mentions, 2)
+	assert.Equal(t, "carol", mentions[0].User)
+	assert.Equal(t, models.MentionGroup, mentions[0].Kind)
+	assert.Equal(t, "dave", mentions[1].User)
+	assert.Equal(t, models.MentionUser, mentions[1].Kind)
+}
@@ -1,27 +1,51 @@
 package handlers
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"sre-chat-api/internal/commands"
 	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
 	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/unfurl"
+	"sre-chat-api/internal/webhooks"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// unfurlTimeout bounds the background work of previewing a message's links
+const unfurlTimeout = 30 * time.Second
+
 // MessageHandler handles message-related HTTP requests
 type MessageHandler struct {
 	sseHandler *SSEHandler
+	unfurler   *unfurl.Service
+	commands   *commands.Registry
+	reminders  *ReminderHandler
 }
 
-// NewMessageHandler creates a new message handler
-func NewMessageHandler(sseHandler *SSEHandler) *MessageHandler {
+// NewMessageHandler creates a new message handler. The unfurler may be nil to
+// disable link previews, and the registry nil to store slash commands verbatim.
+func NewMessageHandler(sseHandler *SSEHandler, unfurler *unfurl.Service, registry *commands.Registry) *MessageHandler {
 	return &MessageHandler{
 		sseHandler: sseHandler,
+		unfurler:   unfurler,
+		commands:   registry,
+		reminders:  NewReminderHandler(nil),
 	}
 }
 
+// SetReminders creates the reminders of /remind through the reminder handler, so
+// that they wake its runner like those created through the reminders API
+func (h *MessageHandler) SetReminders(reminders *ReminderHandler) {
+	h.reminders = reminders
+}
+
 // CreateMessage handles POST /api/v1/messages
 func (h *MessageHandler) CreateMessage(c *gin.Context) {
 	var req models.CreateMessageRequest
@@ -30,73 +54,311 @@ func (h *MessageHandler) CreateMessage(c *gin.Context) {
 		return
 	}
 
+	result, err := h.createMessage(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch {
+	case result.command != nil:
+		c.JSON(http.StatusOK, result.command)
+	case result.scheduled != nil:
+		c.JSON(http.StatusAccepted, result.scheduled)
+	default:
+		c.JSON(http.StatusCreated, result.message)
+	}
+}
+
+// createResult is the outcome of createMessage: exactly one field is set, depending
+// on whether the message was posted, scheduled or ran a slash command
+type createResult struct {
+	message   *models.Message
+	scheduled *models.ScheduledMessage
+	command   *models.CommandResponse
+}
+
+// createMessage posts a message, schedules it or runs it as a slash command, for
+// the REST, gRPC and GraphQL APIs
+func (h *MessageHandler) createMessage(ctx context.Context, req models.CreateMessageRequest) (createResult, error) {
+	if req.User == "" || req.Content == "" {
+		return createResult{}, newRequestError(http.StatusBadRequest, "user and content are required")
+	}
+
+	groupID, err := resolveGroup(req.GroupID)
+	if err != nil {
+		return createResult{}, err
+	}
+	req.GroupID = groupID
+
+	// Bots post through the bot API so that their messages are flagged
+	if err := checkPoster(req.User); err != nil {
+		return createResult{}, err
+	}
+
+	if h.commands != nil && commands.IsCommand(req.Content) {
+		if req.SendAt != nil {
+			return createResult{}, newRequestError(http.StatusBadRequest, "Slash commands cannot be scheduled")
+		}
+		response := h.runCommand(ctx, req)
+		return createResult{command: &response}, nil
+	}
+	// A leading "//" escapes a message that should start with a slash
+	if h.commands != nil && strings.HasPrefix(req.Content, "//") {
+		req.Content = req.Content[1:]
+	}
+
+	// Messages scheduled for the future are posted later by the scheduler
+	if req.SendAt != nil && req.SendAt.After(time.Now()) {
+		scheduled := models.ScheduledMessage{
+			GroupID: req.GroupID,
+			User:    req.User,
+			Content: req.Content,
+			SendAt:  req.SendAt.UTC(),
+			Status:  models.ScheduledPending,
+		}
+		if err := database.DB.Create(&scheduled).Error; err != nil {
+			return createResult{}, newRequestError(http.StatusInternalServerError, "Failed to schedule message")
+		}
+		return createResult{scheduled: &scheduled}, nil
+	}
+
+	message, err := h.postMessage(req.GroupID, req.User, req.Content)
+	if err != nil {
+		return createResult{}, publishError(err, "Failed to create message")
+	}
+	return createResult{message: &message}, nil
+}
+
+// postMessage stores a new message and delivers it to clients
+func (h *MessageHandler) postMessage(groupID uint, user, content string) (models.Message, error) {
+	return h.publishMessage(models.Message{
+		GroupID: groupID,
+		User:    user,
+		Content: content,
+	})
+}
+
+// publishMessage stores a prepared message and delivers it to clients
+func (h *MessageHandler) publishMessage(message models.Message) (models.Message, error) {
+	return h.publishMessageWith(message, nil)
+}
+
+// publishMessageWith is publishMessage that also runs with, if not nil, in the
+// transaction storing the message, to store what belongs to it before clients see it
+func (h *MessageHandler) publishMessageWith(message models.Message, with func(tx *gorm.DB, message models.Message) error) (models.Message, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := messages.Create(tx, &message); err != nil {
+			return err
+		}
+		if with != nil {
+			return with(tx, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return message, err
+	}
+
+	// Load group relationship and any already cached link previews
+	database.DB.Preload("Group").First(&message, message.ID)
+	enrichMessages(&message)
+
+	h.Deliver(message)
+
+	return message, nil
+}
+
+// runCommand dispatches a slash command instead of storing it. The reply is sent
+// only to the caller, both in the response and over their SSE streams.
+func (h *MessageHandler) runCommand(ctx context.Context, req models.CreateMessageRequest) models.CommandResponse {
+	commandCtx := &commands.Context{
+		Context: ctx,
+		User:    req.User,
+		GroupID: req.GroupID,
+		Post: func(content string) (models.Message, error) {
+			return h.postMessage(req.GroupID, req.User, content)
+		},
+		PostAs:   h.postMessage,
+		Announce: h.Announce,
+		Deliver:  h.Deliver,
+		Schedule: func(content string, sendAt time.Time) (models.ScheduledMessage, error) {
+			return h.scheduleMessage(ctx, req.GroupID, req.User, content, sendAt)
+		},
+		Remind: func(reminder models.ReminderRequest) (models.Reminder, error) {
+			return h.reminders.createReminder(req.GroupID, reminder)
+		},
+	}
+
+	name, reply := h.commands.Dispatch(commandCtx, req.Content)
+	response := models.CommandResponse{
+		Command:   name,
+		GroupID:   req.GroupID,
+		Text:      reply,
+		Ephemeral: true,
+	}
+
+	if h.sseHandler != nil {
+		h.sseHandler.NotifyUser(req.User, SSEMessage{Type: EventCommandResponse, Data: response})
+	}
+
+	return response
+}
+
+// scheduleMessage posts content at sendAt through createMessage, so that it is
+// validated like a message sent with send_at. A time that has already passed by
+// then posts the message at once, which is reported as sent.
+func (h *MessageHandler) scheduleMessage(ctx context.Context, groupID uint, user, content string, sendAt time.Time) (models.ScheduledMessage, error) {
+	result, err := h.createMessage(ctx, models.CreateMessageRequest{
+		GroupID: groupID,
+		User:    user,
+		Content: content,
+		SendAt:  &sendAt,
+	})
+	if err != nil {
+		return models.ScheduledMessage{}, err
+	}
+	if result.scheduled != nil {
+		return *result.scheduled, nil
+	}
+	return models.ScheduledMessage{
+		GroupID:   groupID,
+		User:      user,
+		Content:   result.message.Content,
+		SendAt:    result.message.CreatedAt,
+		Status:    models.ScheduledSent,
+		MessageID: &result.message.ID,
+	}, nil
+}
+
+// resolveGroupID returns the group a new message is posted to, defaulting to the
+// "SRE Bootcamp" group. It writes an error response and returns false if the group
+// does not exist.
+func resolveGroupID(c *gin.Context, groupID uint) (uint, bool) {
+	id, err := resolveGroup(groupID)
+	if err != nil {
+		respondError(c, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// resolveGroup is resolveGroupID for logic shared with the gRPC API
+func resolveGroup(groupID uint) (uint, error) {
 	// If no group_id is provided, use the default "SRE Bootcamp" group
-	if req.GroupID == 0 {
+	if groupID == 0 {
 		var defaultGroup models.Group
 		if err := database.DB.Where("name = ?", "SRE Bootcamp").First(&defaultGroup).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Default group not found"})
-			return
+			return 0, newRequestError(http.StatusInternalServerError, "Default group not found")
 		}
-		req.GroupID = defaultGroup.ID
+		return defaultGroup.ID, nil
 	}
 
 	// Verify group exists
 	var group models.Group
-	if err := database.DB.First(&group, req.GroupID).Error; err != nil {
+	if err := database.DB.First(&group, groupID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
-			return
+			return 0, newRequestError(http.StatusNotFound, "Group not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
+		return 0, newRequestError(http.StatusInternalServerError, "Database error")
 	}
 
-	message := models.Message{
-		GroupID: req.GroupID,
-		User:    req.User,
-		Content: req.Content,
-	}
+	return group.ID, nil
+}
 
-	if err := database.DB.Create(&message).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message"})
-		return
+// Deliver notifies SSE clients and mentioned users about a newly posted message and
+// starts unfurling its links
+func (h *MessageHandler) Deliver(message models.Message) {
+	if h.sseHandler != nil {
+		h.sseHandler.NotifyNewMessage(message)
 	}
 
-	// Load group relationship
-	database.DB.Preload("Group").First(&message, message.ID)
+	processMentions(h.sseHandler, message)
+	go h.unfurlLinks(message)
+}
 
-	// Notify SSE clients about the new message
+// Announce notifies SSE clients that an existing message was edited
+func (h *MessageHandler) Announce(message models.Message) {
 	if h.sseHandler != nil {
-		h.sseHandler.NotifyNewMessage(message)
+		h.sseHandler.NotifyEvent(EventMessageUpdated, message)
 	}
-
-	c.JSON(http.StatusCreated, message)
 }
 
 // GetMessages handles GET /api/v1/messages
+// Pass limit to page through the listing, continuing with after_id (sort=asc) or
+// before_id (sort=desc) set to the last message of the previous page.
 func (h *MessageHandler) GetMessages(c *gin.Context) {
-	var messages []models.Message
+	filters, err := parseMessageFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	query := database.DB.Preload("Group")
+	direction, err := parseSortDirection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Optional filter by group_id
-	if groupID := c.Query("group_id"); groupID != "" {
-		id, err := strconv.ParseUint(groupID, 10, 32)
+	page, err := parseMessagePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := parseFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only load the group and the enrichments when they will be returned
+	withGroup := fields == nil || containsField(fields, "group")
+	enrich := fields == nil || containsField(fields, "reactions") || containsField(fields, "attachments") || containsField(fields, "link_previews") || containsField(fields, "poll")
+
+	messages, err := listMessages(filters, page, direction, withGroup, enrich)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if fields != nil {
+		projected, err := selectFields(messages, fields)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
 			return
 		}
-		query = query.Where("group_id = ?", uint(id))
-	}
-
-	if err := query.Order("created_at ASC").Find(&messages).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		c.JSON(http.StatusOK, projected)
 		return
 	}
 
 	c.JSON(http.StatusOK, messages)
 }
 
+// listMessages returns one page of the messages matching the filters, ordered by
+// direction, optionally with their group and their reactions, attachments and link
+// previews
+func listMessages(filters messageFilters, page messagePage, direction string, withGroup, enrich bool) ([]models.Message, error) {
+	var messages []models.Message
+
+	query := database.DB.Model(&models.Message{})
+	if withGroup {
+		query = query.Preload("Group")
+	}
+	query = page.apply(filters.apply(query))
+
+	if err := query.Order("created_at " + direction).Order("id " + direction).Find(&messages).Error; err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Failed to fetch messages")
+	}
+
+	if enrich {
+		if err := enrichMessageSlice(messages); err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Failed to fetch messages")
+		}
+	}
+	return messages, nil
+}
+
 // GetMessage handles GET /api/v1/messages/:id
 func (h *MessageHandler) GetMessage(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -105,17 +367,57 @@ func (h *MessageHandler) GetMessage(c *gin.Context) {
 		return
 	}
 
+	message, err := getMessage(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, message)
+}
+
+// getMessage returns a message with its group, reactions, attachments and link previews
+func getMessage(id uint) (models.Message, error) {
+	message, err := loadMessage(id, "Group")
+	if err != nil {
+		return message, err
+	}
+
+	enrichMessages(&message)
+	return message, nil
+}
+
+// loadMessage loads a message and the named relations. Deleted messages are not
+// found.
+func loadMessage(id uint, preloads ...string) (models.Message, error) {
 	var message models.Message
-	if err := database.DB.Preload("Group").First(&message, uint(id)).Error; err != nil {
+	query := database.DB
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if err := query.First(&message, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-			return
+			return message, newRequestError(http.StatusNotFound, "Message not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
+		return message, newRequestError(http.StatusInternalServerError, "Database error")
 	}
+	return message, nil
+}
 
-	c.JSON(http.StatusOK, message)
+// findMessage loads the message named by the id path parameter, writing an error
+// response and returning false if it is invalid or does not exist
+func findMessage(c *gin.Context) (models.Message, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return models.Message{}, false
+	}
+
+	message, err := loadMessage(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return message, false
+	}
+	return message, true
 }
 
 // UpdateMessage handles PUT /api/v1/messages/:id
@@ -132,24 +434,44 @@ func (h *MessageHandler) UpdateMessage(c *gin.Context) {
 		return
 	}
 
-	var message models.Message
-	if err := database.DB.First(&message, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	message, err := h.updateMessage(uint(id), req.Content)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, message)
+}
+
+// updateMessage replaces a message's content and announces the edit
+func (h *MessageHandler) updateMessage(id uint, content string) (models.Message, error) {
+	if content == "" {
+		return models.Message{}, newRequestError(http.StatusBadRequest, "content is required")
+	}
 
-	message.Content = req.Content
-	if err := database.DB.Save(&message).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
-		return
+	message, err := loadMessage(id)
+	if err != nil {
+		return message, err
+	}
+
+	message.Content = content
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&message).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.EventMessageUpdated, message)
+	})
+	if err != nil {
+		return message, newRequestError(http.StatusInternalServerError, "Failed to update message")
 	}
 
 	database.DB.Preload("Group").First(&message, message.ID)
-	c.JSON(http.StatusOK, message)
+	enrichMessages(&message)
+
+	h.Announce(message)
+	updateMentions(h.sseHandler, message)
+	go h.unfurlLinks(message)
+
+	return message, nil
 }
 
 // DeleteMessage handles DELETE /api/v1/messages/:id
@@ -160,20 +482,49 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 		return
 	}
 
-	var message models.Message
-	if err := database.DB.First(&message, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	if err := deleteMessage(uint(id)); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if err := database.DB.Delete(&message).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
+
+// deleteMessage soft-deletes a message
+func deleteMessage(id uint) error {
+	message, err := loadMessage(id)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&message).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.EventMessageDeleted, message)
+	})
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Failed to delete message")
+	}
+	return nil
+}
+
+// unfurlLinks fetches previews for the links in a message and, if any new previews
+// were found, notifies SSE clients so they can show them
+func (h *MessageHandler) unfurlLinks(message models.Message) {
+	if h.unfurler == nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+	ctx, cancel := context.WithTimeout(context.Background(), unfurlTimeout)
+	defer cancel()
+
+	fetched, err := h.unfurler.Unfurl(ctx, message.Content)
+	if err != nil {
+		log.Printf("Failed to unfurl links in message %d: %v", message.ID, err)
+	}
+
+	if fetched > 0 && h.sseHandler != nil {
+		h.sseHandler.NotifyEvent(EventLinkPreview, message)
+	}
 }
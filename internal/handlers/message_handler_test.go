@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"sre-chat-api/internal/commands"
 	"sre-chat-api/internal/config"
 	"sre-chat-api/internal/database"
 	"sre-chat-api/internal/models"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -36,9 +38,34 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Clean up and migrate
+	db.Exec("DROP TABLE IF EXISTS bot_memberships CASCADE")
+	db.Exec("DROP TABLE IF EXISTS bot_tokens CASCADE")
+	db.Exec("DROP TABLE IF EXISTS bots CASCADE")
+	db.Exec("DROP TABLE IF EXISTS oncall_overrides CASCADE")
+	db.Exec("DROP TABLE IF EXISTS oncall_schedules CASCADE")
+	db.Exec("DROP TABLE IF EXISTS incidents CASCADE")
+	db.Exec("DROP TABLE IF EXISTS alerts CASCADE")
+	db.Exec("DROP TABLE IF EXISTS incoming_webhooks CASCADE")
+	db.Exec("DROP TABLE IF EXISTS webhook_attempts CASCADE")
+	db.Exec("DROP TABLE IF EXISTS webhook_deliveries CASCADE")
+	db.Exec("DROP TABLE IF EXISTS webhooks CASCADE")
+	db.Exec("DROP TABLE IF EXISTS poll_votes CASCADE")
+	db.Exec("DROP TABLE IF EXISTS poll_options CASCADE")
+	db.Exec("DROP TABLE IF EXISTS polls CASCADE")
+	db.Exec("DROP TABLE IF EXISTS leader_leases CASCADE")
+	db.Exec("DROP TABLE IF EXISTS reminder_runs CASCADE")
+	db.Exec("DROP TABLE IF EXISTS reminders CASCADE")
+	db.Exec("DROP TABLE IF EXISTS scheduled_messages CASCADE")
+	db.Exec("DROP TABLE IF EXISTS pins CASCADE")
+	db.Exec("DROP TABLE IF EXISTS read_positions CASCADE")
+	db.Exec("DROP TABLE IF EXISTS mentions CASCADE")
+	db.Exec("DROP TABLE IF EXISTS link_previews CASCADE")
+	db.Exec("DROP TABLE IF EXISTS attachments CASCADE")
+	db.Exec("DROP TABLE IF EXISTS reactions CASCADE")
+	db.Exec("DROP TABLE IF EXISTS retention_policies CASCADE")
 	db.Exec("DROP TABLE IF EXISTS messages CASCADE")
 	db.Exec("DROP TABLE IF EXISTS groups CASCADE")
-	db.AutoMigrate(&models.Group{}, &models.Message{})
+	db.AutoMigrate(&models.Group{}, &models.Message{}, &models.RetentionPolicy{}, &models.Reaction{}, &models.Attachment{}, &models.LinkPreview{}, &models.Mention{}, &models.ReadPosition{}, &models.Pin{}, &models.ScheduledMessage{}, &models.Reminder{}, &models.ReminderRun{}, &models.LeaderLease{}, &models.Poll{}, &models.PollOption{}, &models.PollVote{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}, &models.IncomingWebhook{}, &models.Alert{}, &models.Incident{}, &models.OncallSchedule{}, &models.OncallOverride{}, &models.Bot{}, &models.BotToken{}, &models.BotMembership{})
 
 	// Create default group
 	db.Create(&models.Group{
@@ -54,7 +81,7 @@ func setupRouter(db *gorm.DB) *gin.Engine {
 	database.DB = db
 
 	router := gin.New()
-	messageHandler := NewMessageHandler(nil) // nil SSE handler for tests
+	messageHandler := NewMessageHandler(nil, nil, commands.Default) // nil SSE handler and unfurler for tests
 	healthHandler := NewHealthHandler()
 
 	v1 := router.Group("/api/v1")
@@ -127,6 +154,59 @@ func TestGetMessages(t *testing.T) {
 	assert.GreaterOrEqual(t, len(messages), 1)
 }
 
+func TestGetMessagesFilters(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	db.Create(&models.Message{GroupID: group.ID, User: "alice", Content: "Runbook: https://wiki.example.com/runbook"})
+	db.Create(&models.Message{GroupID: group.ID, User: "bob", Content: "Looking at the runbook now"})
+	db.Create(&models.Message{GroupID: group.ID, User: "alice", Content: "Done"})
+
+	// Filter by user and link, newest first, with selected fields
+	req, _ := http.NewRequest("GET", "/api/v1/messages?user=alice&has_link=true&sort=desc&fields=id,user,content", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var messages []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &messages)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "alice", messages[0]["user"])
+	assert.NotContains(t, messages[0], "group")
+	assert.NotContains(t, messages[0], "created_at")
+
+	// Case-insensitive contains
+	req, _ = http.NewRequest("GET", "/api/v1/messages?contains=RUNBOOK", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var all []models.Message
+	json.Unmarshal(w.Body.Bytes(), &all)
+	assert.Len(t, all, 2)
+
+	// A date-only until includes the whole day
+	req, _ = http.NewRequest("GET", "/api/v1/messages?user=alice&until="+time.Now().UTC().Format("2006-01-02"), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	json.Unmarshal(w.Body.Bytes(), &all)
+	assert.Len(t, all, 2)
+
+	// Invalid parameters are rejected
+	for _, query := range []string{"sort=sideways", "fields=id,password", "since=yesterday", "has_link=maybe"} {
+		req, _ = http.NewRequest("GET", "/api/v1/messages?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 func TestGetMessage(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -239,3 +319,45 @@ func TestHealthCheck(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Equal(t, "healthy", response["status"])
 }
+
+func TestSlashCommands(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	post := func(content string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: "alice", Content: content})
+		req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/topic Incident 42: checkout latency")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.CommandResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "topic", response.Command)
+	assert.True(t, response.Ephemeral)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	assert.Equal(t, "Incident 42: checkout latency", group.Description)
+
+	// Only the announcement was stored, not the command
+	var messages []models.Message
+	db.Find(&messages)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "changed the topic to: Incident 42: checkout latency", messages[0].Content)
+
+	// A doubled slash posts the text as typed
+	w = post("//topic is not a command")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	assert.Equal(t, "/topic is not a command", message.Content)
+}
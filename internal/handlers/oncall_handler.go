@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/oncall"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// oncallTimeFormat formats shift boundaries in replies, in the schedule's time zone
+const oncallTimeFormat = "Mon 2006-01-02 15:04 MST"
+
+func init() {
+	commands.Register(commands.Command{
+		Name:        "oncall",
+		Usage:       "/oncall [schedule]",
+		Description: "Show who is on call and who is next, for this group's schedules or the one named",
+		Run:         runOncall,
+	})
+}
+
+// OncallHandler handles on-call schedule HTTP requests
+type OncallHandler struct{}
+
+// NewOncallHandler creates a new on-call handler
+func NewOncallHandler() *OncallHandler {
+	return &OncallHandler{}
+}
+
+// GetSchedules handles GET /api/v1/oncall
+// Pass group_id to list the schedules handing off into one group.
+func (h *OncallHandler) GetSchedules(c *gin.Context) {
+	query := database.DB.Order("name")
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		query = query.Where("group_id = ?", uint(id))
+	}
+
+	var schedules []models.OncallSchedule
+	if err := query.Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateSchedule handles POST /api/v1/admin/oncall
+func (h *OncallHandler) CreateSchedule(c *gin.Context) {
+	var req models.OncallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var schedule models.OncallSchedule
+	if !applyOncallScheduleRequest(c, &schedule, req) {
+		return
+	}
+
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// GetSchedule handles GET /api/v1/oncall/:schedule
+func (h *OncallHandler) GetSchedule(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule handles PUT /api/v1/admin/oncall/:schedule
+// The whole schedule is replaced; a change of who is on call is announced as a handoff.
+func (h *OncallHandler) UpdateSchedule(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req models.OncallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyOncallScheduleRequest(c, &schedule, req) {
+		return
+	}
+
+	if err := database.DB.Save(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule handles DELETE /api/v1/admin/oncall/:schedule
+func (h *OncallHandler) DeleteSchedule(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// GetNow handles GET /api/v1/oncall/:schedule/now
+// Pass at (RFC 3339) to ask who is on call at another time.
+func (h *OncallHandler) GetNow(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected RFC 3339"})
+			return
+		}
+		at = parsed
+	}
+
+	var overrides []models.OncallOverride
+	if err := database.DB.Where("schedule_id = ?", schedule.ID).Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overrides"})
+		return
+	}
+
+	now, err := oncall.Now(schedule, overrides, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, now)
+}
+
+// GetOverrides handles GET /api/v1/oncall/:schedule/overrides
+// Overrides that have ended are omitted unless past=true.
+func (h *OncallHandler) GetOverrides(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("schedule_id = ?", schedule.ID)
+	if past, _ := strconv.ParseBool(c.Query("past")); !past {
+		query = query.Where("ends_at > ?", time.Now())
+	}
+
+	var overrides []models.OncallOverride
+	if err := query.Order("starts_at, id").Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overrides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// CreateOverride handles POST /api/v1/admin/oncall/:schedule/overrides
+func (h *OncallHandler) CreateOverride(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req models.OncallOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override := models.OncallOverride{
+		ScheduleID: schedule.ID,
+		User:       strings.TrimPrefix(req.User, "@"),
+		StartsAt:   req.StartsAt.UTC(),
+		EndsAt:     req.EndsAt.UTC(),
+		CreatedBy:  currentUser(c),
+	}
+	if err := oncall.ValidateOverride(override); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&override).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create override"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteOverride handles DELETE /api/v1/admin/oncall/:schedule/overrides/:id
+func (h *OncallHandler) DeleteOverride(c *gin.Context) {
+	schedule, ok := findOncallSchedule(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid override ID"})
+		return
+	}
+
+	result := database.DB.Where("schedule_id = ?", schedule.ID).Delete(&models.OncallOverride{}, uint(id))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete override"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Override not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Override deleted successfully"})
+}
+
+// applyOncallScheduleRequest validates the request and copies it onto the schedule,
+// filling in defaults. It writes an error response and returns false when the
+// request is invalid, names a missing group or takes another schedule's name.
+func applyOncallScheduleRequest(c *gin.Context, schedule *models.OncallSchedule, req models.OncallScheduleRequest) bool {
+	updated := *schedule
+	updated.Name = strings.ToLower(req.Name)
+	updated.GroupID = req.GroupID
+	updated.Timezone = req.Timezone
+	if updated.Timezone == "" {
+		updated.Timezone = "UTC"
+	}
+	updated.Participants = make([]string, len(req.Participants))
+	for i, participant := range req.Participants {
+		updated.Participants[i] = strings.TrimPrefix(participant, "@")
+	}
+	updated.ShiftDays = req.ShiftDays
+	if updated.ShiftDays == 0 {
+		updated.ShiftDays = 7
+	}
+	updated.HandoffTime = req.HandoffTime
+	if updated.HandoffTime == "" {
+		updated.HandoffTime = "09:00"
+	}
+	updated.StartDate = req.StartDate
+
+	if err := oncall.Validate(updated); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	var groups int64
+	if err := database.DB.Model(&models.Group{}).Where("id = ?", updated.GroupID).Count(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if groups == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+		return false
+	}
+
+	var taken int64
+	if err := database.DB.Model(&models.OncallSchedule{}).
+		Where("name = ? AND id <> ?", updated.Name, schedule.ID).
+		Count(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A schedule with this name already exists"})
+		return false
+	}
+
+	*schedule = updated
+	return true
+}
+
+// findOncallSchedule loads the schedule named by the :schedule parameter, writing
+// an error response and returning false if it does not exist
+func findOncallSchedule(c *gin.Context) (models.OncallSchedule, bool) {
+	var schedule models.OncallSchedule
+
+	err := database.DB.Where("name = ?", strings.ToLower(c.Param("schedule"))).First(&schedule).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return schedule, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return schedule, false
+	}
+
+	return schedule, true
+}
+
+// oncallMentions resolves an "@oncall" mention to whoever is on call for the
+// schedules handing off into the group, and "@oncall-<schedule>" to whoever is on
+// call for that schedule. ok is false for other names.
+func oncallMentions(name string, groupID uint, at time.Time) (users []string, ok bool) {
+	name = strings.ToLower(name)
+	query := database.DB.Model(&models.OncallSchedule{})
+	switch {
+	case name == "oncall":
+		query = query.Where("group_id = ?", groupID)
+	case strings.HasPrefix(name, oncall.MentionPrefix):
+		query = query.Where("name = ?", strings.TrimPrefix(name, oncall.MentionPrefix))
+	default:
+		return nil, false
+	}
+
+	var schedules []models.OncallSchedule
+	if err := query.Find(&schedules).Error; err != nil {
+		log.Printf("Failed to load on-call schedules for @%s: %v", name, err)
+		return nil, true
+	}
+
+	for _, schedule := range schedules {
+		var overrides []models.OncallOverride
+		if err := database.DB.Where("schedule_id = ? AND starts_at <= ? AND ends_at > ?", schedule.ID, at, at).
+			Find(&overrides).Error; err != nil {
+			log.Printf("Failed to load overrides of schedule %s: %v", schedule.Name, err)
+			continue
+		}
+		shift, err := oncall.At(schedule, overrides, at)
+		if err != nil {
+			log.Printf("Failed to resolve on-call for schedule %s: %v", schedule.Name, err)
+			continue
+		}
+		users = append(users, shift.User)
+	}
+	return users, true
+}
+
+// runOncall reports who is on call now and who takes over next
+func runOncall(ctx *commands.Context) (string, error) {
+	query := database.DB.Order("name")
+	switch len(ctx.Args) {
+	case 0:
+		query = query.Where("group_id = ?", ctx.GroupID)
+	case 1:
+		query = query.Where("name = ?", strings.ToLower(strings.TrimPrefix(ctx.Args[0], "@"+oncall.MentionPrefix)))
+	default:
+		return "", commands.ErrUsage
+	}
+
+	var schedules []models.OncallSchedule
+	if err := query.Find(&schedules).Error; err != nil {
+		return "", err
+	}
+	if len(schedules) == 0 {
+		if len(ctx.Args) == 1 {
+			return fmt.Sprintf("No on-call schedule named %s.", ctx.Args[0]), nil
+		}
+		return "No on-call schedules hand off into this group.", nil
+	}
+
+	at := time.Now()
+	lines := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		var overrides []models.OncallOverride
+		if err := database.DB.Where("schedule_id = ? AND ends_at > ?", schedule.ID, at).Find(&overrides).Error; err != nil {
+			return "", err
+		}
+		now, err := oncall.Now(schedule, overrides, at)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s until %s, then %s",
+			schedule.Name, oncallShiftUser(now.Current), oncallShiftEnd(schedule, now.Current), oncallShiftUser(now.Next)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// oncallShiftUser names the user of a shift, marking overrides
+func oncallShiftUser(shift models.OncallShift) string {
+	if shift.Override {
+		return shift.User + " (override)"
+	}
+	return shift.User
+}
+
+// oncallShiftEnd formats the end of a shift in the schedule's time zone
+func oncallShiftEnd(schedule models.OncallSchedule, shift models.OncallShift) string {
+	end := shift.End
+	if loc, err := time.LoadLocation(schedule.Timezone); err == nil {
+		end = end.In(loc)
+	}
+	return end.Format(oncallTimeFormat)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestOncallSchedules(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+
+	oncallHandler := NewOncallHandler()
+	router.POST("/api/v1/admin/oncall", oncallHandler.CreateSchedule)
+	router.GET("/api/v1/oncall/:schedule/now", oncallHandler.GetNow)
+	router.POST("/api/v1/admin/oncall/:schedule/overrides", oncallHandler.CreateOverride)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "alice")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A daily rotation that started with alice today
+	now := time.Now().UTC()
+	start := now.Add(-time.Hour)
+	schedule := models.OncallScheduleRequest{
+		Name:         "payments",
+		GroupID:      group.ID,
+		Participants: []string{"alice", "@bob"},
+		ShiftDays:    1,
+		HandoffTime:  start.Format("15:04"),
+		StartDate:    start.Format("2006-01-02"),
+	}
+	w := request("POST", "/api/v1/admin/oncall", schedule)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Names must be unique and fit in a mention
+	assert.Equal(t, http.StatusConflict, request("POST", "/api/v1/admin/oncall", schedule).Code)
+	schedule.Name = "Payments Team"
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/api/v1/admin/oncall", schedule).Code)
+
+	w = request("GET", "/api/v1/oncall/payments/now", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var current models.OncallNow
+	json.Unmarshal(w.Body.Bytes(), &current)
+	assert.Equal(t, "alice", current.Current.User)
+	assert.Equal(t, "bob", current.Next.User)
+
+	// The first check announces who is on call, later checks only handoffs
+	announcer := jobs.NewHandoffAnnouncer(config.OncallConfig{IntervalSeconds: 1, User: "oncall"}, zap.NewNop())
+	announced, err := announcer.AnnounceDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, announced)
+	announced, _ = announcer.AnnounceDue(context.Background(), now)
+	assert.Equal(t, 0, announced)
+
+	// An override hands off to carol
+	w = request("POST", "/api/v1/admin/oncall/payments/overrides", models.OncallOverrideRequest{
+		User:     "carol",
+		StartsAt: now.Add(-time.Minute),
+		EndsAt:   now.Add(time.Hour),
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	announced, _ = announcer.AnnounceDue(context.Background(), now)
+	assert.Equal(t, 1, announced)
+
+	var handoffs []models.Message
+	db.Where("\"user\" = ?", "oncall").Order("id").Find(&handoffs)
+	if assert.Len(t, handoffs, 2) {
+		assert.True(t, strings.HasPrefix(handoffs[0].Content, "@alice is now on call for payments until "))
+		assert.True(t, strings.HasPrefix(handoffs[1].Content, "On-call handoff for payments: alice → @carol until "))
+		assert.True(t, strings.HasSuffix(handoffs[1].Content, "(override)"))
+	}
+
+	// @oncall resolves to whoever is on shift
+	w = request("POST", "/api/v1/messages", models.CreateMessageRequest{User: "dave", Content: "@oncall the checkout is down", GroupID: group.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+
+	var mentions []models.Mention
+	db.Where("message_id = ?", message.ID).Find(&mentions)
+	if assert.Len(t, mentions, 1) {
+		assert.Equal(t, "carol", mentions[0].User)
+		assert.Equal(t, models.MentionOncall, mentions[0].Kind)
+	}
+
+	w = request("POST", "/api/v1/messages", models.CreateMessageRequest{User: "dave", Content: "cc @oncall-payments", GroupID: group.ID})
+	json.Unmarshal(w.Body.Bytes(), &message)
+	var count int64
+	db.Model(&models.Mention{}).Where("message_id = ? AND \"user\" = ?", message.ID, "carol").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// /oncall replies with the current and next shift
+	command := func(content string) string {
+		w := request("POST", "/api/v1/messages", models.CreateMessageRequest{User: "dave", Content: content, GroupID: group.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.CommandResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Text
+	}
+	reply := command("/oncall")
+	assert.True(t, strings.HasPrefix(reply, "payments: carol (override) until "), reply)
+	assert.True(t, strings.HasSuffix(reply, ", then alice"), reply)
+	assert.Equal(t, reply, command("/oncall payments"))
+	assert.Equal(t, "No on-call schedule named checkout.", command("/oncall checkout"))
+
+	w = request("GET", fmt.Sprintf("/api/v1/oncall/payments/now?at=%s", now.Add(2*time.Hour).Format(time.RFC3339)), nil)
+	json.Unmarshal(w.Body.Bytes(), &current)
+	assert.Equal(t, "alice", current.Current.User)
+}
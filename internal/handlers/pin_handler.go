@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errPinLimit is returned when a group already has the maximum number of pins
+var errPinLimit = errors.New("pin limit reached")
+
+// PinHandler handles pinned message HTTP requests
+type PinHandler struct {
+	sseHandler  *SSEHandler
+	maxPerGroup int
+}
+
+// NewPinHandler creates a new pin handler
+func NewPinHandler(sseHandler *SSEHandler, cfg config.PinsConfig) *PinHandler {
+	return &PinHandler{
+		sseHandler:  sseHandler,
+		maxPerGroup: cfg.MaxPerGroup,
+	}
+}
+
+// GetPins handles GET /api/v1/groups/:id/pins
+func (h *PinHandler) GetPins(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var pins []models.Pin
+	if err := database.DB.Preload("Message").
+		Where("group_id = ?", group.ID).
+		Order("created_at DESC").
+		Find(&pins).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pins"})
+		return
+	}
+
+	// Pins of deleted messages stay hidden until the message is restored
+	visible := make([]models.Pin, 0, len(pins))
+	var messages []*models.Message
+	for _, pin := range pins {
+		if pin.Message != nil {
+			visible = append(visible, pin)
+			messages = append(messages, visible[len(visible)-1].Message)
+		}
+	}
+	if err := enrichMessages(messages...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, visible)
+}
+
+// PinMessage handles POST /api/v1/messages/:id/pin
+func (h *PinHandler) PinMessage(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	pin := models.Pin{GroupID: message.GroupID, MessageID: message.ID, PinnedBy: user}
+	created := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the group so concurrent pins cannot exceed the limit
+		var group models.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, message.GroupID).Error; err != nil {
+			return err
+		}
+
+		// Pinning twice is a no-op
+		if err := tx.Where("message_id = ?", message.ID).First(&pin).Error; err == nil {
+			return nil
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		// Pins of deleted messages are hidden and do not count towards the limit
+		var count int64
+		if err := tx.Model(&models.Pin{}).
+			Joins("JOIN messages ON messages.id = pins.message_id AND messages.deleted_at IS NULL").
+			Where("pins.group_id = ?", message.GroupID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if h.maxPerGroup > 0 && count >= int64(h.maxPerGroup) {
+			return errPinLimit
+		}
+
+		created = true
+		return tx.Create(&pin).Error
+	})
+	if err != nil {
+		if err == errPinLimit {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A group can have at most %d pinned messages", h.maxPerGroup)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pin message"})
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, pin)
+		return
+	}
+
+	if h.sseHandler != nil {
+		h.sseHandler.Publish(SSEMessage{Type: EventMessagePinned, Message: &message, Data: pin})
+	}
+
+	c.JSON(http.StatusCreated, pin)
+}
+
+// UnpinMessage handles DELETE /api/v1/messages/:id/pin
+func (h *PinHandler) UnpinMessage(c *gin.Context) {
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	var pin models.Pin
+	if err := database.DB.Where("message_id = ?", message.ID).First(&pin).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message is not pinned"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := database.DB.Delete(&pin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpin message"})
+		return
+	}
+
+	if h.sseHandler != nil {
+		h.sseHandler.Publish(SSEMessage{Type: EventMessageUnpinned, Message: &message, Data: pin})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message unpinned successfully"})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPins(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	pinHandler := NewPinHandler(nil, config.PinsConfig{MaxPerGroup: 1}) // nil SSE handler for tests
+	router.GET("/api/v1/groups/:id/pins", pinHandler.GetPins)
+	router.POST("/api/v1/messages/:id/pin", pinHandler.PinMessage)
+	router.DELETE("/api/v1/messages/:id/pin", pinHandler.UnpinMessage)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	messages := []models.Message{
+		{GroupID: group.ID, User: "alice", Content: "Status: investigating"},
+		{GroupID: group.ID, User: "alice", Content: "Bridge: https://meet.example.com/inc"},
+	}
+	db.Create(&messages)
+
+	pin := func(method string, id uint) int {
+		req, _ := http.NewRequest(method, fmt.Sprintf("/api/v1/messages/%d/pin", id), nil)
+		req.Header.Set("X-User", "alice")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, pin("POST", messages[0].ID))
+	assert.Equal(t, http.StatusOK, pin("POST", messages[0].ID))
+
+	// The group is at its limit
+	assert.Equal(t, http.StatusConflict, pin("POST", messages[1].ID))
+
+	req, _ := http.NewRequest("GET", "/api/v1/groups/1/pins", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var pins []models.Pin
+	json.Unmarshal(w.Body.Bytes(), &pins)
+	assert.Len(t, pins, 1)
+	assert.Equal(t, "Status: investigating", pins[0].Message.Content)
+
+	assert.Equal(t, http.StatusOK, pin("DELETE", messages[0].ID))
+	assert.Equal(t, http.StatusNotFound, pin("DELETE", messages[0].ID))
+	assert.Equal(t, http.StatusCreated, pin("POST", messages[1].ID))
+
+	// A pin on a deleted message does not hold the group at its limit
+	db.Delete(&messages[1])
+	assert.Equal(t, http.StatusCreated, pin("POST", messages[0].ID))
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPollClosed    = errors.New("poll is closed")
+	errInvalidOption = errors.New("invalid option")
+)
+
+// PollHandler handles poll HTTP requests
+type PollHandler struct {
+	sseHandler     *SSEHandler
+	messageHandler *MessageHandler
+}
+
+// NewPollHandler creates a new poll handler. New polls are posted through the
+// message handler like any other message.
+func NewPollHandler(sseHandler *SSEHandler, messageHandler *MessageHandler) *PollHandler {
+	return &PollHandler{
+		sseHandler:     sseHandler,
+		messageHandler: messageHandler,
+	}
+}
+
+// CreatePoll handles POST /api/v1/polls
+func (h *PollHandler) CreatePoll(c *gin.Context) {
+	var req models.CreatePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Options))
+	for _, option := range req.Options {
+		key := strings.ToLower(strings.TrimSpace(option))
+		if key == "" || seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Poll options must be non-empty and distinct"})
+			return
+		}
+		seen[key] = true
+	}
+	if req.ClosesAt != nil && !req.ClosesAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "closes_at must be in the future"})
+		return
+	}
+
+	groupID, ok := resolveGroupID(c, req.GroupID)
+	if !ok {
+		return
+	}
+
+	message, err := h.messageHandler.publishMessageWith(models.Message{
+		GroupID: groupID,
+		User:    req.User,
+		Content: req.Question,
+	}, func(tx *gorm.DB, message models.Message) error {
+		poll := models.Poll{
+			MessageID:      message.ID,
+			MultipleChoice: req.MultipleChoice,
+			Anonymous:      req.Anonymous,
+			ClosesAt:       req.ClosesAt,
+		}
+		for i, option := range req.Options {
+			poll.Options = append(poll.Options, models.PollOption{Position: i, Text: strings.TrimSpace(option)})
+		}
+		return tx.Create(&poll).Error
+	})
+	if err != nil {
+		respondError(c, publishError(err, "Failed to create poll"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// Vote handles POST /api/v1/messages/:id/poll/votes
+// A vote replaces the user's previous vote on the poll.
+func (h *PollHandler) Vote(c *gin.Context) {
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	var req models.PollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll, ok := findPoll(c, message.ID)
+	if !ok {
+		return
+	}
+	if !poll.MultipleChoice && len(req.OptionIDs) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This poll allows a single choice"})
+		return
+	}
+
+	user = strings.ToLower(user)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize votes on the poll so a single-choice poll can't get two votes from one user
+		if err := lockPoll(tx, &poll); err != nil {
+			return err
+		}
+		if poll.Closed(time.Now()) {
+			return errPollClosed
+		}
+
+		var valid int64
+		if err := tx.Model(&models.PollOption{}).
+			Where("poll_id = ? AND id IN ?", poll.ID, req.OptionIDs).
+			Count(&valid).Error; err != nil {
+			return err
+		}
+		if valid != int64(len(uniqueIDs(req.OptionIDs))) {
+			return errInvalidOption
+		}
+
+		if err := tx.Where("poll_id = ? AND poll_votes.user = ?", poll.ID, user).Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		votes := make([]models.PollVote, 0, len(req.OptionIDs))
+		for _, optionID := range uniqueIDs(req.OptionIDs) {
+			votes = append(votes, models.PollVote{PollID: poll.ID, OptionID: optionID, User: user})
+		}
+		return tx.Create(&votes).Error
+	})
+	if !h.respondPollError(c, err, "Failed to record vote") {
+		return
+	}
+
+	h.publishResults(c, message, http.StatusOK)
+}
+
+// RetractVote handles DELETE /api/v1/messages/:id/poll/votes
+func (h *PollHandler) RetractVote(c *gin.Context) {
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	poll, ok := findPoll(c, message.ID)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPoll(tx, &poll); err != nil {
+			return err
+		}
+		if poll.Closed(time.Now()) {
+			return errPollClosed
+		}
+		return tx.Where("poll_id = ? AND poll_votes.user = ?", poll.ID, strings.ToLower(user)).Delete(&models.PollVote{}).Error
+	})
+	if !h.respondPollError(c, err, "Failed to retract vote") {
+		return
+	}
+
+	h.publishResults(c, message, http.StatusOK)
+}
+
+// ClosePoll handles POST /api/v1/messages/:id/poll/close
+// Only the poll's author can close it early.
+func (h *PollHandler) ClosePoll(c *gin.Context) {
+	message, ok := findMessage(c)
+	if !ok {
+		return
+	}
+
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+	if !strings.EqualFold(user, message.User) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the poll's author can close it"})
+		return
+	}
+
+	poll, ok := findPoll(c, message.ID)
+	if !ok {
+		return
+	}
+
+	// Only the close that sets closed_at announces it, so concurrent closes and the
+	// poll closer job cannot both do so
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Poll{}).
+			Where("id = ? AND closed_at IS NULL AND (closes_at IS NULL OR closes_at > ?)", poll.ID, now).
+			Update("closed_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPollClosed
+		}
+		return webhooks.Enqueue(tx, webhooks.EventMessageUpdated, message)
+	})
+	if err == errPollClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Poll is already closed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close poll"})
+		return
+	}
+
+	h.publishResults(c, message, http.StatusOK)
+}
+
+// publishResults reloads the poll message with its tally, broadcasts it and writes it
+// as the response
+func (h *PollHandler) publishResults(c *gin.Context, message models.Message, status int) {
+	c.JSON(status, h.broadcastResults(message))
+}
+
+// AnnounceClosed broadcasts the final results of a poll closed by the poll closer job
+func (h *PollHandler) AnnounceClosed(message models.Message) {
+	h.broadcastResults(message)
+}
+
+// broadcastResults reloads the poll message with its tally and sends it to clients
+// as a poll_updated event
+func (h *PollHandler) broadcastResults(message models.Message) models.Message {
+	database.DB.Preload("Group").First(&message, message.ID)
+	enrichMessages(&message)
+
+	if h.sseHandler != nil {
+		h.sseHandler.NotifyEvent(EventPollUpdated, message)
+	}
+
+	return message
+}
+
+// respondPollError writes the response for an error from a vote transaction and
+// returns true when there was no error
+func (h *PollHandler) respondPollError(c *gin.Context, err error, failure string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errPollClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Poll is closed"})
+	case errors.Is(err, errInvalidOption):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option for this poll"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+	return false
+}
+
+// findPoll loads the poll of a message, writing an error response and returning
+// false if the message has no poll
+func findPoll(c *gin.Context, messageID uint) (models.Poll, bool) {
+	var poll models.Poll
+	if err := database.DB.Where("message_id = ?", messageID).First(&poll).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message has no poll"})
+			return poll, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return poll, false
+	}
+	return poll, true
+}
+
+// lockPoll reloads the poll inside a transaction, locking its row on databases that support it
+func lockPoll(tx *gorm.DB, poll *models.Poll) error {
+	if tx.Dialector.Name() == "postgres" {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return tx.First(poll, poll.ID).Error
+}
+
+// attachPolls fills in the poll results of the given messages
+func attachPolls(messages ...*models.Message) error {
+	ids := make([]uint, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+
+	var polls []models.Poll
+	if err := database.DB.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("message_id IN ?", ids).Find(&polls).Error; err != nil {
+		return err
+	}
+	if len(polls) == 0 {
+		return nil
+	}
+
+	pollIDs := make([]uint, len(polls))
+	for i, poll := range polls {
+		pollIDs[i] = poll.ID
+	}
+
+	var votes []models.PollVote
+	if err := database.DB.Where("poll_id IN ?", pollIDs).Order("created_at, id").Find(&votes).Error; err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint]*models.PollResults, len(polls))
+	now := time.Now()
+	for _, poll := range polls {
+		byMessage[poll.MessageID] = tallyPoll(poll, votes, now)
+	}
+	for _, message := range messages {
+		message.Poll = byMessage[message.ID]
+	}
+
+	return nil
+}
+
+// tallyPoll counts the votes for each option of a poll
+func tallyPoll(poll models.Poll, votes []models.PollVote, now time.Time) *models.PollResults {
+	results := &models.PollResults{
+		ID:             poll.ID,
+		MultipleChoice: poll.MultipleChoice,
+		Anonymous:      poll.Anonymous,
+		ClosesAt:       poll.ClosesAt,
+		Closed:         poll.Closed(now),
+		Options:        make([]models.PollOptionResults, len(poll.Options)),
+	}
+
+	index := make(map[uint]int, len(poll.Options))
+	for i, option := range poll.Options {
+		index[option.ID] = i
+		results.Options[i] = models.PollOptionResults{ID: option.ID, Text: option.Text}
+	}
+
+	voters := make(map[string]bool)
+	for _, vote := range votes {
+		i, ok := index[vote.OptionID]
+		if vote.PollID != poll.ID || !ok {
+			continue
+		}
+		results.Options[i].Votes++
+		if !poll.Anonymous {
+			results.Options[i].Voters = append(results.Options[i].Voters, vote.User)
+		}
+		voters[vote.User] = true
+	}
+	results.TotalVoters = len(voters)
+
+	return results
+}
+
+// uniqueIDs returns ids without duplicates, keeping their order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
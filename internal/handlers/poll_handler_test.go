@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestTallyPoll(t *testing.T) {
+	poll := models.Poll{
+		ID:        1,
+		Anonymous: true,
+		Options:   []models.PollOption{{ID: 10, Text: "Rollback"}, {ID: 11, Text: "Fix forward"}},
+	}
+	votes := []models.PollVote{
+		{PollID: 1, OptionID: 10, User: "alice"},
+		{PollID: 1, OptionID: 10, User: "bob"},
+		{PollID: 2, OptionID: 20, User: "carol"},
+	}
+
+	results := tallyPoll(poll, votes, time.Now())
+	assert.Equal(t, 2, results.TotalVoters)
+	assert.Equal(t, 2, results.Options[0].Votes)
+	assert.Nil(t, results.Options[0].Voters)
+	assert.Equal(t, 0, results.Options[1].Votes)
+	assert.False(t, results.Closed)
+
+	closesAt := time.Now().Add(-time.Minute)
+	poll.ClosesAt = &closesAt
+	assert.True(t, tallyPoll(poll, nil, time.Now()).Closed)
+}
+
+func TestPolls(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	pollHandler := NewPollHandler(nil, NewMessageHandler(nil, nil, nil)) // nil SSE handler for tests
+	router.POST("/api/v1/polls", pollHandler.CreatePoll)
+	router.POST("/api/v1/messages/:id/poll/votes", pollHandler.Vote)
+	router.POST("/api/v1/messages/:id/poll/close", pollHandler.ClosePoll)
+
+	jsonBody, _ := json.Marshal(models.CreatePollRequest{
+		User:     "alice",
+		Question: "Rollback or fix forward?",
+		Options:  []string{"Rollback", "Fix forward"},
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/polls", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	if !assert.NotNil(t, message.Poll) {
+		return
+	}
+	rollback, fixForward := message.Poll.Options[0].ID, message.Poll.Options[1].ID
+
+	vote := func(user string, optionIDs ...uint) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(models.PollVoteRequest{OptionIDs: optionIDs})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/messages/%d/poll/votes", message.ID), bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The voter is the caller
+	assert.Equal(t, http.StatusUnauthorized, vote("", rollback).Code)
+	assert.Equal(t, http.StatusOK, vote("bob", rollback).Code)
+	// Single choice polls take one option, and changing the vote replaces it
+	assert.Equal(t, http.StatusBadRequest, vote("bob", rollback, fixForward).Code)
+	w = vote("bob", fixForward)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	json.Unmarshal(w.Body.Bytes(), &message)
+	assert.Equal(t, 1, message.Poll.TotalVoters)
+	assert.Equal(t, 0, message.Poll.Options[0].Votes)
+	assert.Equal(t, []string{"bob"}, message.Poll.Options[1].Voters)
+
+	// Only the author can close the poll
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/messages/%d/poll/close", message.ID), nil)
+	req.Header.Set("X-User", "bob")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/messages/%d/poll/close", message.ID), nil)
+	req.Header.Set("X-User", "alice")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A poll is closed once
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/messages/%d/poll/close", message.ID), nil)
+	req.Header.Set("X-User", "alice")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, http.StatusConflict, vote("carol", rollback).Code)
+}
+
+func TestPollCloser(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	pollHandler := NewPollHandler(nil, NewMessageHandler(nil, nil, nil)) // nil SSE handler for tests
+	router.POST("/api/v1/polls", pollHandler.CreatePoll)
+
+	closesAt := time.Now().Add(time.Hour)
+	jsonBody, _ := json.Marshal(models.CreatePollRequest{
+		User:     "alice",
+		Question: "Cut the release today?",
+		Options:  []string{"Yes", "No"},
+		ClosesAt: &closesAt,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/polls", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	db.Create(&models.Webhook{GroupID: message.GroupID, URL: "https://example.com/hook", Secret: "secret", Events: []string{webhooks.EventMessageUpdated}, Active: true})
+
+	// Polls are only closed once closes_at passes, and only once
+	var closed []models.Message
+	closer := jobs.NewPollCloser(config.PollsConfig{IntervalSeconds: 1}, zap.NewNop())
+	closer.OnClose(func(message models.Message) { closed = append(closed, message) })
+
+	count, err := closer.CloseDue(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, _ = closer.CloseDue(context.Background(), closesAt.Add(time.Second))
+	assert.Equal(t, 1, count)
+	if assert.Len(t, closed, 1) {
+		assert.Equal(t, message.ID, closed[0].ID)
+	}
+	count, _ = closer.CloseDue(context.Background(), closesAt.Add(time.Second))
+	assert.Equal(t, 0, count)
+
+	var poll models.Poll
+	db.Where("message_id = ?", message.ID).First(&poll)
+	assert.NotNil(t, poll.ClosedAt)
+
+	var updates int64
+	db.Model(&models.WebhookDelivery{}).Where("event = ?", webhooks.EventMessageUpdated).Count(&updates)
+	assert.Equal(t, int64(1), updates)
+
+	// fields=poll loads the results
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/messages?group_id=%d&fields=id,poll", message.GroupID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"closed":true`)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"sort"
+	"sre-chat-api/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// presenceForget is how long the tracker remembers an offline user's last-seen time
+const presenceForget = 24 * time.Hour
+
+// presenceState is what the tracker knows about one user
+type presenceState struct {
+	user        string
+	connections int
+	away        bool
+	lastSeen    time.Time
+	status      string
+	// offline fires once the grace period after the last disconnect has passed
+	offline *time.Timer
+}
+
+// typingKey identifies a user typing in a group
+type typingKey struct {
+	user    string
+	groupID uint
+}
+
+// presenceTracker derives online, away and offline status from a user's open
+// streams, keeping them online for a grace period after the last one closes
+type presenceTracker struct {
+	mu        sync.Mutex
+	grace     time.Duration
+	typingTTL time.Duration
+	// forget is how long offline users are kept, for their last-seen time
+	forget   time.Duration
+	users    map[string]*presenceState
+	typing   map[typingKey]time.Time
+	onChange func(models.Presence)
+}
+
+// newPresenceTracker creates a tracker that calls onChange whenever a user's status changes
+func newPresenceTracker(grace, typingTTL time.Duration, onChange func(models.Presence)) *presenceTracker {
+	return &presenceTracker{
+		grace:     grace,
+		typingTTL: typingTTL,
+		forget:    presenceForget,
+		users:     make(map[string]*presenceState),
+		typing:    make(map[typingKey]time.Time),
+		onChange:  onChange,
+	}
+}
+
+// connect records a new stream for user
+func (t *presenceTracker) connect(user string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.state(user)
+	state.connections++
+	if state.offline != nil {
+		// Reconnected within the grace period
+		state.offline.Stop()
+		state.offline = nil
+	}
+	t.update(state)
+}
+
+// disconnect records that one of user's streams closed
+func (t *presenceTracker) disconnect(user string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.state(user)
+	if state.connections > 0 {
+		state.connections--
+	}
+	state.lastSeen = time.Now()
+	if state.connections > 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(t.grace, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// A reconnect may have replaced this timer after it fired but before it
+		// got the lock
+		if state.offline != timer {
+			return
+		}
+		state.offline = nil
+		state.away = false
+		t.update(state)
+		t.forgetOffline()
+	})
+	state.offline = timer
+}
+
+// forgetOffline drops users who have been offline for longer than forget. The
+// caller must hold mu.
+func (t *presenceTracker) forgetOffline() {
+	now := time.Now()
+	for key, state := range t.users {
+		if state.connections == 0 && state.offline == nil && now.Sub(state.lastSeen) > t.forget {
+			delete(t.users, key)
+		}
+	}
+}
+
+// setAway marks a connected user as away or back online. It returns false when
+// the user has no open stream.
+func (t *presenceTracker) setAway(user string, away bool) (models.Presence, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.users[strings.ToLower(user)]
+	if !ok || (state.connections == 0 && state.offline == nil) {
+		return models.Presence{User: user, Status: models.PresenceOffline}, false
+	}
+
+	state.away = away
+	t.update(state)
+	return state.presence(), true
+}
+
+// presence returns the status of each user, online users first
+func (t *presenceTracker) presence(users []string) []models.Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]models.Presence, 0, len(users))
+	for _, user := range users {
+		if state, ok := t.users[strings.ToLower(user)]; ok {
+			list = append(list, state.presence())
+		} else {
+			list = append(list, models.Presence{User: user, Status: models.PresenceOffline})
+		}
+	}
+
+	rank := map[string]int{models.PresenceOnline: 0, models.PresenceAway: 1, models.PresenceOffline: 2}
+	sort.SliceStable(list, func(i, j int) bool {
+		if rank[list[i].Status] != rank[list[j].Status] {
+			return rank[list[i].Status] < rank[list[j].Status]
+		}
+		return strings.ToLower(list[i].User) < strings.ToLower(list[j].User)
+	})
+	return list
+}
+
+// allowTyping reports whether a typing notification from user in the group should be
+// fanned out, dropping repeats while the previous indicator is still showing
+func (t *presenceTracker) allowTyping(user string, groupID uint) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for key, expires := range t.typing {
+		if now.After(expires) {
+			delete(t.typing, key)
+		}
+	}
+
+	key := typingKey{user: strings.ToLower(user), groupID: groupID}
+	// Resend once half the indicator's lifetime has passed so it does not flicker
+	if expires, ok := t.typing[key]; ok && expires.Sub(now) > t.typingTTL/2 {
+		return expires, false
+	}
+
+	expires := now.Add(t.typingTTL)
+	t.typing[key] = expires
+	return expires, true
+}
+
+// state returns the tracked state of user, creating it if needed. The caller must hold mu.
+func (t *presenceTracker) state(user string) *presenceState {
+	key := strings.ToLower(user)
+	state, ok := t.users[key]
+	if !ok {
+		state = &presenceState{user: user, status: models.PresenceOffline}
+		t.users[key] = state
+	}
+	return state
+}
+
+// update recomputes the user's status and reports it if it changed. The caller must hold mu.
+func (t *presenceTracker) update(state *presenceState) {
+	status := models.PresenceOffline
+	if state.connections > 0 || state.offline != nil {
+		status = models.PresenceOnline
+		if state.away {
+			status = models.PresenceAway
+		}
+	}
+
+	if status == state.status {
+		return
+	}
+	state.status = status
+	if t.onChange != nil {
+		t.onChange(state.presence())
+	}
+}
+
+// presence returns the user's status as exposed by the API
+func (s *presenceState) presence() models.Presence {
+	p := models.Presence{User: s.user, Status: s.status}
+	if s.status != models.PresenceOnline && !s.lastSeen.IsZero() {
+		lastSeen := s.lastSeen
+		p.LastSeen = &lastSeen
+	}
+	return p
+}
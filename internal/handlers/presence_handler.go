@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PresenceHandler handles presence and typing indicator HTTP requests
+type PresenceHandler struct {
+	sseHandler *SSEHandler
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(sseHandler *SSEHandler) *PresenceHandler {
+	return &PresenceHandler{
+		sseHandler: sseHandler,
+	}
+}
+
+// GetGroupPresence handles GET /api/v1/groups/:id/presence
+// Members are the users who have posted in the group or are streaming it.
+func (h *PresenceHandler) GetGroupPresence(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var posters []string
+	if err := database.DB.Raw(`SELECT DISTINCT "user" FROM messages WHERE group_id = ? AND deleted_at IS NULL`, group.ID).
+		Scan(&posters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group members"})
+		return
+	}
+
+	seen := make(map[string]bool)
+	var members []string
+	for _, user := range append(posters, h.sseHandler.ConnectedUsers(group.ID)...) {
+		if key := strings.ToLower(user); !seen[key] {
+			seen[key] = true
+			members = append(members, user)
+		}
+	}
+
+	c.JSON(http.StatusOK, h.sseHandler.Presence(members))
+}
+
+// SetPresence handles PUT /api/v1/me/presence
+// Connected users can mark themselves away or back online; offline follows from
+// closing all streams.
+func (h *PresenceHandler) SetPresence(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	var req models.PresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	presence, ok := h.sseHandler.SetAway(user, req.Status == models.PresenceAway)
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "User has no open stream"})
+		return
+	}
+
+	c.JSON(http.StatusOK, presence)
+}
+
+// Typing handles POST /api/v1/groups/:id/typing
+func (h *PresenceHandler) Typing(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User identity required (X-User header or user query parameter)"})
+		return
+	}
+
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.sseHandler.NotifyTyping(user, group.ID))
+}
+
+// findGroup loads the group named by the id path parameter, writing an error
+// response and returning false if it is invalid or does not exist
+func findGroup(c *gin.Context) (models.Group, bool) {
+	var group models.Group
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return group, false
+	}
+
+	if err := database.DB.First(&group, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return group, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return group, false
+	}
+
+	return group, true
+}
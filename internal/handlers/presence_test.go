@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"sre-chat-api/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	var mu sync.Mutex
+	var changes []models.Presence
+	tracker := newPresenceTracker(50*time.Millisecond, time.Second, func(p models.Presence) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, p)
+	})
+
+	// Two streams for the same user count as one presence
+	tracker.connect("Alice")
+	tracker.connect("alice")
+	tracker.disconnect("alice")
+	assert.Equal(t, models.PresenceOnline, tracker.presence([]string{"alice"})[0].Status)
+
+	_, ok := tracker.setAway("alice", true)
+	assert.True(t, ok)
+	_, ok = tracker.setAway("bob", true)
+	assert.False(t, ok)
+
+	// Reconnecting within the grace period does not go offline
+	tracker.disconnect("alice")
+	tracker.connect("alice")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, models.PresenceAway, tracker.presence([]string{"alice"})[0].Status)
+
+	tracker.disconnect("alice")
+	time.Sleep(100 * time.Millisecond)
+
+	list := tracker.presence([]string{"bob", "alice"})
+	assert.Equal(t, "Alice", list[0].User)
+	assert.Equal(t, models.PresenceOffline, list[0].Status)
+	assert.NotNil(t, list[0].LastSeen)
+
+	mu.Lock()
+	defer mu.Unlock()
+	statuses := make([]string, len(changes))
+	for i, change := range changes {
+		statuses[i] = change.Status
+	}
+	assert.Equal(t, []string{models.PresenceOnline, models.PresenceAway, models.PresenceOffline}, statuses)
+}
+
+func TestPresenceReconnectAfterTimerFired(t *testing.T) {
+	tracker := newPresenceTracker(50*time.Millisecond, time.Second, nil)
+	tracker.connect("alice")
+	tracker.disconnect("alice")
+
+	// The grace timer fires while the tracker is busy, then alice reconnects and
+	// disconnects again; only the new grace period may take alice offline
+	tracker.mu.Lock()
+	time.Sleep(80 * time.Millisecond)
+	tracker.mu.Unlock()
+	tracker.connect("alice")
+	tracker.disconnect("alice")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, models.PresenceOnline, tracker.presence([]string{"alice"})[0].Status)
+
+	time.Sleep(80 * time.Millisecond)
+	assert.Equal(t, models.PresenceOffline, tracker.presence([]string{"alice"})[0].Status)
+}
+
+func TestPresenceForgetsOfflineUsers(t *testing.T) {
+	tracker := newPresenceTracker(10*time.Millisecond, time.Second, nil)
+	tracker.forget = time.Millisecond
+
+	tracker.connect("alice")
+	tracker.disconnect("alice")
+	time.Sleep(50 * time.Millisecond)
+
+	tracker.mu.Lock()
+	assert.Empty(t, tracker.users)
+	tracker.mu.Unlock()
+	list := tracker.presence([]string{"alice"})
+	assert.Equal(t, models.PresenceOffline, list[0].Status)
+	assert.Nil(t, list[0].LastSeen)
+}
+
+func TestAllowTyping(t *testing.T) {
+	tracker := newPresenceTracker(time.Second, time.Second, nil)
+
+	_, send := tracker.allowTyping("alice", 1)
+	assert.True(t, send)
+	_, send = tracker.allowTyping("Alice", 1)
+	assert.False(t, send)
+	_, send = tracker.allowTyping("alice", 2)
+	assert.True(t, send)
+}
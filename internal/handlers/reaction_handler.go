@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionHandler handles emoji reaction HTTP requests
+type ReactionHandler struct {
+	sseHandler *SSEHandler
+}
+
+// NewReactionHandler creates a new reaction handler
+func NewReactionHandler(sseHandler *SSEHandler) *ReactionHandler {
+	return &ReactionHandler{
+		sseHandler: sseHandler,
+	}
+}
+
+// AddReaction handles POST /api/v1/messages/:id/reactions
+func (h *ReactionHandler) AddReaction(c *gin.Context) {
+	message, req, ok := bindReaction(c)
+	if !ok {
+		return
+	}
+
+	reaction, created, err := h.addReaction(message, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Reacting twice with the same emoji is a no-op
+	if !created {
+		c.JSON(http.StatusOK, reaction)
+		return
+	}
+	c.JSON(http.StatusCreated, reaction)
+}
+
+// addReaction adds a reaction to a message, reporting whether it is new
+func (h *ReactionHandler) addReaction(message models.Message, req models.ReactionRequest) (models.Reaction, bool, error) {
+	reaction := models.Reaction{
+		MessageID: message.ID,
+		User:      req.User,
+		Emoji:     req.Emoji,
+	}
+
+	// Concurrent identical reactions meet at the unique index; the one that loses
+	// returns the reaction that won
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		return reaction, false, newRequestError(http.StatusInternalServerError, "Failed to add reaction")
+	}
+	if result.RowsAffected == 0 {
+		existing := models.Reaction{}
+		if err := database.DB.Where(models.Reaction{MessageID: message.ID, User: req.User, Emoji: req.Emoji}).First(&existing).Error; err != nil {
+			return reaction, false, newRequestError(http.StatusInternalServerError, "Failed to add reaction")
+		}
+		return existing, false, nil
+	}
+
+	if h.sseHandler != nil {
+		h.sseHandler.Publish(SSEMessage{Type: EventReactionAdded, Message: &message, Data: reaction})
+	}
+	return reaction, true, nil
+}
+
+// RemoveReaction handles DELETE /api/v1/messages/:id/reactions
+func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
+	message, req, ok := bindReaction(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.removeReaction(message, req); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction removed successfully"})
+}
+
+// removeReaction removes a reaction from a message
+func (h *ReactionHandler) removeReaction(message models.Message, req models.ReactionRequest) (models.Reaction, error) {
+	var reaction models.Reaction
+	if err := database.DB.Where(models.Reaction{MessageID: message.ID, User: req.User, Emoji: req.Emoji}).First(&reaction).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return reaction, newRequestError(http.StatusNotFound, "Reaction not found")
+		}
+		return reaction, newRequestError(http.StatusInternalServerError, "Database error")
+	}
+
+	if err := database.DB.Delete(&reaction).Error; err != nil {
+		return reaction, newRequestError(http.StatusInternalServerError, "Failed to remove reaction")
+	}
+
+	if h.sseHandler != nil {
+		h.sseHandler.Publish(SSEMessage{Type: EventReactionRemoved, Message: &message, Data: reaction})
+	}
+	return reaction, nil
+}
+
+// bindReaction loads the message and parses the reaction body, writing an error
+// response and returning false if either is invalid or the message does not exist
+func bindReaction(c *gin.Context) (models.Message, models.ReactionRequest, bool) {
+	var req models.ReactionRequest
+
+	message, ok := findMessage(c)
+	if !ok {
+		return message, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return message, req, false
+	}
+
+	return message, req, true
+}
+
+// attachReactions fills in the aggregated reaction counts of the given messages
+func attachReactions(messages ...*models.Message) error {
+	ids := make([]uint, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+
+	var reactions []models.Reaction
+	if err := database.DB.Where("message_id IN ?", ids).Order("created_at, id").Find(&reactions).Error; err != nil {
+		return err
+	}
+
+	summaries := summarizeReactions(reactions)
+	for _, message := range messages {
+		message.Reactions = summaries[message.ID]
+	}
+
+	return nil
+}
+
+// summarizeReactions groups reactions by message and emoji, keeping emojis in the
+// order they were first used
+func summarizeReactions(reactions []models.Reaction) map[uint][]models.ReactionSummary {
+	summaries := make(map[uint][]models.ReactionSummary)
+	for _, reaction := range reactions {
+		list := summaries[reaction.MessageID]
+
+		found := false
+		for i := range list {
+			if list[i].Emoji == reaction.Emoji {
+				list[i].Count++
+				list[i].Users = append(list[i].Users, reaction.User)
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, models.ReactionSummary{
+				Emoji: reaction.Emoji,
+				Count: 1,
+				Users: []string{reaction.User},
+			})
+		}
+
+		summaries[reaction.MessageID] = list
+	}
+	return summaries
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/models"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeReactions(t *testing.T) {
+	summaries := summarizeReactions([]models.Reaction{
+		{MessageID: 1, User: "alice", Emoji: "👀"},
+		{MessageID: 1, User: "bob", Emoji: "✅"},
+		{MessageID: 1, User: "carol", Emoji: "👀"},
+		{MessageID: 2, User: "alice", Emoji: "✅"},
+	})
+
+	assert.Equal(t, []models.ReactionSummary{
+		{Emoji: "👀", Count: 2, Users: []string{"alice", "carol"}},
+		{Emoji: "✅", Count: 1, Users: []string{"bob"}},
+	}, summaries[1])
+	assert.Len(t, summaries[2], 1)
+}
+
+func TestReactions(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	reactionHandler := NewReactionHandler(nil) // nil SSE handler for tests
+	router.POST("/api/v1/messages/:id/reactions", reactionHandler.AddReaction)
+	router.DELETE("/api/v1/messages/:id/reactions", reactionHandler.RemoveReaction)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	message := models.Message{GroupID: group.ID, User: "testuser", Content: "Disk full on db-1"}
+	db.Create(&message)
+
+	jsonBody, _ := json.Marshal(models.ReactionRequest{User: "alice", Emoji: "👀"})
+	req, _ := http.NewRequest("POST", "/api/v1/messages/1/reactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Reacting again is idempotent
+	req, _ = http.NewRequest("POST", "/api/v1/messages/1/reactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Concurrent identical reactions add one reaction and never fail
+	var wg sync.WaitGroup
+	codes := make([]int, 8)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(models.ReactionRequest{User: "bob", Emoji: "✅"})
+			req, _ := http.NewRequest("POST", "/api/v1/messages/1/reactions", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+	created := 0
+	for _, code := range codes {
+		assert.Contains(t, []int{http.StatusCreated, http.StatusOK}, code)
+		if code == http.StatusCreated {
+			created++
+		}
+	}
+	assert.Equal(t, 1, created)
+	db.Where("\"user\" = ?", "bob").Delete(&models.Reaction{})
+
+	// Counts are embedded in the message
+	req, _ = http.NewRequest("GET", "/api/v1/messages/1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response models.Message
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []models.ReactionSummary{{Emoji: "👀", Count: 1, Users: []string{"alice"}}}, response.Reactions)
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/messages/1/reactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/messages/1/reactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReminderRunLimit = 50
+	maxReminderRunLimit     = 500
+)
+
+// ReminderHandler handles recurring reminder HTTP requests
+type ReminderHandler struct {
+	runner *jobs.ReminderRunner
+}
+
+// NewReminderHandler creates a new reminder handler
+func NewReminderHandler(runner *jobs.ReminderRunner) *ReminderHandler {
+	return &ReminderHandler{
+		runner: runner,
+	}
+}
+
+// GetReminders handles GET /api/v1/groups/:id/reminders
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var reminders []models.Reminder
+	if err := database.DB.Where("group_id = ?", group.ID).Order("id").Find(&reminders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reminders)
+}
+
+// CreateReminder handles POST /api/v1/groups/:id/reminders
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminder, err := h.createReminder(group.ID, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reminder)
+}
+
+// GetReminder handles GET /api/v1/reminders/:id
+func (h *ReminderHandler) GetReminder(c *gin.Context) {
+	reminder, ok := findReminder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, reminder)
+}
+
+// UpdateReminder handles PUT /api/v1/reminders/:id
+func (h *ReminderHandler) UpdateReminder(c *gin.Context) {
+	reminder, ok := findReminder(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyReminderRequest(c, &reminder, req) {
+		return
+	}
+
+	if err := database.DB.Save(&reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder"})
+		return
+	}
+	h.wake()
+
+	c.JSON(http.StatusOK, reminder)
+}
+
+// DeleteReminder handles DELETE /api/v1/reminders/:id
+func (h *ReminderHandler) DeleteReminder(c *gin.Context) {
+	reminder, ok := findReminder(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&reminder).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
+}
+
+// GetReminderRuns handles GET /api/v1/reminders/:id/runs
+func (h *ReminderHandler) GetReminderRuns(c *gin.Context) {
+	reminder, ok := findReminder(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultReminderRunLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxReminderRunLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var runs []models.ReminderRun
+	if err := database.DB.Where("reminder_id = ?", reminder.ID).
+		Order("scheduled_for DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// createReminder validates the request and stores it as a new reminder in the
+// group, for the REST API and /remind
+func (h *ReminderHandler) createReminder(groupID uint, req models.ReminderRequest) (models.Reminder, error) {
+	reminder := models.Reminder{GroupID: groupID}
+	if err := prepareReminder(&reminder, req); err != nil {
+		return reminder, err
+	}
+
+	if err := database.DB.Create(&reminder).Error; err != nil {
+		return reminder, newRequestError(http.StatusInternalServerError, "Failed to create reminder")
+	}
+	h.wake()
+
+	return reminder, nil
+}
+
+// wake tells the local runner that reminders changed
+func (h *ReminderHandler) wake() {
+	if h.runner != nil {
+		h.runner.Wake()
+	}
+}
+
+// applyReminderRequest validates the request and copies it onto the reminder,
+// computing its next run. It writes an error response and returns false when the
+// schedule or time zone is invalid or the user is a bot's name.
+func applyReminderRequest(c *gin.Context, reminder *models.Reminder, req models.ReminderRequest) bool {
+	if err := prepareReminder(reminder, req); err != nil {
+		respondError(c, err)
+		return false
+	}
+	return true
+}
+
+// prepareReminder is applyReminderRequest for logic shared with /remind
+func prepareReminder(reminder *models.Reminder, req models.ReminderRequest) error {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	next, err := jobs.NextReminderRun(req.Schedule, timezone, time.Now())
+	if err != nil {
+		return newRequestError(http.StatusBadRequest, err.Error())
+	}
+	if err := checkPoster(req.User); err != nil {
+		return err
+	}
+
+	reminder.Name = req.Name
+	reminder.Schedule = req.Schedule
+	reminder.Timezone = timezone
+	reminder.User = req.User
+	reminder.Content = req.Content
+	reminder.Enabled = req.Enabled == nil || *req.Enabled
+	reminder.NextRunAt = &next
+	return nil
+}
+
+// findReminder loads the reminder named by the id path parameter, writing an error
+// response and returning false if it is invalid or does not exist
+func findReminder(c *gin.Context) (models.Reminder, bool) {
+	var reminder models.Reminder
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return reminder, false
+	}
+
+	if err := database.DB.First(&reminder, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+			return reminder, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return reminder, false
+	}
+
+	return reminder, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestReminders(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	reminderHandler := NewReminderHandler(nil)
+	router.POST("/api/v1/groups/:id/reminders", reminderHandler.CreateReminder)
+	router.PUT("/api/v1/reminders/:id", reminderHandler.UpdateReminder)
+	router.GET("/api/v1/reminders/:id/runs", reminderHandler.GetReminderRuns)
+
+	body := models.ReminderRequest{
+		Name:     "Checklist",
+		Schedule: "0 9 * * MON-FRI",
+		Timezone: "Europe/Berlin",
+		User:     "ops-bot",
+		Content:  "On-call checklist",
+	}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/api/v1/groups/1/reminders", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var reminder models.Reminder
+	json.Unmarshal(w.Body.Bytes(), &reminder)
+	assert.True(t, reminder.Enabled)
+	assert.NotNil(t, reminder.NextRunAt)
+
+	// Invalid schedules and time zones are rejected
+	for _, invalid := range []models.ReminderRequest{
+		{Name: "x", Schedule: "every day", User: "ops-bot", Content: "x"},
+		{Name: "x", Schedule: "@daily", Timezone: "Mars/Olympus", User: "ops-bot", Content: "x"},
+	} {
+		jsonBody, _ = json.Marshal(invalid)
+		req, _ = http.NewRequest("POST", "/api/v1/groups/1/reminders", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+
+	// Fire the reminder as if it were due
+	past := time.Now().Add(-time.Minute)
+	db.Model(&models.Reminder{}).Where("id = ?", reminder.ID).Update("next_run_at", past)
+
+	runner := jobs.NewReminderRunner(config.RemindersConfig{Enabled: true, LeaseSeconds: 30}, zap.NewNop())
+	var delivered []models.Message
+	runner.OnDeliver(func(message models.Message) {
+		delivered = append(delivered, message)
+	})
+
+	fired, err := runner.RunDue(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Len(t, delivered, 1)
+	assert.Equal(t, "On-call checklist", delivered[0].Content)
+
+	// The reminder moved on to its next run
+	fired, err = runner.RunDue(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fired)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/reminders/%d/runs", reminder.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var runs []models.ReminderRun
+	json.Unmarshal(w.Body.Bytes(), &runs)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, delivered[0].ID, *runs[0].MessageID)
+
+	// Disabling keeps the reminder from firing
+	disabled := false
+	body.Enabled = &disabled
+	jsonBody, _ = json.Marshal(body)
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/reminders/%d", reminder.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &reminder)
+	assert.False(t, reminder.Enabled)
+}
+
+func TestLeaderElection(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	setupRouter(db)
+
+	first := jobs.NewLeaderElector("test", time.Minute)
+	second := jobs.NewLeaderElector("test", time.Minute)
+
+	leader, err := first.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = second.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, leader)
+
+	// Renewing keeps the lease
+	leader, err = first.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, leader)
+
+	assert.NoError(t, first.Release(context.Background()))
+	leader, err = second.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, leader)
+}
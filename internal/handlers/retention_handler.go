@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RetentionHandler handles retention policy HTTP requests
+type RetentionHandler struct {
+	enforcer *jobs.RetentionEnforcer
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(enforcer *jobs.RetentionEnforcer) *RetentionHandler {
+	return &RetentionHandler{
+		enforcer: enforcer,
+	}
+}
+
+// GetPolicy handles GET /api/v1/admin/groups/:id/retention
+func (h *RetentionHandler) GetPolicy(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var policy models.RetentionPolicy
+	if err := database.DB.Where("group_id = ?", uint(groupID)).First(&policy).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Retention policy not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// SetPolicy handles PUT /api/v1/admin/groups/:id/retention
+func (h *RetentionHandler) SetPolicy(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req models.RetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify group exists
+	var group models.Group
+	if err := database.DB.First(&group, uint(groupID)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var policy models.RetentionPolicy
+	if err := database.DB.Where(models.RetentionPolicy{GroupID: group.ID}).FirstOrInit(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	policy.MaxAgeDays = req.MaxAgeDays
+	policy.MaxMessages = req.MaxMessages
+	if err := database.DB.Save(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save retention policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy handles DELETE /api/v1/admin/groups/:id/retention
+func (h *RetentionHandler) DeletePolicy(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	result := database.DB.Where("group_id = ?", uint(groupID)).Delete(&models.RetentionPolicy{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete retention policy"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Retention policy not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Retention policy deleted successfully"})
+}
+
+// RunRetention handles POST /api/v1/admin/retention/run
+// Pass dry_run=true to report what would be removed without deleting anything.
+func (h *RetentionHandler) RunRetention(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	results, err := h.enforcer.Enforce(c.Request.Context(), dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enforce retention policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": dryRun,
+		"results": results,
+	})
+}
+
+// GetMetrics handles GET /api/v1/admin/retention/metrics
+func (h *RetentionHandler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.enforcer.Metrics())
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func setupRetentionRouter(db *gorm.DB) *gin.Engine {
+	router := setupRouter(db)
+	enforcer := jobs.NewRetentionEnforcer(config.RetentionConfig{BatchSize: 2}, zap.NewNop())
+	retentionHandler := NewRetentionHandler(enforcer)
+
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.PUT("/groups/:id/retention", retentionHandler.SetPolicy)
+		admin.POST("/retention/run", retentionHandler.RunRetention)
+		admin.GET("/retention/metrics", retentionHandler.GetMetrics)
+	}
+
+	return router
+}
+
+func TestRetentionMaxMessages(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRetentionRouter(db)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	for i := 0; i < 5; i++ {
+		db.Create(&models.Message{GroupID: group.ID, User: "testuser", Content: "Message"})
+	}
+
+	// Keep only the newest two messages
+	jsonBody, _ := json.Marshal(models.RetentionPolicyRequest{MaxMessages: 2})
+	req, _ := http.NewRequest("PUT", "/api/v1/admin/groups/1/retention", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Dry run reports without deleting
+	req, _ = http.NewRequest("POST", "/api/v1/admin/retention/run?dry_run=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dryRun struct {
+		Results []jobs.RetentionResult `json:"results"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &dryRun)
+	assert.Len(t, dryRun.Results, 1)
+	assert.Equal(t, int64(3), dryRun.Results[0].Matched)
+	assert.Equal(t, int64(0), dryRun.Results[0].Purged)
+
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(5), count)
+
+	// Real run deletes in batches
+	req, _ = http.NewRequest("POST", "/api/v1/admin/retention/run", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	req, _ = http.NewRequest("GET", "/api/v1/admin/retention/metrics", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var metrics jobs.RetentionMetrics
+	json.Unmarshal(w.Body.Bytes(), &metrics)
+	assert.Equal(t, int64(3), metrics.RowsPurged)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ScheduledMessageHandler handles scheduled message HTTP requests
+type ScheduledMessageHandler struct{}
+
+// NewScheduledMessageHandler creates a new scheduled message handler
+func NewScheduledMessageHandler() *ScheduledMessageHandler {
+	return &ScheduledMessageHandler{}
+}
+
+// GetScheduledMessages handles GET /api/v1/scheduled-messages
+// Lists pending messages by default; pass status to see sent, cancelled or failed ones.
+func (h *ScheduledMessageHandler) GetScheduledMessages(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ScheduledPending)
+	switch status {
+	case models.ScheduledPending, models.ScheduledSent, models.ScheduledCancelled, models.ScheduledFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	query := database.DB.Where("status = ?", status)
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		query = query.Where("group_id = ?", uint(id))
+	}
+	if user := c.Query("user"); user != "" {
+		query = query.Where("scheduled_messages.user = ?", user)
+	}
+
+	var scheduled []models.ScheduledMessage
+	if err := query.Order("send_at, id").Find(&scheduled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
+
+// CancelScheduledMessage handles DELETE /api/v1/scheduled-messages/:id
+func (h *ScheduledMessageHandler) CancelScheduledMessage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	var scheduled models.ScheduledMessage
+	if err := database.DB.First(&scheduled, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled message not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Only cancel if the scheduler has not claimed it in the meantime
+	result := database.DB.Model(&scheduled).
+		Where("status = ?", models.ScheduledPending).
+		Update("status", models.ScheduledCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel scheduled message"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		database.DB.First(&scheduled, scheduled.ID)
+		c.JSON(http.StatusConflict, gin.H{"error": "Scheduled message is already " + scheduled.Status})
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
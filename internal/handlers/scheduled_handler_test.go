@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestScheduledMessages(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	scheduledHandler := NewScheduledMessageHandler()
+	router.GET("/api/v1/scheduled-messages", scheduledHandler.GetScheduledMessages)
+	router.DELETE("/api/v1/scheduled-messages/:id", scheduledHandler.CancelScheduledMessage)
+
+	sendAt := time.Now().Add(time.Hour)
+	jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: "alice", Content: "Handoff at 18:00", SendAt: &sendAt})
+	req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var scheduled models.ScheduledMessage
+	json.Unmarshal(w.Body.Bytes(), &scheduled)
+	assert.Equal(t, models.ScheduledPending, scheduled.Status)
+
+	req, _ = http.NewRequest("GET", "/api/v1/scheduled-messages", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var pending []models.ScheduledMessage
+	json.Unmarshal(w.Body.Bytes(), &pending)
+	assert.Len(t, pending, 1)
+
+	url := fmt.Sprintf("/api/v1/scheduled-messages/%d", scheduled.ID)
+	req, _ = http.NewRequest("DELETE", url, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("DELETE", url, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Nothing was posted
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSchedulerDeliverDue(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	setupRouter(db)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	db.Create(&[]models.ScheduledMessage{
+		{GroupID: group.ID, User: "alice", Content: "due", SendAt: time.Now().Add(-time.Minute), Status: models.ScheduledPending},
+		{GroupID: group.ID, User: "alice", Content: "later", SendAt: time.Now().Add(time.Hour), Status: models.ScheduledPending},
+	})
+
+	scheduler := jobs.NewScheduler(config.SchedulerConfig{IntervalSeconds: 1}, zap.NewNop())
+	var delivered []models.Message
+	scheduler.OnDeliver(func(message models.Message) {
+		delivered = append(delivered, message)
+	})
+
+	sent, err := scheduler.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, delivered, 1)
+	assert.Equal(t, "due", delivered[0].Content)
+
+	var scheduled models.ScheduledMessage
+	db.Where("content = ?", "due").First(&scheduled)
+	assert.Equal(t, models.ScheduledSent, scheduled.Status)
+	assert.Equal(t, delivered[0].ID, *scheduled.MessageID)
+
+	// Already sent messages are not posted twice
+	sent, err = scheduler.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
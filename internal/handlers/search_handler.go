@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"sort"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// snippetRadius is how many characters of context the fallback snippet keeps around a match
+	snippetRadius = 40
+
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+
+	// ts_headline does not escape content, so it marks matches with private-use
+	// characters that are swapped for the highlight markers after escaping
+	headlineStart = "\ue000"
+	headlineStop  = "\ue001"
+)
+
+// headlineOptions configures ts_headline to match the fallback snippet format
+var headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", MaxFragments=2, MaxWords=20, MinWords=5`
+
+// SearchHandler handles message search HTTP requests
+type SearchHandler struct{}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{}
+}
+
+// searchRow is a ranked match before its message is loaded
+type searchRow struct {
+	ID      uint
+	Rank    float64
+	Snippet string
+}
+
+// Search handles GET /api/v1/search
+func (h *SearchHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	filters, err := parseMessageFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []searchRow
+	if database.DB.Dialector.Name() == "postgres" {
+		rows, err = searchFullText(q, filters, limit)
+	} else {
+		rows, err = searchLike(q, filters, limit)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search messages"})
+		return
+	}
+
+	results, err := loadSearchResults(rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// searchFullText ranks matches using the tsvector column and GIN index
+func searchFullText(q string, filters messageFilters, limit int) ([]searchRow, error) {
+	tsQuery := gorm.Expr("websearch_to_tsquery('english', ?)", q)
+
+	query := database.DB.Model(&models.Message{}).
+		Select("messages.id, ts_rank(messages.search_vector, ?) AS rank, ts_headline('english', messages.content, ?, ?) AS snippet",
+			tsQuery, tsQuery, headlineOptions).
+		Where("messages.search_vector @@ ?", tsQuery)
+
+	var rows []searchRow
+	err := filters.apply(query).
+		Order("rank DESC, messages.created_at DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	for i := range rows {
+		rows[i].Snippet = escapeHeadline(rows[i].Snippet)
+	}
+	return rows, err
+}
+
+// escapeHeadline HTML-escapes a ts_headline snippet and highlights its matches
+func escapeHeadline(snippet string) string {
+	return strings.NewReplacer(headlineStart, highlightStart, headlineStop, highlightStop).Replace(html.EscapeString(snippet))
+}
+
+// searchLike is the fallback for databases without full-text search. Every term must
+// appear in the content; results are ranked by how often the terms occur.
+func searchLike(q string, filters messageFilters, limit int) ([]searchRow, error) {
+	terms := strings.Fields(strings.ToLower(q))
+
+	query := database.DB.Model(&models.Message{})
+	for _, term := range terms {
+		query = query.Where("LOWER(messages.content) LIKE ? ESCAPE '\\'", "%"+escapeLike(term)+"%")
+	}
+
+	var messages []models.Message
+	err := filters.apply(query).
+		Order("messages.created_at DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]searchRow, 0, len(messages))
+	for _, message := range messages {
+		content := strings.ToLower(message.Content)
+		rank := 0
+		for _, term := range terms {
+			rank += strings.Count(content, term)
+		}
+		rows = append(rows, searchRow{
+			ID:      message.ID,
+			Rank:    float64(rank),
+			Snippet: highlightSnippet(message.Content, terms),
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Rank > rows[j].Rank
+	})
+
+	return rows, nil
+}
+
+// loadSearchResults loads the messages for ranked rows, preserving their order
+func loadSearchResults(rows []searchRow) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, 0, len(rows))
+	if len(rows) == 0 {
+		return results, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	var messages []models.Message
+	if err := database.DB.Preload("Group").Find(&messages, ids).Error; err != nil {
+		return nil, err
+	}
+	if err := enrichMessageSlice(messages); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+
+	for _, row := range rows {
+		message, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Message: message,
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	return results, nil
+}
+
+// highlightSnippet returns the HTML-escaped content around the first matching term
+// with every term occurrence wrapped in highlight markers
+func highlightSnippet(content string, terms []string) string {
+	lower := strings.ToLower(content)
+	if len(lower) != len(content) {
+		// Lowercasing changed byte offsets; fall back to case-sensitive highlighting
+		lower = content
+	}
+
+	first := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := first - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetRadius
+	if end > len(content) {
+		end = len(content)
+	}
+
+	// Don't cut a multi-byte character in half
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+
+	window := content[start:end]
+	windowLower := lower[start:end]
+	plain := 0
+	for i := 0; i < len(window); {
+		matched := ""
+		for _, term := range terms {
+			if term != "" && strings.HasPrefix(windowLower[i:], term) && len(term) > len(matched) {
+				matched = term
+			}
+		}
+		if matched == "" {
+			i++
+			continue
+		}
+		b.WriteString(html.EscapeString(window[plain:i]))
+		b.WriteString(highlightStart)
+		b.WriteString(html.EscapeString(window[i : i+len(matched)]))
+		b.WriteString(highlightStop)
+		i += len(matched)
+		plain = i
+	}
+	b.WriteString(html.EscapeString(window[plain:]))
+
+	if end < len(content) {
+		b.WriteString("...")
+	}
+
+	return b.String()
+}
+
+// escapeLike escapes LIKE wildcards so search terms match literally
+func escapeLike(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(term)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := highlightSnippet("See the Runbook at https://wiki/runbook", []string{"runbook"})
+	assert.Equal(t, "See the <mark>Runbook</mark> at https://wiki/<mark>runbook</mark>", snippet)
+
+	assert.Equal(t, "", highlightSnippet("nothing here", []string{"runbook"}))
+
+	// Content is escaped so that snippets can be rendered as HTML
+	snippet = highlightSnippet(`<img src=x onerror="alert(1)"> runbook & <b>`, []string{"runbook"})
+	assert.Equal(t, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>runbook</mark> &amp; &lt;b&gt;", snippet)
+}
+
+func TestEscapeHeadline(t *testing.T) {
+	snippet := escapeHeadline("<script>x</script> the " + headlineStart + "runbook" + headlineStop)
+	assert.Equal(t, "&lt;script&gt;x&lt;/script&gt; the <mark>runbook</mark>", snippet)
+}
+
+func TestHighlightSnippetTruncates(t *testing.T) {
+	content := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa needle bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	snippet := highlightSnippet(content, []string{"needle"})
+	assert.Contains(t, snippet, "<mark>needle</mark>")
+	assert.True(t, len(snippet) < len(content))
+	assert.Equal(t, "...", snippet[:3])
+}
+
+func TestSearch(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	router.GET("/api/v1/search", NewSearchHandler().Search)
+	database.CreateSearchIndex()
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+	db.Create(&models.Message{GroupID: group.ID, User: "alice", Content: "The runbook for the database failover"})
+	db.Create(&models.Message{GroupID: group.ID, User: "bob", Content: "Lunch anyone?"})
+
+	req, _ := http.NewRequest("GET", "/api/v1/search?q=runbook", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var results []models.SearchResult
+	json.Unmarshal(w.Body.Bytes(), &results)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "alice", results[0].Message.User)
+	assert.Contains(t, results[0].Snippet, "<mark>runbook</mark>")
+
+	// Missing query is rejected
+	req, _ = http.NewRequest("GET", "/api/v1/search", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
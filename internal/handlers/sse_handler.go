@@ -1,34 +1,97 @@
 package handlers
 
 import (
+	"sre-chat-api/internal/config"
 	"sre-chat-api/internal/database"
 	"sre-chat-api/internal/models"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// SSE event types broadcast to connected clients
+const (
+	EventMessage         = "message"
+	EventMessageRestored = "message_restored"
+	EventMessageUpdated  = "message_updated"
+	EventReactionAdded   = "reaction_added"
+	EventReactionRemoved = "reaction_removed"
+	EventAttachmentAdded = "attachment_added"
+	EventLinkPreview     = "link_preview"
+	EventMention         = "mention"
+	EventReadPosition    = "read_position"
+	EventPresence        = "presence"
+	EventTyping          = "typing"
+	EventMessagePinned   = "message_pinned"
+	EventMessageUnpinned = "message_unpinned"
+	EventPollUpdated     = "poll_updated"
+	EventCommandResponse = "command_response"
+	EventResync          = "resync"
+)
+
+// sseReplaySize is how many recent events are kept for streams that reconnect with
+// Last-Event-ID
+const sseReplaySize = 1000
+
 // SSEMessage represents a message sent via SSE
 type SSEMessage struct {
-	Type    string      `json:"type"`
-	Message models.Message `json:"message,omitempty"`
+	Type    string          `json:"type"`
+	Message *models.Message `json:"message,omitempty"`
+	Data    interface{}     `json:"data,omitempty"`
+
+	// target restricts delivery to one user's streams when set
+	target string
+	// groupID scopes events without a message to one group's streams
+	groupID uint
+	// seq orders the events kept for replay and id is sent as the SSE event ID;
+	// both are zero for events that are not replayed
+	seq uint64
+	id  string
+}
+
+// sseClient is a connected stream and what it subscribed to
+type sseClient struct {
+	user    string
+	groupID uint
+	// groups, when set, limits a bot's stream to the groups it has joined
+	groups map[uint]bool
 }
 
 // SSEHandler handles Server-Sent Events for real-time message updates
 type SSEHandler struct {
-	clients map[chan SSEMessage]bool
-	notify  chan models.Message
+	mu       sync.RWMutex
+	clients  map[chan SSEMessage]sseClient
+	notify   chan SSEMessage
+	presence *presenceTracker
+
+	// epoch tells event IDs of this process apart from those of earlier runs and
+	// other replicas, which cannot be resumed
+	epoch   string
+	seq     uint64
+	history []SSEMessage
 }
 
 // NewSSEHandler creates a new SSE handler
-func NewSSEHandler() *SSEHandler {
+func NewSSEHandler(cfg config.PresenceConfig) *SSEHandler {
 	handler := &SSEHandler{
-		clients: make(map[chan SSEMessage]bool),
-		notify:  make(chan models.Message, 100),
+		clients: make(map[chan SSEMessage]sseClient),
+		notify:  make(chan SSEMessage, 100),
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
 	}
+	handler.presence = newPresenceTracker(
+		time.Duration(cfg.GraceSeconds)*time.Second,
+		time.Duration(cfg.TypingTTLSeconds)*time.Second,
+		func(presence models.Presence) {
+			handler.Publish(SSEMessage{Type: EventPresence, Data: presence})
+		},
+	)
 	
 	// Start broadcasting goroutine
 	go handler.broadcast()
@@ -36,31 +99,80 @@ func NewSSEHandler() *SSEHandler {
 	return handler
 }
 
-// StreamMessages handles SSE connection for real-time message updates
+// StreamMessages handles SSE connection for real-time message updates.
+// Streams can identify their user (X-User header or user query parameter) to receive
+// events addressed to them, and pass group_id to only receive that group's events.
+// A stream reconnecting with Last-Event-ID (or last_event_id) first receives the
+// events it missed, or a resync event when they are no longer available.
 func (h *SSEHandler) StreamMessages(c *gin.Context) {
+	client := sseClient{user: currentUser(c)}
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		client.groupID = uint(id)
+	}
+
+	h.stream(c, client)
+}
+
+// StreamBotEvents streams the events of the groups a bot has joined, plus events
+// addressed to it such as mentions. Bots do not show up in presence.
+func (h *SSEHandler) StreamBotEvents(c *gin.Context, bot string, groupIDs []uint) {
+	h.stream(c, sseClient{user: bot, groups: groupSet(groupIDs)})
+}
+
+// SetBotGroups changes the groups the open streams of a bot receive events from
+func (h *SSEHandler) SetBotGroups(bot string, groupIDs []uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for clientChan, client := range h.clients {
+		if client.groups != nil && strings.EqualFold(client.user, bot) {
+			client.groups = groupSet(groupIDs)
+			h.clients[clientChan] = client
+		}
+	}
+}
+
+// stream sends events to the client until it disconnects
+func (h *SSEHandler) stream(c *gin.Context, client sseClient) {
 	// Set headers for SSE
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	// Create a channel for this client
-	messageChan := make(chan SSEMessage, 10)
-	h.clients[messageChan] = true
-
-	// Send initial connection message
-	initialMsg := SSEMessage{
-		Type: "connected",
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
 	}
-	sendSSE(c, initialMsg)
+
+	messageChan, missed, resumed := h.subscribe(client, lastEventID)
 
 	// Clean up when client disconnects
 	defer func() {
-		delete(h.clients, messageChan)
-		close(messageChan)
+		h.unsubscribe(messageChan, client)
 		log.Println("SSE client disconnected")
 	}()
 
+	// Send initial connection message
+	initialMsg := SSEMessage{
+		Type: "connected",
+	}
+	sendSSE(c, initialMsg)
+	if !resumed {
+		sendSSE(c, SSEMessage{Type: EventResync})
+	}
+	for _, msg := range missed {
+		if err := sendSSE(c, msg); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
 	// Keep connection alive and send messages
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -85,10 +197,99 @@ func (h *SSEHandler) StreamMessages(c *gin.Context) {
 	}
 }
 
+// subscribe registers a client and returns its channel together with the kept
+// events after lastEventID (see missedEvents). Registering the client and
+// collecting the missed events under the same lock means no event is both replayed
+// and sent again.
+func (h *SSEHandler) subscribe(client sseClient, lastEventID string) (chan SSEMessage, []SSEMessage, bool) {
+	messageChan := make(chan SSEMessage, 10)
+	h.mu.Lock()
+	h.clients[messageChan] = client
+	missed, resumed := h.missedEvents(lastEventID, client)
+	h.mu.Unlock()
+
+	if client.user != "" && client.groups == nil {
+		h.presence.connect(client.user)
+	}
+	return messageChan, missed, resumed
+}
+
+// unsubscribe removes a client registered by subscribe
+func (h *SSEHandler) unsubscribe(messageChan chan SSEMessage, client sseClient) {
+	h.mu.Lock()
+	delete(h.clients, messageChan)
+	h.mu.Unlock()
+	close(messageChan)
+	if client.user != "" && client.groups == nil {
+		h.presence.disconnect(client.user)
+	}
+}
+
 // NotifyNewMessage sends a new message to all connected clients
 func (h *SSEHandler) NotifyNewMessage(message models.Message) {
+	h.NotifyEvent(EventMessage, message)
+}
+
+// NotifyEvent sends a message event of the given type to all connected clients
+func (h *SSEHandler) NotifyEvent(eventType string, message models.Message) {
+	h.Publish(SSEMessage{Type: eventType, Message: &message})
+}
+
+// NotifyUser sends an event only to the streams of the given user
+func (h *SSEHandler) NotifyUser(user string, event SSEMessage) {
+	event.target = user
+	h.Publish(event)
+}
+
+// ConnectedUsers returns the distinct users with a stream receiving the group's events
+func (h *SSEHandler) ConnectedUsers(groupID uint) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var users []string
+	for _, client := range h.clients {
+		if client.user == "" || (client.groupID != 0 && client.groupID != groupID) {
+			continue
+		}
+		if client.groups != nil && !client.groups[groupID] {
+			continue
+		}
+		key := strings.ToLower(client.user)
+		if !seen[key] {
+			seen[key] = true
+			users = append(users, client.user)
+		}
+	}
+	return users
+}
+
+// Presence returns the presence of each of the given users
+func (h *SSEHandler) Presence(users []string) []models.Presence {
+	return h.presence.presence(users)
+}
+
+// SetAway marks a connected user as away or back online. It returns false when the
+// user has no open stream.
+func (h *SSEHandler) SetAway(user string, away bool) (models.Presence, bool) {
+	return h.presence.setAway(user, away)
+}
+
+// NotifyTyping fans a typing indicator out to the group's streams without persisting
+// it. Repeated notifications are dropped while the previous indicator is showing.
+func (h *SSEHandler) NotifyTyping(user string, groupID uint) models.TypingEvent {
+	expiresAt, send := h.presence.allowTyping(user, groupID)
+	event := models.TypingEvent{User: user, GroupID: groupID, ExpiresAt: expiresAt}
+	if send {
+		h.Publish(SSEMessage{Type: EventTyping, Data: event, groupID: groupID})
+	}
+	return event
+}
+
+// Publish sends an arbitrary event to all connected clients
+func (h *SSEHandler) Publish(event SSEMessage) {
 	select {
-	case h.notify <- message:
+	case h.notify <- event:
 	default:
 		// Channel full, skip (prevents blocking)
 	}
@@ -97,25 +298,85 @@ func (h *SSEHandler) NotifyNewMessage(message models.Message) {
 // broadcast sends new messages to all connected clients
 func (h *SSEHandler) broadcast() {
 	for {
-		message := <-h.notify
-		
-		// Load group relationship
-		database.DB.Preload("Group").First(&message, message.ID)
+		sseMsg := <-h.notify
 		
-		sseMsg := SSEMessage{
-			Type:    "message",
-			Message: message,
+		if sseMsg.Message != nil {
+			// Load group relationship, reactions, attachments and link previews
+			database.DB.Preload("Group").First(sseMsg.Message, sseMsg.Message.ID)
+			enrichMessages(sseMsg.Message)
 		}
 
-		// Send to all clients
-		for clientChan := range h.clients {
+		groupID := sseMsg.eventGroupID()
+
+		// Send to all interested clients, keeping the event for streams that
+		// reconnect; typing indicators are stale by then
+		h.mu.Lock()
+		if sseMsg.Type != EventTyping {
+			h.seq++
+			sseMsg.seq = h.seq
+			sseMsg.id = h.epoch + "-" + strconv.FormatUint(h.seq, 10)
+			h.history = append(h.history, sseMsg)
+			if len(h.history) > sseReplaySize {
+				h.history = h.history[len(h.history)-sseReplaySize:]
+			}
+		}
+		for clientChan, client := range h.clients {
+			if !client.wants(sseMsg, groupID) {
+				continue
+			}
 			select {
 			case clientChan <- sseMsg:
 			default:
 				// Client channel full, skip
 			}
 		}
+		h.mu.Unlock()
+	}
+}
+
+// missedEvents returns the kept events after lastEventID that the client wants.
+// resumed is false when lastEventID was issued by another process or is older
+// than the kept events, so that events may have been missed. Callers hold h.mu.
+func (h *SSEHandler) missedEvents(lastEventID string, client sseClient) (missed []SSEMessage, resumed bool) {
+	if lastEventID == "" {
+		return nil, true
+	}
+
+	epoch, seqText, ok := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if !ok || err != nil || epoch != h.epoch || seq > h.seq {
+		return nil, false
+	}
+	if len(h.history) > 0 && seq+1 < h.history[0].seq {
+		return nil, false
+	}
+
+	for _, event := range h.history {
+		if event.seq > seq && client.wants(event, event.eventGroupID()) {
+			missed = append(missed, event)
+		}
 	}
+	return missed, true
+}
+
+// eventGroupID returns the group an event is about, or 0 for events about no group
+func (m SSEMessage) eventGroupID() uint {
+	if m.Message != nil {
+		return m.Message.GroupID
+	}
+	return m.groupID
+}
+
+// wants reports whether the client should receive an event about the given group
+func (c sseClient) wants(event SSEMessage, groupID uint) bool {
+	// Events addressed to a user reach all of their streams, whatever the group
+	if event.target != "" {
+		return strings.EqualFold(c.user, event.target)
+	}
+	if c.groups != nil {
+		return c.groups[groupID]
+	}
+	return c.groupID == 0 || groupID == 0 || c.groupID == groupID
 }
 
 // sendSSE sends a message in SSE format
@@ -125,7 +386,20 @@ func sendSSE(c *gin.Context, msg SSEMessage) error {
 		return err
 	}
 	
+	if msg.id != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", msg.id); err != nil {
+			return err
+		}
+	}
 	_, err = fmt.Fprintf(c.Writer, "data: %s\n\n", data)
 	return err
 }
 
+// groupSet turns group IDs into a set, never nil so that an empty set matches nothing
+func groupSet(groupIDs []uint) map[uint]bool {
+	set := make(map[uint]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		set[id] = true
+	}
+	return set
+}
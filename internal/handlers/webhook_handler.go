@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+)
+
+// errDeliveryQueued is returned when retrying a delivery that is still pending
+var errDeliveryQueued = errors.New("delivery is already queued")
+
+// WebhookHandler handles outgoing webhook HTTP requests
+type WebhookHandler struct {
+	allowPrivate bool
+}
+
+// NewWebhookHandler creates a new webhook handler. Webhook URLs may only name
+// private hosts when the configuration allows delivering to them.
+func NewWebhookHandler(cfg config.WebhooksConfig) *WebhookHandler {
+	return &WebhookHandler{allowPrivate: cfg.AllowPrivate}
+}
+
+// GetWebhooks handles GET /api/v1/admin/groups/:id/webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := database.DB.Where("group_id = ?", group.ID).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateWebhook handles POST /api/v1/admin/groups/:id/webhooks
+// The response is the only place the signing secret is returned.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, ok := webhookEvents(c, req.Events)
+	if !ok {
+		return
+	}
+	if err := webhooks.ValidateURL(req.URL, h.allowPrivate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhooks.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+	}
+
+	hook := models.Webhook{
+		GroupID: group.ID,
+		URL:     strings.TrimSpace(req.URL),
+		Secret:  secret,
+		Events:  events,
+		Active:  true,
+	}
+	if err := database.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateWebhook handles PUT /api/v1/admin/webhooks/:id
+// Deactivated webhooks stop receiving new events; deliveries already queued are still sent.
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.URL != nil {
+		if err := webhooks.ValidateURL(*req.URL, h.allowPrivate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hook.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Events != nil {
+		events, ok := webhookEvents(c, req.Events)
+		if !ok {
+			return
+		}
+		hook.Events = events
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if err := database.DB.Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook handles DELETE /api/v1/admin/webhooks/:id
+// Deliveries still in the outbox are dropped with the webhook.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries handles GET /api/v1/admin/webhooks/:id/deliveries
+// It returns the webhook's delivery log, newest first, with every attempt of each
+// delivery. Pass status to only list pending, delivered or dead deliveries.
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		if status != models.WebhookPending && status != models.WebhookDelivered && status != models.WebhookDead {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	h.listDeliveries(c, query)
+}
+
+// GetDeadLetters handles GET /api/v1/admin/webhook-deliveries/dead
+// It lists the deliveries of all webhooks that exhausted their attempts.
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	h.listDeliveries(c, database.DB.Where("status = ?", models.WebhookDead))
+}
+
+// RetryDelivery handles POST /api/v1/admin/webhook-deliveries/:id/retry
+// A dead or delivered payload is queued again with a fresh set of attempts.
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, uint(id)).Error; err != nil {
+			return err
+		}
+		if delivery.Status == models.WebhookPending {
+			return errDeliveryQueued
+		}
+		return tx.Model(&delivery).Updates(map[string]interface{}{
+			"status":          models.WebhookPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"last_error":      "",
+		}).Error
+	})
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		case errDeliveryQueued:
+			c.JSON(http.StatusConflict, gin.H{"error": "Delivery is already queued"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry delivery"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// listDeliveries writes the deliveries matched by the query, newest first and
+// limited by the limit query parameter, with their attempts
+func (h *WebhookHandler) listDeliveries(c *gin.Context, query *gorm.DB) {
+	limit := defaultWebhookDeliveryLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxWebhookDeliveryLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempted_at") }).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookEvents validates the events a webhook subscribes to, defaulting to every
+// message event. It writes an error response and returns false if one is unknown.
+func webhookEvents(c *gin.Context, events []string) ([]string, bool) {
+	if len(events) == 0 {
+		return append([]string(nil), webhooks.Events...), true
+	}
+
+	seen := make(map[string]bool, len(events))
+	var valid []string
+	for _, event := range events {
+		if !webhooks.ValidEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event: " + event})
+			return nil, false
+		}
+		if !seen[event] {
+			seen[event] = true
+			valid = append(valid, event)
+		}
+	}
+	return valid, true
+}
+
+// findWebhook loads the webhook named by the id path parameter, writing an error
+// response and returning false if it is invalid or does not exist
+func findWebhook(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return hook, false
+	}
+
+	if err := database.DB.First(&hook, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return hook, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return hook, false
+	}
+
+	return hook, true
+}
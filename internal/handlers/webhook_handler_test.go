@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// webhookReceiver records the payloads POSTed to it whose signature verifies
+type webhookReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	payloads []webhooks.Payload
+	invalid  int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !webhooks.Verify(r.secret, req.Header.Get(webhooks.HeaderTimestamp), body, req.Header.Get(webhooks.HeaderSignature)) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload webhooks.Payload
+	json.Unmarshal(body, &payload)
+	r.payloads = append(r.payloads, payload)
+	w.WriteHeader(r.status)
+}
+
+func setupWebhookRouter(router *gin.Engine) {
+	webhookHandler := NewWebhookHandler(config.WebhooksConfig{AllowPrivate: true}) // test receivers listen on loopback
+	router.GET("/api/v1/admin/groups/:id/webhooks", webhookHandler.GetWebhooks)
+	router.POST("/api/v1/admin/groups/:id/webhooks", webhookHandler.CreateWebhook)
+	router.PUT("/api/v1/admin/webhooks/:id", webhookHandler.UpdateWebhook)
+	router.GET("/api/v1/admin/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+	router.GET("/api/v1/admin/webhook-deliveries/dead", webhookHandler.GetDeadLetters)
+	router.POST("/api/v1/admin/webhook-deliveries/:id/retry", webhookHandler.RetryDelivery)
+}
+
+func createTestWebhook(t *testing.T, router *gin.Engine, groupID uint, url string, events []string) models.Webhook {
+	jsonBody, _ := json.Marshal(models.CreateWebhookRequest{URL: url, Events: events})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/admin/groups/%d/webhooks", groupID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var hook models.Webhook
+	json.Unmarshal(w.Body.Bytes(), &hook)
+	assert.NotEmpty(t, hook.Secret)
+	return hook
+}
+
+func postTestMessage(t *testing.T, router *gin.Engine, user, content string) models.Message {
+	jsonBody, _ := json.Marshal(models.CreateMessageRequest{User: user, Content: content})
+	req, _ := http.NewRequest("POST", "/api/v1/messages", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var message models.Message
+	json.Unmarshal(w.Body.Bytes(), &message)
+	return message
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	setupWebhookRouter(router)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hook := createTestWebhook(t, router, group.ID, server.URL, nil)
+	receiver.secret = hook.Secret
+	assert.Equal(t, webhooks.Events, hook.Events)
+
+	// The secret is not listed again
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/admin/groups/%d/webhooks", group.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var hooks []models.Webhook
+	json.Unmarshal(w.Body.Bytes(), &hooks)
+	assert.Len(t, hooks, 1)
+	assert.Empty(t, hooks[0].Secret)
+
+	message := postTestMessage(t, router, "alice", "Deploy started")
+
+	jsonBody, _ := json.Marshal(models.UpdateMessageRequest{Content: "Deploy finished"})
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/messages/%d", message.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/v1/messages/%d", message.ID), nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Events wait in the outbox until the dispatcher runs
+	var pending int64
+	db.Model(&models.WebhookDelivery{}).Where("status = ?", models.WebhookPending).Count(&pending)
+	assert.Equal(t, int64(3), pending)
+
+	dispatcher := jobs.NewWebhookDispatcher(config.WebhooksConfig{IntervalSeconds: 1, TimeoutSeconds: 5, AllowPrivate: true}, zap.NewNop())
+	delivered, err := dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+
+	assert.Equal(t, 0, receiver.invalid)
+	if assert.Len(t, receiver.payloads, 3) {
+		assert.Equal(t, webhooks.EventMessageCreated, receiver.payloads[0].Event)
+		assert.Equal(t, "Deploy started", receiver.payloads[0].Message.Content)
+		assert.Equal(t, webhooks.EventMessageUpdated, receiver.payloads[1].Event)
+		assert.Equal(t, "Deploy finished", receiver.payloads[1].Message.Content)
+		assert.Equal(t, webhooks.EventMessageDeleted, receiver.payloads[2].Event)
+		assert.Equal(t, message.ID, receiver.payloads[2].Message.ID)
+	}
+
+	// Delivered payloads are not sent again
+	delivered, err = dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/admin/webhooks/%d/deliveries", hook.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	if assert.Len(t, deliveries, 3) {
+		assert.Equal(t, models.WebhookDelivered, deliveries[0].Status)
+		assert.Len(t, deliveries[0].Log, 1)
+		assert.Equal(t, http.StatusOK, deliveries[0].Log[0].StatusCode)
+	}
+
+	// Unsubscribed events are not queued
+	jsonBody, _ = json.Marshal(models.UpdateWebhookRequest{Events: []string{webhooks.EventMessageDeleted}})
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/api/v1/admin/webhooks/%d", hook.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	postTestMessage(t, router, "alice", "Not delivered")
+	db.Model(&models.WebhookDelivery{}).Where("status = ?", models.WebhookPending).Count(&pending)
+	assert.Equal(t, int64(0), pending)
+}
+
+func TestWebhookRetryAndDeadLetter(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	setupWebhookRouter(router)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	receiver := &webhookReceiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hook := createTestWebhook(t, router, group.ID, server.URL, []string{webhooks.EventMessageCreated})
+	receiver.secret = hook.Secret
+
+	postTestMessage(t, router, "alice", "Pager fired")
+
+	// No backoff so the retry is due on the next run
+	dispatcher := jobs.NewWebhookDispatcher(config.WebhooksConfig{IntervalSeconds: 1, TimeoutSeconds: 5, MaxAttempts: 2, AllowPrivate: true}, zap.NewNop())
+	for i := 0; i < 2; i++ {
+		delivered, err := dispatcher.DispatchDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+	}
+	assert.Len(t, receiver.payloads, 2)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/webhook-deliveries/dead", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var dead []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &dead)
+	if !assert.Len(t, dead, 1) {
+		return
+	}
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, dead[0].LastStatusCode)
+	assert.Len(t, dead[0].Log, 2)
+
+	// Dead deliveries are not retried until requeued
+	delivered, err := dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, receiver.payloads, 2)
+
+	url := fmt.Sprintf("/api/v1/admin/webhook-deliveries/%d/retry", dead[0].ID)
+	req, _ = http.NewRequest("POST", url, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("POST", url, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	receiver.mu.Lock()
+	receiver.status = http.StatusNoContent
+	receiver.mu.Unlock()
+
+	delivered, err = dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	req, _ = http.NewRequest("GET", "/api/v1/admin/webhook-deliveries/dead", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &dead)
+	assert.Empty(t, dead)
+}
+
+func TestWebhookPrivateAddress(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	router := setupRouter(db)
+	setupWebhookRouter(router)
+
+	var group models.Group
+	db.Where("name = ?", "SRE Bootcamp").First(&group)
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// Without WEBHOOKS_ALLOW_PRIVATE loopback URLs are refused when created...
+	handler := NewWebhookHandler(config.WebhooksConfig{})
+	strict := gin.New()
+	strict.POST("/api/v1/admin/groups/:id/webhooks", handler.CreateWebhook)
+	jsonBody, _ := json.Marshal(models.CreateWebhookRequest{URL: server.URL})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/admin/groups/%d/webhooks", group.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	strict.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// ...and never dialed, whatever the stored URL
+	hook := createTestWebhook(t, router, group.ID, server.URL, []string{webhooks.EventMessageCreated})
+	receiver.secret = hook.Secret
+	postTestMessage(t, router, "alice", "Deploy started")
+
+	dispatcher := jobs.NewWebhookDispatcher(config.WebhooksConfig{IntervalSeconds: 1, TimeoutSeconds: 5}, zap.NewNop())
+	delivered, err := dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Empty(t, receiver.payloads)
+
+	var delivery models.WebhookDelivery
+	db.Where("webhook_id = ?", hook.ID).First(&delivery)
+	assert.Contains(t, delivery.LastError, "address is not allowed")
+}
@@ -0,0 +1,134 @@
+// Package incidents holds the rules for incident severities, statuses and group
+// names, and renders incident timelines for postmortems.
+package incidents
+
+import (
+	"errors"
+	"fmt"
+	"sre-chat-api/internal/models"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TimelineTag marks a message for the incident timeline without pinning it
+const TimelineTag = "#timeline"
+
+// maxSlugLength bounds the part of a group name derived from the incident title
+const maxSlugLength = 40
+
+var (
+	// ErrInvalidSeverity is returned for a severity other than SEV1 to SEV4
+	ErrInvalidSeverity = errors.New("severity must be one of SEV1, SEV2, SEV3, SEV4")
+	// ErrInvalidStatus is returned for an unknown incident status
+	ErrInvalidStatus = errors.New("status must be one of investigating, identified, monitoring, resolved")
+)
+
+// Severities lists the severities, most severe first
+var Severities = []string{models.SeverityOne, models.SeverityTwo, models.SeverityThree, models.SeverityFour}
+
+// Statuses lists the statuses in the order an incident usually moves through them
+var Statuses = []string{models.IncidentInvestigating, models.IncidentIdentified, models.IncidentMonitoring, models.IncidentResolved}
+
+// ParseSeverity accepts a severity in any case, with or without the "SEV" prefix
+func ParseSeverity(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if !strings.HasPrefix(value, "SEV") {
+		value = "SEV" + value
+	}
+	for _, severity := range Severities {
+		if value == severity {
+			return severity, nil
+		}
+	}
+	return "", ErrInvalidSeverity
+}
+
+// ParseStatus accepts a status in any case
+func ParseStatus(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, status := range Statuses {
+		if value == status {
+			return status, nil
+		}
+	}
+	return "", ErrInvalidStatus
+}
+
+// GroupName derives the name of an incident's group from its declaration date
+// and title, e.g. "incident-20240301-checkout-latency"
+func GroupName(title string, declaredAt time.Time) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if slug.Len() >= maxSlugLength {
+			break
+		}
+	}
+
+	name := "incident-" + declaredAt.UTC().Format("20060102")
+	if slug.Len() > 0 {
+		name += "-" + slug.String()
+	}
+	return name
+}
+
+// Topic is the group description kept in sync with the incident's fields
+func Topic(incident models.Incident) string {
+	return fmt.Sprintf("%s · %s · IC %s · %s", incident.Severity, incident.Status, incident.Commander, incident.Title)
+}
+
+// Tagged reports whether a message's content carries the timeline tag
+func Tagged(content string) bool {
+	return strings.Contains(strings.ToLower(content), TimelineTag)
+}
+
+// Markdown renders a timeline as a postmortem document
+func Markdown(timeline models.IncidentTimeline) string {
+	incident := timeline.Incident
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Incident %d: %s\n\n", incident.ID, incident.Title)
+	fmt.Fprintf(&b, "- Severity: %s\n", incident.Severity)
+	fmt.Fprintf(&b, "- Status: %s\n", incident.Status)
+	fmt.Fprintf(&b, "- Commander: %s\n", incident.Commander)
+	fmt.Fprintf(&b, "- Declared: %s by %s\n", formatTime(incident.CreatedAt), incident.DeclaredBy)
+	if incident.ResolvedAt != nil {
+		fmt.Fprintf(&b, "- Resolved: %s (duration %s)\n", formatTime(*incident.ResolvedAt), incident.ResolvedAt.Sub(incident.CreatedAt).Round(time.Minute))
+	}
+	if incident.Group.Name != "" {
+		fmt.Fprintf(&b, "- Group: %s\n", incident.Group.Name)
+	}
+
+	b.WriteString("\n## Timeline\n\n")
+	if len(timeline.Entries) == 0 {
+		b.WriteString("No timeline entries.\n")
+		return b.String()
+	}
+	b.WriteString("| Time (UTC) | Who | Kind | Event |\n")
+	b.WriteString("|---|---|---|---|\n")
+	for _, entry := range timeline.Entries {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", formatTime(entry.At), escapeCell(entry.User), entry.Kind, escapeCell(entry.Content))
+	}
+	return b.String()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// escapeCell keeps message content on one table row
+func escapeCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\r\n", "<br>")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
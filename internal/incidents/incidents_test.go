@@ -0,0 +1,67 @@
+package incidents
+
+import (
+	"sre-chat-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for _, value := range []string{"SEV1", "sev1", " Sev1 ", "1"} {
+		severity, err := ParseSeverity(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, models.SeverityOne, severity)
+	}
+	for _, value := range []string{"", "sev0", "SEV5", "critical"} {
+		_, err := ParseSeverity(value)
+		assert.ErrorIs(t, err, ErrInvalidSeverity, value)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	status, err := ParseStatus("Identified")
+	assert.NoError(t, err)
+	assert.Equal(t, models.IncidentIdentified, status)
+
+	_, err = ParseStatus("closed")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
+
+func TestGroupName(t *testing.T) {
+	declared := time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("PST", -8*3600))
+	assert.Equal(t, "incident-20240302-checkout-p99-latency-2s", GroupName("Checkout: p99 latency > 2s!", declared))
+	assert.Equal(t, "incident-20240302", GroupName("!!!", declared))
+	assert.LessOrEqual(t, len(GroupName(strings.Repeat("a", 100), declared)), len("incident-20240302-")+maxSlugLength)
+}
+
+func TestTagged(t *testing.T) {
+	assert.True(t, Tagged("Rolled back api v1.2.3 #Timeline"))
+	assert.False(t, Tagged("nothing to see"))
+}
+
+func TestMarkdown(t *testing.T) {
+	declared := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	resolved := declared.Add(90 * time.Minute)
+	doc := Markdown(models.IncidentTimeline{
+		Incident: models.Incident{
+			ID:         3,
+			Title:      "Checkout latency",
+			Severity:   models.SeverityTwo,
+			Status:     models.IncidentResolved,
+			Commander:  "alice",
+			DeclaredBy: "bob",
+			CreatedAt:  declared,
+			ResolvedAt: &resolved,
+		},
+		Entries: []models.TimelineEntry{
+			{At: declared.Add(time.Minute), User: "carol", Kind: models.TimelinePinned, Content: "Error rate | 5%\nfrom LB logs"},
+		},
+	})
+
+	assert.True(t, strings.HasPrefix(doc, "# Incident 3: Checkout latency\n"))
+	assert.Contains(t, doc, "- Resolved: 2024-03-01 13:30:00 (duration 1h30m0s)\n")
+	assert.Contains(t, doc, "| 2024-03-01 12:01:00 | carol | pinned | Error rate \\| 5%<br>from LB logs |\n")
+}
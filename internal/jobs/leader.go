@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// LeaderElector elects one replica to run a singleton job using a lease row in the
+// database. The holder must renew the lease before it expires; once it lapses any
+// replica may take over.
+type LeaderElector struct {
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+// NewLeaderElector creates an elector for the named lease
+func NewLeaderElector(name string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		name:   name,
+		holder: replicaID(),
+		ttl:    ttl,
+	}
+}
+
+// Holder returns the identity this replica uses in the lease
+func (e *LeaderElector) Holder() string {
+	return e.holder
+}
+
+// Acquire takes or renews the lease and reports whether this replica is the leader
+func (e *LeaderElector) Acquire(ctx context.Context) (bool, error) {
+	if database.DB == nil {
+		return false, fmt.Errorf("database connection not established")
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(e.ttl)
+	db := database.DB.WithContext(ctx)
+
+	// Renew our own lease or take over an expired one
+	result := db.Model(&models.LeaderLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", e.name, e.holder, now).
+		Updates(map[string]interface{}{"holder": e.holder, "expires_at": expires})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// Nobody has held the lease yet
+	lease := models.LeaderLease{Name: e.name, Holder: e.holder, ExpiresAt: expires}
+	result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create lease: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release gives up the lease if this replica holds it, letting another take over
+// without waiting for it to expire
+func (e *LeaderElector) Release(ctx context.Context) error {
+	if database.DB == nil {
+		return nil
+	}
+	return database.DB.WithContext(ctx).
+		Where("name = ? AND holder = ?", e.name, e.holder).
+		Delete(&models.LeaderLease{}).Error
+}
+
+// replicaID identifies this process among the replicas
+func replicaID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/oncall"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HandoffAnnouncer posts a message into a schedule's group whenever someone else
+// goes on call. Each schedule remembers who was last announced and is locked while
+// it is checked, so any number of replicas can run it.
+type HandoffAnnouncer struct {
+	interval time.Duration
+	user     string
+	logger   *zap.Logger
+
+	mu      sync.RWMutex
+	deliver func(models.Message)
+}
+
+// NewHandoffAnnouncer creates a new handoff announcer from the on-call configuration
+func NewHandoffAnnouncer(cfg config.OncallConfig, logger *zap.Logger) *HandoffAnnouncer {
+	return &HandoffAnnouncer{
+		interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		user:     cfg.User,
+		logger:   logger,
+	}
+}
+
+// OnDeliver sets the function that notifies clients about each posted message
+func (a *HandoffAnnouncer) OnDeliver(deliver func(models.Message)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.deliver = deliver
+}
+
+// Run announces handoffs on every interval until the context is cancelled
+func (a *HandoffAnnouncer) Run(ctx context.Context) {
+	if a.interval <= 0 {
+		a.logger.Info("On-call handoff announcements are disabled")
+		return
+	}
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if announced, err := a.AnnounceDue(ctx, time.Now()); err != nil {
+			a.logger.Error("Failed to announce on-call handoffs", zap.Error(err))
+		} else if announced > 0 {
+			a.logger.Info("Announced on-call handoffs", zap.Int("count", announced))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// AnnounceDue posts a handoff message for every schedule whose on-call user changed
+// since the last announcement and returns how many were posted
+func (a *HandoffAnnouncer) AnnounceDue(ctx context.Context, now time.Time) (int, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	var ids []uint
+	if err := database.DB.WithContext(ctx).Model(&models.OncallSchedule{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to load on-call schedules: %w", err)
+	}
+
+	a.mu.RLock()
+	deliver := a.deliver
+	a.mu.RUnlock()
+
+	announced := 0
+	for _, id := range ids {
+		message, err := a.announce(ctx, id, now)
+		if err != nil {
+			a.logger.Warn("Failed to announce on-call handoff", zap.Uint("schedule_id", id), zap.Error(err))
+			continue
+		}
+		if message == nil {
+			continue
+		}
+		announced++
+		if deliver != nil {
+			deliver(*message)
+		}
+	}
+	return announced, nil
+}
+
+// announce checks one schedule and posts its handoff if the on-call user changed
+func (a *HandoffAnnouncer) announce(ctx context.Context, id uint, now time.Time) (*models.Message, error) {
+	var posted *models.Message
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule models.OncallSchedule
+		query := tx.Where("id = ?", id).Limit(1)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		if err := query.Find(&schedule).Error; err != nil {
+			return err
+		}
+		// Deleted, or being checked by another replica
+		if schedule.ID == 0 {
+			return nil
+		}
+
+		var overrides []models.OncallOverride
+		if err := tx.Where("schedule_id = ? AND ends_at > ?", schedule.ID, now).Find(&overrides).Error; err != nil {
+			return err
+		}
+		shift, err := oncall.At(schedule, overrides, now)
+		if err != nil {
+			return err
+		}
+		if shift.User == schedule.LastOncall {
+			return nil
+		}
+
+		message := models.Message{
+			GroupID: schedule.GroupID,
+			User:    a.user,
+			Content: HandoffMessage(schedule, schedule.LastOncall, shift),
+		}
+		if err := messages.Create(tx, &message); err != nil {
+			return err
+		}
+		posted = &message
+
+		return tx.Model(&schedule).Updates(map[string]interface{}{
+			"last_oncall":     shift.User,
+			"last_handoff_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posted, nil
+}
+
+// HandoffMessage words the announcement that shift's user took over from previous,
+// who is empty for a schedule's first announcement
+func HandoffMessage(schedule models.OncallSchedule, previous string, shift models.OncallShift) string {
+	until := shift.End
+	if loc, err := time.LoadLocation(schedule.Timezone); err == nil {
+		until = until.In(loc)
+	}
+
+	text := fmt.Sprintf("On-call handoff for %s: %s → @%s", schedule.Name, previous, shift.User)
+	if previous == "" {
+		text = fmt.Sprintf("@%s is now on call for %s", shift.User, schedule.Name)
+	}
+	text += " until " + until.Format("Mon 2006-01-02 15:04 MST")
+	if shift.Override {
+		text += " (override)"
+	}
+	return text
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PollCloser closes polls whose closes_at has passed so clients and webhooks hear
+// about it. A poll is closed by setting closed_at only where it is still unset, so
+// any number of replicas can run it and each poll is announced once.
+type PollCloser struct {
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu     sync.RWMutex
+	notify func(models.Message)
+}
+
+// NewPollCloser creates a new poll closer from the polls configuration
+func NewPollCloser(cfg config.PollsConfig, logger *zap.Logger) *PollCloser {
+	return &PollCloser{
+		interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		logger:   logger,
+	}
+}
+
+// OnClose sets the function that notifies clients about each closed poll's message
+func (p *PollCloser) OnClose(notify func(models.Message)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notify = notify
+}
+
+// Run closes due polls on every interval until the context is cancelled
+func (p *PollCloser) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		p.logger.Info("Closing polls at closes_at is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if closed, err := p.CloseDue(ctx, time.Now()); err != nil {
+			p.logger.Error("Failed to close polls", zap.Error(err))
+		} else if closed > 0 {
+			p.logger.Info("Closed polls", zap.Int("count", closed))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CloseDue closes every open poll whose closes_at is not after now and returns how
+// many this call closed
+func (p *PollCloser) CloseDue(ctx context.Context, now time.Time) (int, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	var due []models.Poll
+	if err := database.DB.WithContext(ctx).
+		Where("closed_at IS NULL AND closes_at <= ?", now).
+		Order("closes_at, id").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due polls: %w", err)
+	}
+
+	p.mu.RLock()
+	notify := p.notify
+	p.mu.RUnlock()
+
+	closed := 0
+	for _, poll := range due {
+		message, err := closePoll(ctx, poll)
+		if err != nil {
+			p.logger.Warn("Failed to close poll", zap.Uint("poll_id", poll.ID), zap.Error(err))
+			continue
+		}
+		if message == nil {
+			continue
+		}
+		closed++
+		if notify != nil {
+			notify(*message)
+		}
+	}
+	return closed, nil
+}
+
+// closePoll marks one poll closed as of its closes_at and queues a message.updated
+// webhook for its message. It returns nil when another replica closed it first.
+func closePoll(ctx context.Context, poll models.Poll) (*models.Message, error) {
+	var closed *models.Message
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Poll{}).
+			Where("id = ? AND closed_at IS NULL", poll.ID).
+			Update("closed_at", poll.ClosesAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var message models.Message
+		if err := tx.Where("id = ?", poll.MessageID).Limit(1).Find(&message).Error; err != nil {
+			return err
+		}
+		// The message was deleted; the poll is closed but there is nothing to announce
+		if message.ID == 0 {
+			return nil
+		}
+		if err := webhooks.Enqueue(tx, webhooks.EventMessageUpdated, message); err != nil {
+			return err
+		}
+		closed = &message
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return closed, nil
+}
@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Purger periodically hard-deletes messages that have been soft-deleted for too long
+type Purger struct {
+	maxAge   time.Duration
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// NewPurger creates a new purger from the purge configuration
+func NewPurger(cfg config.PurgeConfig, logger *zap.Logger) *Purger {
+	return &Purger{
+		maxAge:   time.Duration(cfg.AfterDays) * 24 * time.Hour,
+		interval: time.Duration(cfg.IntervalMinutes) * time.Minute,
+		logger:   logger,
+	}
+}
+
+// Enabled reports whether auto-purge is configured
+func (p *Purger) Enabled() bool {
+	return p.maxAge > 0 && p.interval > 0
+}
+
+// Run purges expired messages on every interval until the context is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	if !p.Enabled() {
+		p.logger.Info("Auto-purge of deleted messages is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.PurgeOnce(); err != nil {
+			p.logger.Error("Failed to purge deleted messages", zap.Error(err))
+		} else if purged > 0 {
+			p.logger.Info("Purged deleted messages", zap.Int64("count", purged))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PurgeOnce hard-deletes messages soft-deleted before the configured age and returns the number removed
+func (p *Purger) PurgeOnce() (int64, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	cutoff := time.Now().Add(-p.maxAge)
+	purged, keys, err := PurgeMessages(func(db *gorm.DB) *gorm.DB {
+		return db.Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	}, 0)
+	if err := storage.DeleteAll(context.Background(), keys); err != nil {
+		p.logger.Warn("Failed to delete attachments of purged messages", zap.Error(err))
+	}
+	return purged, err
+}
+
+// PurgeMessages hard-deletes the messages matching scope, at most limit of them
+// unless limit is 0, and returns the blob keys of their attachments for the caller
+// to delete with storage.DeleteAll. The messages stay locked between reading their
+// attachments and deleting them, so that none can be restored in between.
+func PurgeMessages(scope func(*gorm.DB) *gorm.DB, limit int) (int64, []string, error) {
+	var purged int64
+	var keys []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Unscoped().Model(&models.Message{}).
+			Scopes(scope).
+			Order("id").
+			Clauses(clause.Locking{Strength: "UPDATE"})
+		if limit > 0 {
+			query = query.Limit(limit)
+		}
+		var ids []uint
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		var attachments []models.Attachment
+		if err := tx.Select("storage_key", "thumbnail_key").Where("message_id IN ?", ids).Find(&attachments).Error; err != nil {
+			return err
+		}
+		for _, attachment := range attachments {
+			keys = append(keys, attachment.StorageKey, attachment.ThumbnailKey)
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Message{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return purged, keys, nil
+}
@@ -0,0 +1,251 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/cron"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reminderLease is the name of the lease held by the replica that fires reminders
+const reminderLease = "reminders"
+
+// ErrScheduleNeverFires is returned for cron expressions with no upcoming run
+var ErrScheduleNeverFires = errors.New("schedule never fires")
+
+// ReminderRunner fires recurring reminders. Only the replica holding the reminders
+// lease fires them, and it wakes exactly when the next reminder is due.
+type ReminderRunner struct {
+	enabled    bool
+	elector    *LeaderElector
+	renewEvery time.Duration
+	logger     *zap.Logger
+	wake       chan struct{}
+
+	mu      sync.RWMutex
+	deliver func(models.Message)
+}
+
+// NewReminderRunner creates a new reminder runner from the reminders configuration
+func NewReminderRunner(cfg config.RemindersConfig, logger *zap.Logger) *ReminderRunner {
+	lease := time.Duration(cfg.LeaseSeconds) * time.Second
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+
+	return &ReminderRunner{
+		enabled:    cfg.Enabled,
+		elector:    NewLeaderElector(reminderLease, lease),
+		renewEvery: lease / 3,
+		logger:     logger,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// OnDeliver sets the function that notifies clients about each posted message
+func (r *ReminderRunner) OnDeliver(deliver func(models.Message)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliver = deliver
+}
+
+// Wake makes the runner recheck when the next reminder is due, after reminders change
+func (r *ReminderRunner) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run fires due reminders while this replica is the leader, until the context is cancelled
+func (r *ReminderRunner) Run(ctx context.Context) {
+	if !r.enabled {
+		r.logger.Info("Recurring reminders are disabled")
+		return
+	}
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.elector.Release(releaseCtx); err != nil {
+			r.logger.Warn("Failed to release reminders lease", zap.Error(err))
+		}
+	}()
+
+	leading := false
+	for {
+		wait := r.renewEvery
+
+		leader, err := r.elector.Acquire(ctx)
+		if err != nil {
+			r.logger.Error("Failed to acquire reminders lease", zap.Error(err))
+		}
+		if leader != leading {
+			r.logger.Info("Reminders leadership changed",
+				zap.Bool("leader", leader),
+				zap.String("holder", r.elector.Holder()),
+			)
+			leading = leader
+		}
+
+		if leader {
+			if fired, err := r.RunDue(ctx, time.Now()); err != nil {
+				r.logger.Error("Failed to fire reminders", zap.Error(err))
+			} else if fired > 0 {
+				r.logger.Info("Fired reminders", zap.Int("count", fired))
+			}
+
+			// Sleep until the next reminder is due rather than polling
+			if next, ok := nextReminderDue(); ok {
+				if until := time.Until(next); until < wait {
+					wait = max(until, 0)
+				}
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-r.wake:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// RunDue fires every enabled reminder due at or before now and returns how many
+// messages were posted
+func (r *ReminderRunner) RunDue(ctx context.Context, now time.Time) (int, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	var due []models.Reminder
+	if err := database.DB.WithContext(ctx).
+		Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at, id").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to load due reminders: %w", err)
+	}
+
+	r.mu.RLock()
+	deliver := r.deliver
+	r.mu.RUnlock()
+
+	fired := 0
+	for _, reminder := range due {
+		message, err := r.fire(ctx, reminder, now)
+		if err != nil {
+			return fired, fmt.Errorf("reminder %d: %w", reminder.ID, err)
+		}
+		if message == nil {
+			continue
+		}
+		fired++
+		if deliver != nil {
+			deliver(*message)
+		}
+	}
+	return fired, nil
+}
+
+// fire posts one due reminder, records the run and schedules the next one. A run
+// already recorded for the same scheduled time, for example by a previous leader
+// that crashed before advancing the reminder, is not posted again.
+func (r *ReminderRunner) fire(ctx context.Context, reminder models.Reminder, now time.Time) (*models.Message, error) {
+	var posted *models.Message
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		run := models.ReminderRun{
+			ReminderID:   reminder.ID,
+			ScheduledFor: *reminder.NextRunAt,
+			FiredAt:      now,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			message := models.Message{
+				GroupID: reminder.GroupID,
+				User:    reminder.User,
+				Content: reminder.Content,
+			}
+			// A savepoint keeps a failed post from aborting the run record
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				return messages.Create(tx, &message)
+			}); err != nil {
+				r.logger.Warn("Failed to post reminder", zap.Uint("reminder_id", reminder.ID), zap.Error(err))
+				run.Error = err.Error()
+			} else {
+				run.MessageID = &message.ID
+				posted = &message
+			}
+			if err := tx.Save(&run).Error; err != nil {
+				return err
+			}
+		}
+
+		// Missed runs are not replayed; the next run is the first one after now
+		updates := map[string]interface{}{"last_run_at": run.ScheduledFor}
+		next, err := NextReminderRun(reminder.Schedule, reminder.Timezone, now)
+		if err != nil {
+			r.logger.Warn("Disabling reminder with invalid schedule", zap.Uint("reminder_id", reminder.ID), zap.Error(err))
+			updates["enabled"] = false
+			updates["next_run_at"] = nil
+		} else {
+			updates["next_run_at"] = next
+		}
+		return tx.Model(&reminder).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posted, nil
+}
+
+// NextReminderRun returns when a cron schedule next fires after the given time,
+// evaluated in the named time zone
+func NextReminderRun(schedule, timezone string, after time.Time) (time.Time, error) {
+	parsed, err := cron.Parse(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	next := parsed.Next(after.In(loc))
+	if next.IsZero() {
+		return next, ErrScheduleNeverFires
+	}
+	return next.UTC(), nil
+}
+
+// nextReminderDue returns the earliest upcoming run of any enabled reminder
+func nextReminderDue() (time.Time, bool) {
+	var reminder models.Reminder
+	err := database.DB.Where("enabled = ? AND next_run_at IS NOT NULL", true).
+		Order("next_run_at").
+		First(&reminder).Error
+	if err != nil || reminder.NextRunAt == nil {
+		return time.Time{}, false
+	}
+	return *reminder.NextRunAt, true
+}
@@ -0,0 +1,261 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/storage"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RetentionResult describes what enforcing one group's retention policy matched and removed
+type RetentionResult struct {
+	GroupID     uint  `json:"group_id"`
+	MaxAgeDays  int   `json:"max_age_days"`
+	MaxMessages int   `json:"max_messages"`
+	Matched     int64 `json:"matched"`
+	Purged      int64 `json:"purged"`
+	DryRun      bool  `json:"dry_run"`
+}
+
+// RetentionMetrics holds counters about retention enforcement since startup
+type RetentionMetrics struct {
+	Runs              int64          `json:"runs"`
+	RowsPurged        int64          `json:"rows_purged"`
+	RowsPurgedByGroup map[uint]int64 `json:"rows_purged_by_group"`
+	LastRunAt         *time.Time     `json:"last_run_at,omitempty"`
+	LastRunDuration   string         `json:"last_run_duration,omitempty"`
+	LastError         string         `json:"last_error,omitempty"`
+}
+
+// RetentionEnforcer periodically removes messages that fall outside their group's retention policy
+type RetentionEnforcer struct {
+	interval   time.Duration
+	batchSize  int
+	batchPause time.Duration
+	logger     *zap.Logger
+
+	mu      sync.Mutex
+	running sync.Mutex
+	metrics RetentionMetrics
+}
+
+// NewRetentionEnforcer creates a new retention enforcer from the retention configuration
+func NewRetentionEnforcer(cfg config.RetentionConfig, logger *zap.Logger) *RetentionEnforcer {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	return &RetentionEnforcer{
+		interval:   time.Duration(cfg.IntervalMinutes) * time.Minute,
+		batchSize:  batchSize,
+		batchPause: time.Duration(cfg.BatchPauseMillis) * time.Millisecond,
+		logger:     logger,
+		metrics: RetentionMetrics{
+			RowsPurgedByGroup: make(map[uint]int64),
+		},
+	}
+}
+
+// Run enforces all retention policies on every interval until the context is cancelled
+func (r *RetentionEnforcer) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		r.logger.Info("Retention enforcement is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Enforce(ctx, false); err != nil {
+			r.logger.Error("Failed to enforce retention policies", zap.Error(err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Enforce applies every group's retention policy. With dryRun set it only reports
+// how many messages would be removed.
+func (r *RetentionEnforcer) Enforce(ctx context.Context, dryRun bool) ([]RetentionResult, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database connection not established")
+	}
+
+	// Only one enforcement pass at a time, whether scheduled or triggered by an admin
+	r.running.Lock()
+	defer r.running.Unlock()
+
+	start := time.Now()
+
+	var policies []models.RetentionPolicy
+	if err := database.DB.Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to load retention policies: %w", err)
+	}
+
+	results := make([]RetentionResult, 0, len(policies))
+	var runErr error
+	for _, policy := range policies {
+		result, err := r.enforcePolicy(ctx, policy, dryRun)
+		if err != nil {
+			runErr = fmt.Errorf("group %d: %w", policy.GroupID, err)
+			break
+		}
+		results = append(results, result)
+	}
+
+	if !dryRun {
+		r.record(results, start, runErr)
+	}
+
+	return results, runErr
+}
+
+// Metrics returns a snapshot of the retention counters
+func (r *RetentionEnforcer) Metrics() RetentionMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.metrics
+	snapshot.RowsPurgedByGroup = make(map[uint]int64, len(r.metrics.RowsPurgedByGroup))
+	for groupID, count := range r.metrics.RowsPurgedByGroup {
+		snapshot.RowsPurgedByGroup[groupID] = count
+	}
+	return snapshot
+}
+
+// enforcePolicy counts and, unless dryRun is set, deletes the messages outside a single policy
+func (r *RetentionEnforcer) enforcePolicy(ctx context.Context, policy models.RetentionPolicy, dryRun bool) (RetentionResult, error) {
+	result := RetentionResult{
+		GroupID:     policy.GroupID,
+		MaxAgeDays:  policy.MaxAgeDays,
+		MaxMessages: policy.MaxMessages,
+		DryRun:      dryRun,
+	}
+
+	scope, ok, err := expiredMessagesScope(policy)
+	if err != nil || !ok {
+		return result, err
+	}
+
+	if err := database.DB.Unscoped().Model(&models.Message{}).Scopes(scope).Count(&result.Matched).Error; err != nil {
+		return result, fmt.Errorf("failed to count expired messages: %w", err)
+	}
+
+	if dryRun || result.Matched == 0 {
+		return result, nil
+	}
+
+	result.Purged, err = r.deleteInBatches(ctx, scope)
+	if result.Purged > 0 {
+		r.logger.Info("Purged messages by retention policy",
+			zap.Uint("group_id", policy.GroupID),
+			zap.Int64("count", result.Purged),
+		)
+	}
+	return result, err
+}
+
+// deleteInBatches hard-deletes matching messages a batch at a time so that no single
+// statement holds row locks on a large part of the messages table
+func (r *RetentionEnforcer) deleteInBatches(ctx context.Context, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	for {
+		purged, keys, err := PurgeMessages(scope, r.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete expired messages: %w", err)
+		}
+		total += purged
+		if err := storage.DeleteAll(ctx, keys); err != nil {
+			r.logger.Warn("Failed to delete attachments of expired messages", zap.Error(err))
+		}
+
+		if purged < int64(r.batchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-time.After(r.batchPause):
+		case <-ctx.Done():
+			return total, ctx.Err()
+		}
+	}
+}
+
+// record updates the metrics after a non-dry-run enforcement pass
+func (r *RetentionEnforcer) record(results []RetentionResult, start time.Time, runErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics.Runs++
+	for _, result := range results {
+		r.metrics.RowsPurged += result.Purged
+		if result.Purged > 0 {
+			r.metrics.RowsPurgedByGroup[result.GroupID] += result.Purged
+		}
+	}
+
+	r.metrics.LastRunAt = &start
+	r.metrics.LastRunDuration = time.Since(start).String()
+	r.metrics.LastError = ""
+	if runErr != nil {
+		r.metrics.LastError = runErr.Error()
+	}
+}
+
+// expiredMessagesScope builds a query scope matching the group's messages that are older
+// than the policy's max age or beyond its max message count. It returns false when the
+// policy cannot match anything.
+func expiredMessagesScope(policy models.RetentionPolicy) (func(*gorm.DB) *gorm.DB, bool, error) {
+	var conditions []string
+	var args []interface{}
+
+	if policy.MaxAgeDays > 0 {
+		cutoff := time.Now().Add(-time.Duration(policy.MaxAgeDays) * 24 * time.Hour)
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, cutoff)
+	}
+
+	if policy.MaxMessages > 0 {
+		// Keep the newest MaxMessages live messages; everything with a lower ID goes
+		var oldestKept []uint
+		err := database.DB.Model(&models.Message{}).
+			Where("group_id = ?", policy.GroupID).
+			Order("id DESC").
+			Offset(policy.MaxMessages-1).
+			Limit(1).
+			Pluck("id", &oldestKept).Error
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to find message count cutoff: %w", err)
+		}
+		if len(oldestKept) > 0 {
+			conditions = append(conditions, "id < ?")
+			args = append(args, oldestKept[0])
+		}
+	}
+
+	if len(conditions) == 0 {
+		return nil, false, nil
+	}
+
+	where := conditions[0]
+	if len(conditions) == 2 {
+		where = fmt.Sprintf("(%s OR %s)", conditions[0], conditions[1])
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("group_id = ?", policy.GroupID).Where(where, args...)
+	}, true, nil
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/messages"
+	"sre-chat-api/internal/models"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scheduler posts scheduled messages once they are due. Due rows are claimed with
+// row locks that skip rows already claimed, so any number of replicas can run it.
+type Scheduler struct {
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	logger      *zap.Logger
+
+	mu      sync.RWMutex
+	deliver func(models.Message)
+}
+
+// NewScheduler creates a new scheduler from the scheduler configuration
+func NewScheduler(cfg config.SchedulerConfig, logger *zap.Logger) *Scheduler {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	return &Scheduler{
+		interval:    time.Duration(cfg.IntervalSeconds) * time.Second,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// OnDeliver sets the function that notifies clients about each posted message
+func (s *Scheduler) OnDeliver(deliver func(models.Message)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliver = deliver
+}
+
+// Run delivers due messages on every interval until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		s.logger.Info("Scheduled message delivery is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := s.DeliverDue(ctx); err != nil {
+			s.logger.Error("Failed to deliver scheduled messages", zap.Error(err))
+		} else if sent > 0 {
+			s.logger.Info("Delivered scheduled messages", zap.Int("count", sent))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// DeliverDue posts every pending message whose send time has passed and returns
+// the number posted
+func (s *Scheduler) DeliverDue(ctx context.Context) (int, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	start := time.Now()
+	total := 0
+	for {
+		messages, claimed, err := s.deliverBatch(ctx, start)
+		if err != nil {
+			return total, err
+		}
+		total += len(messages)
+
+		s.mu.RLock()
+		deliver := s.deliver
+		s.mu.RUnlock()
+		if deliver != nil {
+			for _, message := range messages {
+				deliver(message)
+			}
+		}
+
+		if claimed < s.batchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// deliverBatch claims one batch of due messages and posts them in a single
+// transaction. Messages that already failed during this run are left for the next
+// one. It returns the posted messages and how many rows were claimed.
+func (s *Scheduler) deliverBatch(ctx context.Context, runStart time.Time) ([]models.Message, int, error) {
+	var posted []models.Message
+	var claimed int
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ? AND send_at <= ?", models.ScheduledPending, time.Now()).
+			Where("(attempts = 0 OR updated_at < ?)", runStart).
+			Order("send_at, id").
+			Limit(s.batchSize)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var due []models.ScheduledMessage
+		if err := query.Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to claim scheduled messages: %w", err)
+		}
+		claimed = len(due)
+
+		for _, scheduled := range due {
+			message, err := s.post(tx, scheduled)
+			if err != nil {
+				if err := s.recordFailure(tx, scheduled, err); err != nil {
+					return err
+				}
+				continue
+			}
+			posted = append(posted, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posted, claimed, nil
+}
+
+// post creates the message for a scheduled message and marks it sent. A savepoint
+// keeps a failure from aborting the rest of the batch.
+func (s *Scheduler) post(tx *gorm.DB, scheduled models.ScheduledMessage) (models.Message, error) {
+	message := models.Message{
+		GroupID: scheduled.GroupID,
+		User:    scheduled.User,
+		Content: scheduled.Content,
+	}
+
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := messages.Create(tx, &message); err != nil {
+			return err
+		}
+		return tx.Model(&scheduled).Updates(map[string]interface{}{
+			"status":     models.ScheduledSent,
+			"message_id": message.ID,
+			"attempts":   scheduled.Attempts + 1,
+			"last_error": "",
+		}).Error
+	})
+	return message, err
+}
+
+// recordFailure counts a failed delivery attempt, giving up after the configured maximum
+func (s *Scheduler) recordFailure(tx *gorm.DB, scheduled models.ScheduledMessage, cause error) error {
+	attempts := scheduled.Attempts + 1
+	status := models.ScheduledPending
+	if attempts >= s.maxAttempts {
+		status = models.ScheduledFailed
+	}
+
+	s.logger.Warn("Failed to deliver scheduled message",
+		zap.Uint("id", scheduled.ID),
+		zap.Int("attempts", attempts),
+		zap.Error(cause),
+	)
+
+	err := tx.Model(&scheduled).Updates(map[string]interface{}{
+		"status":     status,
+		"attempts":   attempts,
+		"last_error": cause.Error(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record delivery failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/unfurl"
+	"sre-chat-api/internal/webhooks"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxWebhookResponse is how much of a receiver's response body is kept in the delivery log
+const maxWebhookResponse = 1024
+
+// WebhookDispatcher delivers the webhook outbox. Due deliveries are claimed with row
+// locks that skip rows already claimed and hidden from other replicas while their
+// requests are in flight, so any number of replicas can run it.
+type WebhookDispatcher struct {
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	timeout     time.Duration
+	backoff     time.Duration
+	backoffMax  time.Duration
+	client      *http.Client
+	logger      *zap.Logger
+}
+
+// NewWebhookDispatcher creates a new dispatcher from the webhooks configuration
+func NewWebhookDispatcher(cfg config.WebhooksConfig, logger *zap.Logger) *WebhookDispatcher {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	backoffMax := time.Duration(cfg.BackoffMaxSeconds) * time.Second
+	if backoffMax <= 0 {
+		backoffMax = time.Hour
+	}
+
+	return &WebhookDispatcher{
+		interval:    time.Duration(cfg.IntervalSeconds) * time.Second,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		timeout:     timeout,
+		backoff:     time.Duration(cfg.BackoffSeconds) * time.Second,
+		backoffMax:  backoffMax,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: webhookTransport(timeout, cfg.AllowPrivate),
+			// A redirect is reported as a failed delivery rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+}
+
+// webhookTransport dials receivers with the same address check as link unfurling,
+// so webhooks cannot reach loopback, private or link-local addresses unless
+// allowPrivate is set
+func webhookTransport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = unfurl.SafeControl
+	}
+
+	return &http.Transport{
+		// Never use an environment proxy: the address check must see the real target
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+}
+
+// Run delivers due webhooks on every interval until the context is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	if d.interval <= 0 {
+		d.logger.Info("Webhook delivery is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if delivered, err := d.DispatchDue(ctx); err != nil {
+			d.logger.Error("Failed to deliver webhooks", zap.Error(err))
+		} else if delivered > 0 {
+			d.logger.Info("Delivered webhooks", zap.Int("count", delivered))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// DispatchDue attempts every pending delivery whose next attempt is due and returns
+// the number delivered successfully
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	if database.DB == nil {
+		return 0, fmt.Errorf("database connection not established")
+	}
+
+	total := 0
+	for {
+		deliveries, lease, err := d.claim(ctx)
+		if err != nil {
+			return total, err
+		}
+
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				return total, nil
+			}
+			// Never start a request that could outlast the claim; the rest of the
+			// batch is picked up again once the claim expires
+			if time.Until(lease) < d.timeout {
+				return total, nil
+			}
+			ok, err := d.attempt(ctx, delivery)
+			if err != nil {
+				return total, err
+			}
+			if ok {
+				total++
+			}
+		}
+
+		if len(deliveries) < d.batchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// claim locks a batch of due deliveries and pushes their next attempt past the time
+// needed to send them all one after another, so other replicas skip them while they
+// are being sent. It returns the deliveries and when the claim expires. A replica
+// that crashes mid-delivery leaves them to be retried once that time has passed.
+func (d *WebhookDispatcher) claim(ctx context.Context) ([]models.WebhookDelivery, time.Time, error) {
+	var due []models.WebhookDelivery
+	var lease time.Time
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+			Order("next_attempt_at, id").
+			Limit(d.batchSize)
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		if err := query.Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(due))
+		for i, delivery := range due {
+			ids[i] = delivery.ID
+		}
+		// One request timeout per delivery plus one to record the last outcome
+		lease = now.Add(time.Duration(len(due)+1) * d.timeout)
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", lease).Error
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return due, lease, nil
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry with
+// exponential backoff or dead-lettering it after the configured maximum. It reports
+// whether the receiver accepted the payload.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery) (bool, error) {
+	// Skip the delivery if it is no longer pending under this claim
+	var claimed int64
+	if err := database.DB.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at > ?", delivery.ID, models.WebhookPending, time.Now()).
+		Count(&claimed).Error; err != nil {
+		return false, fmt.Errorf("failed to check webhook delivery %d: %w", delivery.ID, err)
+	}
+	if claimed == 0 {
+		return false, nil
+	}
+
+	var hook models.Webhook
+	if err := database.DB.WithContext(ctx).First(&hook, delivery.WebhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The webhook was deleted and its deliveries with it
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load webhook %d: %w", delivery.WebhookID, err)
+	}
+
+	started := time.Now()
+	statusCode, response, sendErr := d.send(ctx, hook, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// Shutting down; the claim expires and the delivery is retried without
+		// counting this attempt
+		return false, nil
+	}
+	record := models.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		StatusCode:  statusCode,
+		Response:    response,
+		DurationMs:  time.Since(started).Milliseconds(),
+		AttemptedAt: started,
+	}
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+	}
+	if sendErr == nil {
+		now := time.Now()
+		updates["status"] = models.WebhookDelivered
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+	} else {
+		record.Error = sendErr.Error()
+		updates["last_error"] = sendErr.Error()
+		if attempts >= d.maxAttempts {
+			updates["status"] = models.WebhookDead
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(webhooks.Backoff(attempts, d.backoff, d.backoffMax))
+		}
+
+		d.logger.Warn("Failed to deliver webhook",
+			zap.Uint("delivery_id", delivery.ID),
+			zap.Uint("webhook_id", hook.ID),
+			zap.Int("attempts", attempts),
+			zap.Error(sendErr),
+		)
+	}
+
+	// Record the outcome even if shutdown starts now, so a delivered payload is not sent again
+	err := database.DB.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(&delivery).Updates(updates).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook delivery %d: %w", delivery.ID, err)
+	}
+
+	return sendErr == nil, nil
+}
+
+// send POSTs the signed payload and returns the response status and the start of
+// its body. Any status outside 2xx is an error.
+func (d *WebhookDispatcher) send(ctx context.Context, hook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sre-chat-webhooks/1.0")
+	req.Header.Set(webhooks.HeaderEvent, delivery.Event)
+	req.Header.Set(webhooks.HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(webhooks.HeaderTimestamp, timestamp)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	// Keep the excerpt storable as text whatever the receiver sent
+	response := strings.ReplaceAll(strings.ToValidUTF8(string(raw), "\uFFFD"), "\x00", "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, response, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, response, nil
+}
@@ -0,0 +1,42 @@
+// Package messages stores new messages for every API and background job that posts
+// one, so the checks and side effects of posting cannot be skipped by any of them.
+package messages
+
+import (
+	"errors"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/webhooks"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrReservedName is returned when a message that is not a bot's is posted under a
+// bot's name. Bots post through the bot API so that their messages are flagged.
+var ErrReservedName = errors.New("name belongs to a bot")
+
+// Create stores a new message and queues its message.created webhooks in the given
+// transaction. Callers deliver the message to clients once the transaction commits.
+func Create(tx *gorm.DB, message *models.Message) error {
+	if !message.Bot {
+		reserved, err := IsBotName(tx, message.User)
+		if err != nil {
+			return err
+		}
+		if reserved {
+			return ErrReservedName
+		}
+	}
+
+	if err := tx.Create(message).Error; err != nil {
+		return err
+	}
+	return webhooks.Enqueue(tx, webhooks.EventMessageCreated, *message)
+}
+
+// IsBotName reports whether a user name belongs to a bot, ignoring case
+func IsBotName(db *gorm.DB, name string) (bool, error) {
+	var count int64
+	err := db.Model(&models.Bot{}).Where("LOWER(name) = LOWER(?)", strings.TrimSpace(name)).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth creates a middleware that requires the admin bearer token.
+// When no token is configured the admin endpoints are refused, unless open is set
+// to leave them open explicitly.
+func AdminAuth(token string, open bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			if !open {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled; set ADMIN_TOKEN to enable them"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
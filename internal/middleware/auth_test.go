@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(token string, open bool, header string) int {
+		router := gin.New()
+		router.GET("/admin", AdminAuth(token, open), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Without a token the admin endpoints are refused unless explicitly open
+	assert.Equal(t, http.StatusForbidden, request("", false, ""))
+	assert.Equal(t, http.StatusForbidden, request("", false, "Bearer "))
+	assert.Equal(t, http.StatusOK, request("", true, ""))
+
+	assert.Equal(t, http.StatusOK, request("secret", false, "Bearer secret"))
+	assert.Equal(t, http.StatusUnauthorized, request("secret", false, "Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, request("secret", true, ""))
+}
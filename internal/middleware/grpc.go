@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"sre-chat-api/internal/ratelimit"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCUnaryLogger logs gRPC calls like LoggerMiddleware logs HTTP requests
+func GRPCUnaryLogger(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logGRPC(logger, ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// GRPCStreamLogger logs gRPC streams when they end
+func GRPCStreamLogger(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		logGRPC(logger, stream.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logGRPC(logger *zap.Logger, ctx context.Context, method string, start time.Time, err error) {
+	logger.Info("gRPC Request",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("latency", time.Since(start)),
+		zap.String("ip", peerIP(ctx)),
+	)
+}
+
+// GRPCUnaryRecovery turns panics in gRPC calls into Internal errors, like
+// RecoveryMiddleware does for HTTP requests
+func GRPCUnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic recovered", zap.Any("error", r), zap.String("method", info.FullMethod))
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// GRPCStreamRecovery turns panics in gRPC streams into Internal errors
+func GRPCStreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic recovered", zap.Any("error", r), zap.String("method", info.FullMethod))
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+// GRPCRateLimit limits calls of the given methods per client IP like RateLimit,
+// failing them with ResourceExhausted and a retry-after header in seconds
+func GRPCRateLimit(limiter *ratelimit.Limiter, methods ...string) grpc.UnaryServerInterceptor {
+	limited := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		limited[method] = true
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if limited[info.FullMethod] {
+			if allowed, wait := limiter.Allow(peerIP(ctx)); !allowed {
+				grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+				return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP returns the IP address of a gRPC caller
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sre-chat-api/internal/ratelimit"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit creates a middleware that rejects requests once the caller identified
+// by key has used up its allowance, telling it when to retry
+func RateLimit(limiter *ratelimit.Limiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, wait := limiter.Allow(key(c))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
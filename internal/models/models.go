@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,14 +19,508 @@ type Group struct {
 
 // Message represents a chat message
 type Message struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	GroupID   uint           `json:"group_id" gorm:"not null;index"`
-	User      string         `json:"user" gorm:"not null"`
-	Content   string         `json:"content" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	Group     Group          `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	GroupID      uint              `json:"group_id" gorm:"not null;index"`
+	User         string            `json:"user" gorm:"not null"`
+	Content      string            `json:"content" gorm:"not null"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"-" gorm:"index"`
+	Bot          bool              `json:"bot" gorm:"not null;default:false"`
+	System       bool              `json:"system" gorm:"not null;default:false"`
+	Group        Group             `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	Reactions    []ReactionSummary `json:"reactions,omitempty" gorm:"-"`
+	Attachments  []Attachment      `json:"attachments,omitempty" gorm:"-"`
+	LinkPreviews []LinkPreview     `json:"link_previews,omitempty" gorm:"-"`
+	Poll         *PollResults      `json:"poll,omitempty" gorm:"-"`
+}
+
+// Reaction represents a user's emoji reaction to a message
+type Reaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MessageID uint      `json:"message_id" gorm:"not null;uniqueIndex:idx_reactions_message_user_emoji"`
+	User      string    `json:"user" gorm:"not null;uniqueIndex:idx_reactions_message_user_emoji"`
+	Emoji     string    `json:"emoji" gorm:"not null;uniqueIndex:idx_reactions_message_user_emoji"`
+	CreatedAt time.Time `json:"created_at"`
+	Message   Message   `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Attachment represents a file uploaded to a message
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	MessageID    uint      `json:"message_id" gorm:"not null;index"`
+	Filename     string    `json:"filename" gorm:"not null"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	StorageKey   string    `json:"-" gorm:"not null"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url" gorm:"-"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" gorm:"-"`
+	Message      Message   `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// LinkPreview caches the unfurled metadata of a URL posted in a message
+type LinkPreview struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	URL         string    `json:"url" gorm:"uniqueIndex;not null"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Error       string    `json:"-"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Mention kinds
+const (
+	MentionUser   = "user"
+	MentionHere   = "here"
+	MentionGroup  = "group"
+	MentionOncall = "oncall"
+)
+
+// Mention records that a user was mentioned in a message
+type Mention struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	MessageID uint       `json:"message_id" gorm:"not null;uniqueIndex:idx_mentions_message_user"`
+	User      string     `json:"user" gorm:"not null;uniqueIndex:idx_mentions_message_user;index"`
+	Kind      string     `json:"kind" gorm:"not null"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	Message   *Message   `json:"message,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Pin records a message pinned to the top of its group
+type Pin struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;index"`
+	MessageID uint      `json:"message_id" gorm:"not null;uniqueIndex"`
+	PinnedBy  string    `json:"pinned_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	Message   *Message  `json:"message,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Group     Group     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Scheduled message statuses
+const (
+	ScheduledPending   = "pending"
+	ScheduledSent      = "sent"
+	ScheduledCancelled = "cancelled"
+	ScheduledFailed    = "failed"
+)
+
+// ScheduledMessage is a message waiting to be posted at SendAt
+type ScheduledMessage struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;index"`
+	User      string    `json:"user" gorm:"not null"`
+	Content   string    `json:"content" gorm:"not null"`
+	SendAt    time.Time `json:"send_at" gorm:"not null;index:idx_scheduled_messages_due,priority:2"`
+	Status    string    `json:"status" gorm:"not null;default:pending;index:idx_scheduled_messages_due,priority:1"`
+	MessageID *uint     `json:"message_id,omitempty"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Group     Group     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Reminder posts a message to a group on a recurring cron schedule
+type Reminder struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	GroupID   uint       `json:"group_id" gorm:"not null;index"`
+	Name      string     `json:"name" gorm:"not null"`
+	Schedule  string     `json:"schedule" gorm:"not null"`
+	Timezone  string     `json:"timezone" gorm:"not null;default:UTC"`
+	User      string     `json:"user" gorm:"not null"`
+	Content   string     `json:"content" gorm:"not null"`
+	Enabled   bool       `json:"enabled" gorm:"not null"`
+	NextRunAt *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Group     Group      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ReminderRun records one firing of a reminder
+type ReminderRun struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ReminderID   uint      `json:"reminder_id" gorm:"not null;uniqueIndex:idx_reminder_runs_reminder_scheduled"`
+	ScheduledFor time.Time `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_reminder_runs_reminder_scheduled"`
+	FiredAt      time.Time `json:"fired_at" gorm:"not null"`
+	MessageID    *uint     `json:"message_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Reminder     Reminder  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// LeaderLease records which replica currently leads a singleton job
+type LeaderLease struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Holder    string    `json:"holder" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+}
+
+// Poll turns a message into a question with options to vote on; the message
+// content is the question
+type Poll struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	MessageID      uint         `json:"message_id" gorm:"not null;uniqueIndex"`
+	MultipleChoice bool         `json:"multiple_choice" gorm:"not null"`
+	Anonymous      bool         `json:"anonymous" gorm:"not null"`
+	ClosesAt       *time.Time   `json:"closes_at"`
+	ClosedAt       *time.Time   `json:"closed_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Options        []PollOption `json:"options" gorm:"constraint:OnDelete:CASCADE"`
+	Message        Message      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Closed reports whether the poll no longer accepts votes at the given time
+func (p Poll) Closed(now time.Time) bool {
+	return p.ClosedAt != nil || (p.ClosesAt != nil && !now.Before(*p.ClosesAt))
+}
+
+// PollOption is one answer of a poll
+type PollOption struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	PollID   uint   `json:"poll_id" gorm:"not null;index"`
+	Position int    `json:"position" gorm:"not null"`
+	Text     string `json:"text" gorm:"not null"`
+}
+
+// PollVote records a user's vote for one option of a poll
+type PollVote struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	PollID    uint       `json:"poll_id" gorm:"not null;index"`
+	OptionID  uint       `json:"option_id" gorm:"not null;uniqueIndex:idx_poll_votes_option_user"`
+	User      string     `json:"user" gorm:"not null;uniqueIndex:idx_poll_votes_option_user"`
+	CreatedAt time.Time  `json:"created_at"`
+	Poll      Poll       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Option    PollOption `json:"-" gorm:"foreignKey:OptionID;constraint:OnDelete:CASCADE"`
+}
+
+// PollResults is a poll with its current tally, as embedded in the message JSON
+type PollResults struct {
+	ID             uint                `json:"id"`
+	MultipleChoice bool                `json:"multiple_choice"`
+	Anonymous      bool                `json:"anonymous"`
+	ClosesAt       *time.Time          `json:"closes_at,omitempty"`
+	Closed         bool                `json:"closed"`
+	TotalVoters    int                 `json:"total_voters"`
+	Options        []PollOptionResults `json:"options"`
+}
+
+// PollOptionResults is the tally of one poll option. Voters are omitted for anonymous polls.
+type PollOptionResults struct {
+	ID     uint     `json:"id"`
+	Text   string   `json:"text"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+// Webhook subscribes an HTTP endpoint to message events in a group. The secret
+// signs every payload and is only returned when the webhook is created.
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;index"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"secret,omitempty" gorm:"not null"`
+	Events    []string  `json:"events" gorm:"serializer:json;type:text;not null"`
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Group     Group     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebhookDelivery is an outbox entry holding one event payload for one webhook
+// until it is delivered or dead-lettered
+type WebhookDelivery struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	WebhookID      uint             `json:"webhook_id" gorm:"not null;index"`
+	Event          string           `json:"event" gorm:"not null"`
+	Payload        json.RawMessage  `json:"payload" gorm:"serializer:json;type:text;not null"`
+	Status         string           `json:"status" gorm:"not null;default:pending;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  time.Time        `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int              `json:"last_status_code,omitempty"`
+	LastError      string           `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Log            []WebhookAttempt `json:"log,omitempty" gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE"`
+	Webhook        Webhook          `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebhookAttempt records the outcome of one attempt to deliver a webhook payload
+type WebhookAttempt struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID  uint      `json:"delivery_id" gorm:"not null;index"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Response    string    `json:"response,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at" gorm:"not null"`
+}
+
+// IncomingWebhook lets scripts and CI post to a group through a secret URL. Only a
+// hash of the token is stored; the URL is returned once, when the webhook is created.
+type IncomingWebhook struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	GroupID    uint       `json:"group_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	URL        string     `json:"url,omitempty" gorm:"-"`
+	Group      Group      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Alert statuses
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert tracks the chat message of one Alertmanager alert group. Notifications with
+// the same group key edit that message until the group resolves; a group firing
+// again afterwards gets a new message.
+type Alert struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	GroupKey       string          `json:"group_key" gorm:"not null;uniqueIndex"`
+	GroupID        uint            `json:"group_id" gorm:"not null;index"`
+	MessageID      uint            `json:"message_id" gorm:"not null"`
+	Title          string          `json:"title" gorm:"not null"`
+	Status         string          `json:"status" gorm:"not null;index"`
+	Receiver       string          `json:"receiver"`
+	Notification   json.RawMessage `json:"-" gorm:"serializer:json;type:text;not null"`
+	AcknowledgedBy string          `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Group          Group           `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Incident severities, most severe first
+const (
+	SeverityOne   = "SEV1"
+	SeverityTwo   = "SEV2"
+	SeverityThree = "SEV3"
+	SeverityFour  = "SEV4"
+)
+
+// Incident statuses, in the order an incident usually moves through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident is an incident run from its own dedicated group
+type Incident struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	GroupID    uint       `json:"group_id" gorm:"not null;uniqueIndex"`
+	Title      string     `json:"title" gorm:"not null"`
+	Severity   string     `json:"severity" gorm:"not null"`
+	Status     string     `json:"status" gorm:"not null;index"`
+	Commander  string     `json:"commander" gorm:"not null"`
+	DeclaredBy string     `json:"declared_by" gorm:"not null"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Group      Group      `json:"group" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Incident timeline entry kinds
+const (
+	TimelineSystem = "system"
+	TimelinePinned = "pinned"
+	TimelineTagged = "tagged"
+)
+
+// TimelineEntry is one message of an incident's timeline
+type TimelineEntry struct {
+	At        time.Time `json:"at"`
+	MessageID uint      `json:"message_id"`
+	User      string    `json:"user"`
+	Kind      string    `json:"kind"`
+	Content   string    `json:"content"`
+}
+
+// IncidentTimeline is the postmortem export of an incident
+type IncidentTimeline struct {
+	Incident Incident        `json:"incident"`
+	Entries  []TimelineEntry `json:"entries"`
+}
+
+// OncallSchedule is a rotation of participants taking turns on call. Shifts last
+// ShiftDays days and hand off at HandoffTime in Timezone, starting on StartDate
+// with the first participant.
+type OncallSchedule struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"uniqueIndex;not null"`
+	GroupID       uint       `json:"group_id" gorm:"not null;index"`
+	Timezone      string     `json:"timezone" gorm:"not null"`
+	Participants  []string   `json:"participants" gorm:"serializer:json;type:text;not null"`
+	ShiftDays     int        `json:"shift_days" gorm:"not null"`
+	HandoffTime   string     `json:"handoff_time" gorm:"not null"`
+	StartDate     string     `json:"start_date" gorm:"not null"`
+	LastOncall    string     `json:"-"`
+	LastHandoffAt *time.Time `json:"last_handoff_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Group         Group      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// OncallOverride puts someone on call in place of the rotation for a while. The
+// most recently created override wins where overrides overlap.
+type OncallOverride struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	ScheduleID uint            `json:"schedule_id" gorm:"not null;index"`
+	User       string          `json:"user" gorm:"not null"`
+	StartsAt   time.Time       `json:"starts_at" gorm:"not null"`
+	EndsAt     time.Time       `json:"ends_at" gorm:"not null"`
+	CreatedBy  string          `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Schedule   *OncallSchedule `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// OncallShift is a stretch of time one user is on call
+type OncallShift struct {
+	User     string    `json:"user"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Override bool      `json:"override"`
+}
+
+// OncallNow reports who is on call for a schedule and who is next
+type OncallNow struct {
+	Schedule string      `json:"schedule"`
+	Timezone string      `json:"timezone"`
+	Current  OncallShift `json:"current"`
+	Next     OncallShift `json:"next"`
+}
+
+// Bot token scopes
+const (
+	BotScopeMessagesRead  = "messages:read"
+	BotScopeMessagesWrite = "messages:write"
+	BotScopeEvents        = "events:read"
+	BotScopeGroups        = "groups:join"
+)
+
+// Bot is an automation account. Bots authenticate with tokens, post messages
+// flagged as bot-authored, and only see the groups they have joined.
+type Bot struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description"`
+	Owner       string    `json:"owner"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BotToken is a credential of a bot limited to a set of scopes. Only a hash of the
+// token is stored; Token is filled in once, when the token is created.
+type BotToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	BotID      uint       `json:"bot_id" gorm:"not null;index"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:text;not null"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Token      string     `json:"token,omitempty" gorm:"-"`
+	Bot        Bot        `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BotMembership records that a bot joined a group
+type BotMembership struct {
+	BotID    uint      `json:"bot_id" gorm:"primaryKey"`
+	GroupID  uint      `json:"group_id" gorm:"primaryKey;index"`
+	JoinedAt time.Time `json:"joined_at" gorm:"autoCreateTime"`
+	Bot      Bot       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Group    Group     `json:"group" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ReadPosition records the last message a user has read in a group
+type ReadPosition struct {
+	ID                uint      `json:"-" gorm:"primaryKey"`
+	User              string    `json:"user" gorm:"not null;uniqueIndex:idx_read_positions_user_group"`
+	GroupID           uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_read_positions_user_group"`
+	LastReadMessageID uint      `json:"last_read_message_id" gorm:"not null"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	Group             Group     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// GroupSummary is a group as listed for a user, with their read position
+type GroupSummary struct {
+	Group
+	LastReadMessageID uint  `json:"last_read_message_id"`
+	UnreadCount       int64 `json:"unread_count"`
+}
+
+// Presence statuses
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// Presence is a user's current availability
+type Presence struct {
+	User     string     `json:"user"`
+	Status   string     `json:"status"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// TypingEvent announces that a user is typing in a group
+type TypingEvent struct {
+	User      string    `json:"user"`
+	GroupID   uint      `json:"group_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReactionSummary aggregates the reactions with the same emoji on a message
+type ReactionSummary struct {
+	Emoji string   `json:"emoji"`
+	Count int      `json:"count"`
+	Users []string `json:"users"`
+}
+
+// RetentionPolicy limits how long and how many messages a group keeps
+type RetentionPolicy struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	GroupID     uint      `json:"group_id" gorm:"uniqueIndex;not null"`
+	MaxAgeDays  int       `json:"max_age_days"`
+	MaxMessages int       `json:"max_messages"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CommandResponse is the reply to a slash command, shown only to the caller
+type CommandResponse struct {
+	Command   string `json:"command"`
+	GroupID   uint   `json:"group_id"`
+	Text      string `json:"text"`
+	Ephemeral bool   `json:"ephemeral"`
+}
+
+// SearchResult represents a message matched by full-text search
+type SearchResult struct {
+	Message Message `json:"message"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
 }
 
 // CreateMessageRequest represents the request body for creating a message
@@ -33,9 +528,149 @@ type CreateMessageRequest struct {
 	User    string `json:"user" binding:"required"`
 	Content string `json:"content" binding:"required"`
 	GroupID uint   `json:"group_id"`
+	// SendAt schedules the message instead of posting it now when in the future
+	SendAt *time.Time `json:"send_at"`
 }
 
 // UpdateMessageRequest represents the request body for updating a message
 type UpdateMessageRequest struct {
 	Content string `json:"content" binding:"required"`
 }
+
+// RetentionPolicyRequest represents the request body for setting a group's retention policy
+type RetentionPolicyRequest struct {
+	MaxAgeDays  int `json:"max_age_days" binding:"min=0"`
+	MaxMessages int `json:"max_messages" binding:"min=0"`
+}
+
+// ReadPositionRequest represents the request body for advancing a read position
+type ReadPositionRequest struct {
+	MessageID uint `json:"message_id" binding:"required"`
+}
+
+// PresenceRequest represents the request body for setting the caller's presence
+type PresenceRequest struct {
+	Status string `json:"status" binding:"required,oneof=online away"`
+}
+
+// ReminderRequest represents the request body for creating or updating a reminder
+type ReminderRequest struct {
+	Name     string `json:"name" binding:"required,max=255"`
+	Schedule string `json:"schedule" binding:"required"`
+	Timezone string `json:"timezone"`
+	User     string `json:"user" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+// CreatePollRequest represents the request body for posting a poll
+type CreatePollRequest struct {
+	User           string     `json:"user" binding:"required"`
+	GroupID        uint       `json:"group_id"`
+	Question       string     `json:"question" binding:"required"`
+	Options        []string   `json:"options" binding:"required,min=2,max=10,dive,required,max=200"`
+	MultipleChoice bool       `json:"multiple_choice"`
+	Anonymous      bool       `json:"anonymous"`
+	ClosesAt       *time.Time `json:"closes_at"`
+}
+
+// PollVoteRequest represents the request body for voting on a poll
+type PollVoteRequest struct {
+	OptionIDs []uint `json:"option_ids" binding:"required,min=1"`
+}
+
+// CreateWebhookRequest represents the request body for subscribing a webhook to a group.
+// Events default to every message event and a secret is generated when omitted.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,max=2048"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret" binding:"max=255"`
+}
+
+// UpdateWebhookRequest represents the request body for changing a webhook
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url" binding:"omitempty,max=2048"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// CreateIncomingWebhookRequest represents the request body for creating an incoming
+// webhook; the name is the default user its messages are posted as
+type CreateIncomingWebhookRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// IncomingWebhookPayload represents the JSON posted to an incoming webhook URL
+type IncomingWebhookPayload struct {
+	Text        string               `json:"text" binding:"required"`
+	Username    string               `json:"username" binding:"max=255"`
+	Attachments []IncomingAttachment `json:"attachments" binding:"max=10,dive"`
+}
+
+// IncomingAttachment is a file posted inline with an incoming webhook payload
+type IncomingAttachment struct {
+	Filename string `json:"filename" binding:"required,max=255"`
+	// Data is the base64-encoded file content
+	Data string `json:"data" binding:"required"`
+}
+
+// DeclareIncidentRequest represents the request body for declaring an incident
+type DeclareIncidentRequest struct {
+	Title    string `json:"title" binding:"required,max=200"`
+	Severity string `json:"severity"`
+	// Commander defaults to the caller
+	Commander string `json:"commander" binding:"max=255"`
+}
+
+// UpdateIncidentRequest represents the request body for updating an incident;
+// omitted fields are left unchanged
+type UpdateIncidentRequest struct {
+	Status    *string `json:"status"`
+	Severity  *string `json:"severity"`
+	Commander *string `json:"commander" binding:"omitempty,max=255"`
+}
+
+// OncallScheduleRequest represents the request body for creating or replacing an
+// on-call schedule
+type OncallScheduleRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	GroupID      uint     `json:"group_id" binding:"required"`
+	Timezone     string   `json:"timezone"`
+	Participants []string `json:"participants" binding:"required,min=1,dive,required,max=255"`
+	// ShiftDays defaults to 7 and HandoffTime to "09:00"
+	ShiftDays   int    `json:"shift_days" binding:"omitempty,min=1,max=365"`
+	HandoffTime string `json:"handoff_time"`
+	StartDate   string `json:"start_date" binding:"required"`
+}
+
+// OncallOverrideRequest represents the request body for adding an on-call override
+type OncallOverrideRequest struct {
+	User     string    `json:"user" binding:"required,max=255"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+}
+
+// CreateBotRequest represents the request body for creating a bot
+type CreateBotRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description" binding:"max=1000"`
+	Owner       string `json:"owner" binding:"max=255"`
+}
+
+// CreateBotTokenRequest represents the request body for issuing a bot token
+type CreateBotTokenRequest struct {
+	Name   string   `json:"name" binding:"max=255"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// BotMessageRequest represents the request body for posting as a bot
+type BotMessageRequest struct {
+	GroupID uint   `json:"group_id" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// ReactionRequest represents the request body for adding or removing a reaction
+type ReactionRequest struct {
+	User  string `json:"user" binding:"required"`
+	Emoji string `json:"emoji" binding:"required,max=64"`
+}
@@ -0,0 +1,188 @@
+// Package oncall works out who is on call for a schedule at a given time, from
+// its rotation and overrides.
+package oncall
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sre-chat-api/internal/models"
+	"time"
+)
+
+// MentionPrefix followed by a schedule name mentions whoever is on call for that
+// schedule; "@oncall" alone mentions everyone on call for the group's schedules
+const MentionPrefix = "oncall-"
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+)
+
+// namePattern limits schedule names to what fits in URLs and @oncall- mentions
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var (
+	// ErrInvalidName is returned for a schedule name that is not a lowercase slug
+	ErrInvalidName = errors.New("name must be lowercase letters, digits, dashes and underscores")
+	// ErrNoParticipants is returned for a rotation without anybody in it
+	ErrNoParticipants = errors.New("rotation has no participants")
+	// ErrInvalidOverride is returned for an override that does not end after it starts
+	ErrInvalidOverride = errors.New("override must end after it starts")
+)
+
+// rotation is a schedule with its fields parsed
+type rotation struct {
+	participants []string
+	shiftDays    int
+	location     *time.Location
+	// first is the start of the first shift
+	first time.Time
+}
+
+// Validate checks that a schedule's name, time zone, handoff time and start date parse
+func Validate(schedule models.OncallSchedule) error {
+	if !namePattern.MatchString(schedule.Name) {
+		return ErrInvalidName
+	}
+	_, err := parse(schedule)
+	return err
+}
+
+// ValidateOverride checks that an override covers a positive span of time
+func ValidateOverride(override models.OncallOverride) error {
+	if !override.EndsAt.After(override.StartsAt) {
+		return ErrInvalidOverride
+	}
+	return nil
+}
+
+func parse(schedule models.OncallSchedule) (rotation, error) {
+	if len(schedule.Participants) == 0 {
+		return rotation{}, ErrNoParticipants
+	}
+	if schedule.ShiftDays < 1 {
+		return rotation{}, fmt.Errorf("shift_days must be at least 1")
+	}
+
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return rotation{}, fmt.Errorf("invalid timezone: %w", err)
+	}
+	handoff, err := time.Parse(timeLayout, schedule.HandoffTime)
+	if err != nil {
+		return rotation{}, fmt.Errorf("invalid handoff_time, expected HH:MM: %w", err)
+	}
+	date, err := time.Parse(dateLayout, schedule.StartDate)
+	if err != nil {
+		return rotation{}, fmt.Errorf("invalid start_date, expected YYYY-MM-DD: %w", err)
+	}
+
+	return rotation{
+		participants: schedule.Participants,
+		shiftDays:    schedule.ShiftDays,
+		location:     loc,
+		first:        time.Date(date.Year(), date.Month(), date.Day(), handoff.Hour(), handoff.Minute(), 0, 0, loc),
+	}, nil
+}
+
+// shift returns the rotation shift covering at. Shift boundaries are counted in
+// calendar days of the schedule's time zone, so handoffs keep their local time
+// across daylight saving changes. Times before the first shift extrapolate the
+// rotation backwards.
+func (r rotation) shift(at time.Time) models.OncallShift {
+	local := at.In(r.location)
+	days := civilDays(r.first, local)
+	handoff := time.Date(local.Year(), local.Month(), local.Day(), r.first.Hour(), r.first.Minute(), 0, 0, r.location)
+	if local.Before(handoff) {
+		days--
+	}
+
+	n := floorDiv(days, r.shiftDays)
+	return models.OncallShift{
+		User:  r.participants[mod(n, len(r.participants))],
+		Start: r.first.AddDate(0, 0, n*r.shiftDays),
+		End:   r.first.AddDate(0, 0, (n+1)*r.shiftDays),
+	}
+}
+
+// At returns the shift covering at: the most recently created override in effect,
+// or else the rotation's shift. The shift ends early where an override that takes
+// precedence begins.
+func At(schedule models.OncallSchedule, overrides []models.OncallOverride, at time.Time) (models.OncallShift, error) {
+	r, err := parse(schedule)
+	if err != nil {
+		return models.OncallShift{}, err
+	}
+
+	var active *models.OncallOverride
+	for i := range overrides {
+		o := &overrides[i]
+		if !at.Before(o.StartsAt) && at.Before(o.EndsAt) && (active == nil || newer(*o, *active)) {
+			active = o
+		}
+	}
+
+	var shift models.OncallShift
+	if active != nil {
+		shift = models.OncallShift{User: active.User, Start: active.StartsAt, End: active.EndsAt, Override: true}
+	} else {
+		shift = r.shift(at)
+	}
+
+	for _, o := range overrides {
+		if active != nil && !newer(o, *active) {
+			continue
+		}
+		if o.StartsAt.After(at) && o.StartsAt.Before(shift.End) {
+			shift.End = o.StartsAt
+		}
+	}
+	return shift, nil
+}
+
+// Now returns who is on call at the given time and who takes over next
+func Now(schedule models.OncallSchedule, overrides []models.OncallOverride, at time.Time) (models.OncallNow, error) {
+	current, err := At(schedule, overrides, at)
+	if err != nil {
+		return models.OncallNow{}, err
+	}
+	next, err := At(schedule, overrides, current.End)
+	if err != nil {
+		return models.OncallNow{}, err
+	}
+	return models.OncallNow{
+		Schedule: schedule.Name,
+		Timezone: schedule.Timezone,
+		Current:  current,
+		Next:     next,
+	}, nil
+}
+
+// newer reports whether override a was created after b
+func newer(a, b models.OncallOverride) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID > b.ID
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+// civilDays counts the calendar days from a's date to b's date, each taken in its
+// own location
+func civilDays(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func mod(a, b int) int {
+	return ((a % b) + b) % b
+}
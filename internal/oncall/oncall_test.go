@@ -0,0 +1,112 @@
+package oncall
+
+import (
+	"sre-chat-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSchedule() models.OncallSchedule {
+	return models.OncallSchedule{
+		Name:         "payments",
+		Timezone:     "Europe/Berlin",
+		Participants: []string{"alice", "bob", "carol"},
+		ShiftDays:    7,
+		HandoffTime:  "09:00",
+		StartDate:    "2024-03-04",
+	}
+}
+
+func mustAt(t *testing.T, schedule models.OncallSchedule, overrides []models.OncallOverride, at time.Time) models.OncallShift {
+	shift, err := At(schedule, overrides, at)
+	assert.NoError(t, err)
+	return shift
+}
+
+func TestRotation(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	schedule := testSchedule()
+
+	// The first shift starts at the handoff on the start date
+	shift := mustAt(t, schedule, nil, time.Date(2024, 3, 4, 9, 0, 0, 0, berlin))
+	assert.Equal(t, "alice", shift.User)
+	assert.True(t, shift.Start.Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, berlin)))
+	assert.True(t, shift.End.Equal(time.Date(2024, 3, 11, 9, 0, 0, 0, berlin)))
+
+	// Just before the next handoff it is still alice's shift
+	assert.Equal(t, "alice", mustAt(t, schedule, nil, time.Date(2024, 3, 11, 8, 59, 0, 0, berlin)).User)
+	assert.Equal(t, "bob", mustAt(t, schedule, nil, time.Date(2024, 3, 11, 9, 0, 0, 0, berlin)).User)
+	assert.Equal(t, "carol", mustAt(t, schedule, nil, time.Date(2024, 3, 20, 0, 0, 0, 0, berlin)).User)
+	assert.Equal(t, "alice", mustAt(t, schedule, nil, time.Date(2024, 3, 26, 12, 0, 0, 0, berlin)).User)
+
+	// Times before the start extrapolate backwards
+	assert.Equal(t, "carol", mustAt(t, schedule, nil, time.Date(2024, 3, 4, 8, 0, 0, 0, berlin)).User)
+}
+
+func TestRotationKeepsLocalHandoffAcrossDST(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	schedule := testSchedule()
+	schedule.ShiftDays = 1
+	schedule.StartDate = "2024-03-30"
+
+	// Clocks go forward on 2024-03-31; the shift is an hour shorter but hands off at 09:00
+	shift := mustAt(t, schedule, nil, time.Date(2024, 3, 30, 12, 0, 0, 0, berlin))
+	assert.Equal(t, 23*time.Hour, shift.End.Sub(shift.Start))
+	shift = mustAt(t, schedule, nil, time.Date(2024, 3, 31, 9, 0, 0, 0, berlin))
+	assert.Equal(t, "bob", shift.User)
+	assert.Equal(t, 9, shift.Start.In(berlin).Hour())
+}
+
+func TestOverrides(t *testing.T) {
+	schedule := testSchedule()
+	schedule.Timezone = "UTC"
+	day := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC) }
+
+	overrides := []models.OncallOverride{
+		{ID: 1, User: "dave", StartsAt: day(5, 18), EndsAt: day(6, 9), CreatedAt: day(1, 0)},
+		{ID: 2, User: "erin", StartsAt: day(5, 20), EndsAt: day(5, 22), CreatedAt: day(2, 0)},
+	}
+
+	// The rotation shift ends where the override begins
+	shift := mustAt(t, schedule, overrides, day(5, 12))
+	assert.Equal(t, "alice", shift.User)
+	assert.False(t, shift.Override)
+	assert.True(t, shift.End.Equal(day(5, 18)))
+
+	// The newer override wins where they overlap
+	shift = mustAt(t, schedule, overrides, day(5, 19))
+	assert.Equal(t, "dave", shift.User)
+	assert.True(t, shift.Override)
+	assert.True(t, shift.End.Equal(day(5, 20)))
+	assert.Equal(t, "erin", mustAt(t, schedule, overrides, day(5, 21)).User)
+	assert.Equal(t, "dave", mustAt(t, schedule, overrides, day(5, 23)).User)
+	assert.Equal(t, "alice", mustAt(t, schedule, overrides, day(6, 9)).User)
+
+	now, err := Now(schedule, overrides, day(5, 12))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", now.Current.User)
+	assert.Equal(t, "dave", now.Next.User)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(testSchedule()))
+
+	invalid := []func(*models.OncallSchedule){
+		func(s *models.OncallSchedule) { s.Name = "Payments Team" },
+		func(s *models.OncallSchedule) { s.Participants = nil },
+		func(s *models.OncallSchedule) { s.Timezone = "Mars/Olympus" },
+		func(s *models.OncallSchedule) { s.HandoffTime = "9am" },
+		func(s *models.OncallSchedule) { s.StartDate = "04.03.2024" },
+		func(s *models.OncallSchedule) { s.ShiftDays = 0 },
+	}
+	for i, mutate := range invalid {
+		schedule := testSchedule()
+		mutate(&schedule)
+		assert.Error(t, Validate(schedule), "case %d", i)
+	}
+
+	start := time.Now()
+	assert.ErrorIs(t, ValidateOverride(models.OncallOverride{StartsAt: start, EndsAt: start}), ErrInvalidOverride)
+}
@@ -0,0 +1,81 @@
+// Package ratelimit limits how often each caller may act, using a token bucket per key.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepThreshold is how many buckets are kept before full ones are dropped
+const sweepThreshold = 10000
+
+// Limiter allows each key a sustained rate of events with bursts up to a limit.
+// A nil Limiter allows everything.
+type Limiter struct {
+	rate  float64 // tokens added per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter allowing perMinute events per key on average and up to burst
+// at once. It returns nil, allowing everything, when perMinute is not positive.
+func New(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token for key. When none is left it returns false and how long
+// until the next one is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= sweepThreshold {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets that have refilled, as they are the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := New(60, 2)
+	l.now = func() time.Time { return now }
+
+	// The burst is available at once, then one token per second
+	ok, _ := l.Allow("alice")
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice")
+	assert.True(t, ok)
+	ok, wait := l.Allow("alice")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// Keys are limited independently
+	ok, _ = l.Allow("bob")
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, wait = l.Allow("alice")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("alice")
+	assert.True(t, ok)
+}
+
+func TestDisabledLimiter(t *testing.T) {
+	var l *Limiter = New(0, 10)
+	assert.Nil(t, l)
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("alice")
+		assert.True(t, ok)
+	}
+}
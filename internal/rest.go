@@ -1,26 +1,97 @@
 package internal
 
 import (
+	"sre-chat-api/internal/commands"
+	"sre-chat-api/internal/config"
 	"sre-chat-api/internal/handlers"
+	"sre-chat-api/internal/jobs"
 	"sre-chat-api/internal/middleware"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/ratelimit"
+	"sre-chat-api/internal/unfurl"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 // SetupRouter configures and returns a Gin router with all routes and middleware
-func SetupRouter(logger *zap.Logger) *gin.Engine {
+func SetupRouter(logger *zap.Logger, cfg *config.Config, retention *jobs.RetentionEnforcer, scheduler *jobs.Scheduler, reminders *jobs.ReminderRunner, handoffs *jobs.HandoffAnnouncer, polls *jobs.PollCloser) *gin.Engine {
+	router, _ := SetupServers(logger, cfg, retention, scheduler, reminders, handoffs, polls)
+	return router
+}
+
+// SetupServers configures the Gin router and the gRPC server. They share handlers,
+// so messages posted through either API reach the streams of both.
+func SetupServers(logger *zap.Logger, cfg *config.Config, retention *jobs.RetentionEnforcer, scheduler *jobs.Scheduler, reminders *jobs.ReminderRunner, handoffs *jobs.HandoffAnnouncer, polls *jobs.PollCloser) (*gin.Engine, *grpc.Server) {
 	// Set up Gin router
 	router := gin.New()
 
+	// Client IPs key the rate limits, so X-Forwarded-For is only believed from
+	// configured proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies, trusting none", zap.Error(err))
+		router.SetTrustedProxies(nil)
+	}
+
+	if cfg.Admin.Token == "" {
+		if cfg.Admin.Open {
+			logger.Warn("ADMIN_TOKEN is not set and ADMIN_OPEN is true, admin endpoints are open to anyone")
+		} else {
+			logger.Warn("ADMIN_TOKEN is not set, admin endpoints are disabled")
+		}
+	}
+	if cfg.Alertmanager.Token == "" {
+		if cfg.Alertmanager.Open {
+			logger.Warn("ALERTMANAGER_TOKEN is not set and ALERTMANAGER_OPEN is true, anyone can post alerts")
+		} else {
+			logger.Warn("ALERTMANAGER_TOKEN is not set, the Alertmanager receiver is disabled")
+		}
+	}
+
 	// Add middleware
 	router.Use(middleware.LoggerMiddleware(logger))
 	router.Use(middleware.RecoveryMiddleware(logger))
 
 	// Initialize handlers
-	sseHandler := handlers.NewSSEHandler()
-	messageHandler := handlers.NewMessageHandler(sseHandler)
+	var unfurler *unfurl.Service
+	if cfg.Unfurl.Enabled {
+		unfurler = unfurl.NewService(cfg.Unfurl)
+	}
+
+	sseHandler := handlers.NewSSEHandler(cfg.Presence)
+	messageHandler := handlers.NewMessageHandler(sseHandler, unfurler, commands.Default)
 	healthHandler := handlers.NewHealthHandler()
+	adminHandler := handlers.NewAdminHandler(sseHandler)
+	retentionHandler := handlers.NewRetentionHandler(retention)
+	searchHandler := handlers.NewSearchHandler()
+	reactionHandler := handlers.NewReactionHandler(sseHandler)
+	attachmentHandler := handlers.NewAttachmentHandler(sseHandler, cfg.Storage)
+	mentionHandler := handlers.NewMentionHandler()
+	groupHandler := handlers.NewGroupHandler(sseHandler)
+	presenceHandler := handlers.NewPresenceHandler(sseHandler)
+	pinHandler := handlers.NewPinHandler(sseHandler, cfg.Pins)
+	scheduledHandler := handlers.NewScheduledMessageHandler()
+
+	reminderHandler := handlers.NewReminderHandler(reminders)
+	messageHandler.SetReminders(reminderHandler)
+	pollHandler := handlers.NewPollHandler(sseHandler, messageHandler)
+	webhookHandler := handlers.NewWebhookHandler(cfg.Webhooks)
+	incomingWebhookHandler := handlers.NewIncomingWebhookHandler(messageHandler, attachmentHandler)
+	alertHandler := handlers.NewAlertHandler(messageHandler, cfg.Alertmanager)
+	incidentHandler := handlers.NewIncidentHandler(messageHandler)
+	oncallHandler := handlers.NewOncallHandler()
+	botHandler := handlers.NewBotHandler(sseHandler, messageHandler)
+
+	// Humans are limited per client IP, bots per bot
+	messageLimiter := ratelimit.New(cfg.RateLimit.MessagesPerMinute, cfg.RateLimit.MessagesBurst)
+	botLimiter := ratelimit.New(cfg.RateLimit.BotPerMinute, cfg.RateLimit.BotBurst)
+
+	// Scheduled messages, reminders and on-call handoffs are announced like any other new message
+	scheduler.OnDeliver(messageHandler.Deliver)
+	reminders.OnDeliver(messageHandler.Deliver)
+	handoffs.OnDeliver(messageHandler.Deliver)
+	polls.OnClose(pollHandler.AnnounceClosed)
 
 	// Serve web interface
 	router.StaticFile("/", "./web/index.html")
@@ -36,12 +107,139 @@ func SetupRouter(logger *zap.Logger) *gin.Engine {
 		v1.GET("/messages/stream", sseHandler.StreamMessages)
 
 		// Message routes
-		v1.POST("/messages", messageHandler.CreateMessage)
+		v1.POST("/messages", middleware.RateLimit(messageLimiter, (*gin.Context).ClientIP), messageHandler.CreateMessage)
 		v1.GET("/messages", messageHandler.GetMessages)
 		v1.GET("/messages/:id", messageHandler.GetMessage)
 		v1.PUT("/messages/:id", messageHandler.UpdateMessage)
 		v1.DELETE("/messages/:id", messageHandler.DeleteMessage)
+
+		// Reaction routes
+		v1.POST("/messages/:id/reactions", reactionHandler.AddReaction)
+		v1.DELETE("/messages/:id/reactions", reactionHandler.RemoveReaction)
+
+		// Attachment routes
+		v1.POST("/messages/:id/attachments", attachmentHandler.UploadAttachments)
+		v1.GET("/attachments/:id/download", attachmentHandler.DownloadAttachment)
+
+		// Incoming webhooks, authenticated by the secret token in the URL
+		v1.POST("/hooks/:token", incomingWebhookHandler.Post)
+
+		// Alertmanager receiver (bearer token) and alert acknowledgement
+		v1.POST("/alertmanager", alertHandler.Receive)
+		v1.GET("/alerts", alertHandler.GetAlerts)
+		v1.POST("/alerts/:id/ack", alertHandler.AcknowledgeAlert)
+
+		// Incidents, each run from its own group
+		v1.GET("/incidents", incidentHandler.GetIncidents)
+		v1.POST("/incidents", incidentHandler.DeclareIncident)
+		v1.GET("/incidents/:id", incidentHandler.GetIncident)
+		v1.PUT("/incidents/:id", incidentHandler.UpdateIncident)
+		v1.GET("/incidents/:id/timeline", incidentHandler.GetTimeline)
+
+		// On-call schedules, addressed by name
+		v1.GET("/oncall", oncallHandler.GetSchedules)
+		v1.GET("/oncall/:schedule", oncallHandler.GetSchedule)
+		v1.GET("/oncall/:schedule/now", oncallHandler.GetNow)
+		v1.GET("/oncall/:schedule/overrides", oncallHandler.GetOverrides)
+
+		// Bot API, authenticated by a bot token with the scopes each route needs
+		bot := v1.Group("/bot", botHandler.Authenticate, middleware.RateLimit(botLimiter, handlers.BotRateLimitKey))
+		{
+			bot.GET("/me", botHandler.GetMe)
+			bot.GET("/groups", botHandler.GetGroups)
+			bot.POST("/groups/:id/join", handlers.RequireBotScope(models.BotScopeGroups), botHandler.JoinGroup)
+			bot.POST("/groups/:id/leave", handlers.RequireBotScope(models.BotScopeGroups), botHandler.LeaveGroup)
+			bot.GET("/groups/:id/messages", handlers.RequireBotScope(models.BotScopeMessagesRead), botHandler.GetMessages)
+			bot.POST("/messages", handlers.RequireBotScope(models.BotScopeMessagesWrite), botHandler.PostMessage)
+			bot.GET("/events", handlers.RequireBotScope(models.BotScopeEvents), botHandler.StreamEvents)
+		}
+
+		// Search routes
+		v1.GET("/search", searchHandler.Search)
+
+		// Groups with the caller's unread counts and read positions
+		v1.GET("/groups", groupHandler.GetGroups)
+		v1.PUT("/groups/:id/read", groupHandler.MarkRead)
+
+		// Scheduled messages (created via POST /messages with send_at)
+		v1.GET("/scheduled-messages", scheduledHandler.GetScheduledMessages)
+		v1.DELETE("/scheduled-messages/:id", scheduledHandler.CancelScheduledMessage)
+
+		// Polls
+		v1.POST("/polls", pollHandler.CreatePoll)
+		v1.POST("/messages/:id/poll/votes", pollHandler.Vote)
+		v1.DELETE("/messages/:id/poll/votes", pollHandler.RetractVote)
+		v1.POST("/messages/:id/poll/close", pollHandler.ClosePoll)
+
+		// Recurring reminders
+		v1.GET("/groups/:id/reminders", reminderHandler.GetReminders)
+		v1.POST("/groups/:id/reminders", reminderHandler.CreateReminder)
+		v1.GET("/reminders/:id", reminderHandler.GetReminder)
+		v1.PUT("/reminders/:id", reminderHandler.UpdateReminder)
+		v1.DELETE("/reminders/:id", reminderHandler.DeleteReminder)
+		v1.GET("/reminders/:id/runs", reminderHandler.GetReminderRuns)
+
+		// Pinned messages
+		v1.GET("/groups/:id/pins", pinHandler.GetPins)
+		v1.POST("/messages/:id/pin", pinHandler.PinMessage)
+		v1.DELETE("/messages/:id/pin", pinHandler.UnpinMessage)
+
+		// Presence and typing indicators, kept in memory only
+		v1.GET("/groups/:id/presence", presenceHandler.GetGroupPresence)
+		v1.POST("/groups/:id/typing", presenceHandler.Typing)
+		v1.PUT("/me/presence", presenceHandler.SetPresence)
+
+		// Mention inbox of the calling user (X-User header or user query parameter)
+		v1.GET("/me/mentions", mentionHandler.GetMentions)
+		v1.POST("/me/mentions/read", mentionHandler.MarkAllMentionsRead)
+		v1.POST("/me/mentions/:id/read", mentionHandler.MarkMentionRead)
+
+		// Admin routes
+		admin := v1.Group("/admin", middleware.AdminAuth(cfg.Admin.Token, cfg.Admin.Open))
+		{
+			admin.GET("/groups/:id/messages/deleted", adminHandler.GetDeletedMessages)
+			admin.POST("/messages/:id/restore", adminHandler.RestoreMessage)
+			admin.DELETE("/messages/:id", adminHandler.PurgeMessage)
+
+			admin.GET("/groups/:id/retention", retentionHandler.GetPolicy)
+			admin.PUT("/groups/:id/retention", retentionHandler.SetPolicy)
+			admin.DELETE("/groups/:id/retention", retentionHandler.DeletePolicy)
+			admin.POST("/retention/run", retentionHandler.RunRetention)
+			admin.GET("/retention/metrics", retentionHandler.GetMetrics)
+
+			admin.GET("/groups/:id/webhooks", webhookHandler.GetWebhooks)
+			admin.POST("/groups/:id/webhooks", webhookHandler.CreateWebhook)
+			admin.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+			admin.GET("/webhook-deliveries/dead", webhookHandler.GetDeadLetters)
+			admin.POST("/webhook-deliveries/:id/retry", webhookHandler.RetryDelivery)
+
+			admin.GET("/groups/:id/incoming-webhooks", incomingWebhookHandler.GetIncomingWebhooks)
+			admin.POST("/groups/:id/incoming-webhooks", incomingWebhookHandler.CreateIncomingWebhook)
+			admin.DELETE("/incoming-webhooks/:id", incomingWebhookHandler.DeleteIncomingWebhook)
+
+			admin.GET("/bots", botHandler.GetBots)
+			admin.POST("/bots", botHandler.CreateBot)
+			admin.DELETE("/bots/:id", botHandler.DeleteBot)
+			admin.GET("/bots/:id/tokens", botHandler.GetBotTokens)
+			admin.POST("/bots/:id/tokens", botHandler.CreateBotToken)
+			admin.DELETE("/bot-tokens/:id", botHandler.DeleteBotToken)
+
+			admin.POST("/oncall", oncallHandler.CreateSchedule)
+			admin.PUT("/oncall/:schedule", oncallHandler.UpdateSchedule)
+			admin.DELETE("/oncall/:schedule", oncallHandler.DeleteSchedule)
+			admin.POST("/oncall/:schedule/overrides", oncallHandler.CreateOverride)
+			admin.DELETE("/oncall/:schedule/overrides/:id", oncallHandler.DeleteOverride)
+		}
 	}
 
-	return router
+	// GraphQL API; GET also upgrades to WebSocket for subscriptions
+	graphqlHandler := handlers.NewGraphQLHandler(cfg.GraphQL, sseHandler, messageHandler, groupHandler, reactionHandler, messageLimiter)
+	router.POST("/graphql", graphqlHandler.Serve)
+	router.GET("/graphql", graphqlHandler.Serve)
+
+	grpcServer := setupGRPCServer(logger, handlers.NewGRPCHandler(sseHandler, messageHandler), messageLimiter)
+
+	return router, grpcServer
 }
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sre-chat-api/internal/config"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores attachment contents by key. The interface deliberately mirrors
+// S3-style object storage so an S3-compatible implementation can be dropped in later.
+type BlobStore interface {
+	// Put stores the contents of r under key
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the blob stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Store is the configured blob store
+var Store BlobStore
+
+// Signer signs and verifies attachment download URLs
+var Signer *URLSigner
+
+// Init sets up the blob store and URL signer from configuration
+func Init(cfg *config.Config) error {
+	switch cfg.Storage.Driver {
+	case "local":
+		store, err := NewLocalStore(cfg.Storage.LocalPath)
+		if err != nil {
+			return err
+		}
+		Store = store
+	default:
+		return fmt.Errorf("unsupported storage driver: %s", cfg.Storage.Driver)
+	}
+
+	key := []byte(cfg.Storage.SigningKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		log.Println("ATTACHMENT_SIGNING_KEY not set, download URLs will not survive a restart")
+	}
+	Signer = NewURLSigner(key, time.Duration(cfg.Storage.URLExpiryMinutes)*time.Minute)
+
+	log.Printf("Attachment storage initialized (driver=%s)", cfg.Storage.Driver)
+	return nil
+}
+
+// DeleteAll removes the blobs stored under keys, skipping empty keys and blobs
+// that are already gone. It carries on past failures and returns the first one.
+func DeleteAll(ctx context.Context, keys []string) error {
+	if Store == nil {
+		return nil
+	}
+	var first error
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := Store.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) && first == nil {
+			first = fmt.Errorf("failed to delete blob %s: %w", key, err)
+		}
+	}
+	return first
+}
+
+// NewKey returns a random storage key with the given prefix and extension
+func NewKey(prefix, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s%s", prefix, hex.EncodeToString(b), ext), nil
+}
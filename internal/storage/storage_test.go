@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "messages/1/file.txt", strings.NewReader("hello"), "text/plain"))
+
+	blob, err := store.Get(ctx, "messages/1/file.txt")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "messages/1/file.txt"))
+	_, err = store.Get(ctx, "messages/1/file.txt")
+	assert.Equal(t, ErrNotFound, err)
+
+	// Keys cannot escape the storage root
+	assert.Error(t, store.Put(ctx, "../outside.txt", strings.NewReader("x"), "text/plain"))
+}
+
+func TestDeleteAll(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	previous := Store
+	Store = store
+	t.Cleanup(func() { Store = previous })
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "messages/1/a.txt", strings.NewReader("a"), "text/plain"))
+	require.NoError(t, store.Put(ctx, "messages/1/thumbnails/a.jpg", strings.NewReader("t"), "image/jpeg"))
+
+	// Empty thumbnail keys and blobs that are already gone are skipped
+	require.NoError(t, DeleteAll(ctx, []string{"messages/1/a.txt", "", "messages/1/thumbnails/a.jpg", "messages/1/gone.txt"}))
+	_, err = store.Get(ctx, "messages/1/a.txt")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = store.Get(ctx, "messages/1/thumbnails/a.jpg")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"), time.Minute)
+
+	signed, err := url.Parse(signer.SignedURL("/api/v1/attachments/7/download", 7, "thumbnail"))
+	require.NoError(t, err)
+	query := signed.Query()
+
+	assert.True(t, signer.Verify(7, "thumbnail", query.Get("expires"), query.Get("signature")))
+
+	// Signatures are bound to the attachment and variant
+	assert.False(t, signer.Verify(8, "thumbnail", query.Get("expires"), query.Get("signature")))
+	assert.False(t, signer.Verify(7, "", query.Get("expires"), query.Get("signature")))
+
+	// Expired URLs are rejected
+	expired := &URLSigner{key: []byte("secret"), expiry: -time.Minute}
+	signed, _ = url.Parse(expired.SignedURL("/x", 7, ""))
+	assert.False(t, signer.Verify(7, "", signed.Query().Get("expires"), signed.Query().Get("signature")))
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1024, 512))
+	for y := 0; y < 512; y++ {
+		for x := 0; x < 1024; x++ {
+			src.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	thumbnail, width, height, err := GenerateThumbnail(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 1024, width)
+	assert.Equal(t, 512, height)
+
+	decoded, format, err := image.Decode(bytes.NewReader(thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, ThumbnailSize, decoded.Bounds().Dx())
+	assert.Equal(t, ThumbnailSize/2, decoded.Bounds().Dy())
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+	"io"
+)
+
+const (
+	// ThumbnailSize is the maximum width and height of generated thumbnails
+	ThumbnailSize = 256
+
+	// maxThumbnailPixels guards against decompression bombs
+	maxThumbnailPixels = 40_000_000
+)
+
+// ThumbnailContentType is the content type of generated thumbnails
+const ThumbnailContentType = "image/jpeg"
+
+// CanThumbnail reports whether thumbnails can be generated for the content type
+func CanThumbnail(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// GenerateThumbnail decodes an image and returns a JPEG thumbnail that fits within
+// ThumbnailSize, along with the original image dimensions
+func GenerateThumbnail(data []byte) ([]byte, int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxThumbnailPixels {
+		return nil, cfg.Width, cfg.Height, fmt.Errorf("image too large for thumbnail: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, cfg.Width, cfg.Height, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeThumbnail(&buf, src); err != nil {
+		return nil, cfg.Width, cfg.Height, err
+	}
+	return buf.Bytes(), cfg.Width, cfg.Height, nil
+}
+
+// writeThumbnail scales src down with a box filter and encodes it as JPEG.
+// Transparent areas are flattened onto white since JPEG has no alpha channel.
+func writeThumbnail(w io.Writer, src image.Image) error {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return fmt.Errorf("empty image")
+	}
+
+	dstW, dstH := srcW, srcH
+	if srcW > ThumbnailSize || srcH > ThumbnailSize {
+		if srcW >= srcH {
+			dstW, dstH = ThumbnailSize, max(1, srcH*ThumbnailSize/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*ThumbnailSize/srcH), ThumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/dstW)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					// Composite onto white
+					white := 0xffff - uint64(pa)
+					r += uint64(pr) + white
+					g += uint64(pg) + white
+					b += uint64(pb) + white
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return jpeg.Encode(w, dst, &jpeg.Options{Quality: 80})
+}
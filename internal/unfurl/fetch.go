@@ -0,0 +1,289 @@
+package unfurl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sre-chat-api/internal/models"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxPageBytes bounds how much of a page is read looking for metadata
+	maxPageBytes = 1 << 20
+
+	// maxOEmbedBytes bounds the size of an oEmbed response
+	maxOEmbedBytes = 256 << 10
+
+	maxRedirects = 3
+
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+
+	userAgent = "sre-chat-api-unfurler/1.0"
+)
+
+// Fetcher downloads pages and extracts their preview metadata without reaching
+// private networks
+type Fetcher struct {
+	client *http.Client
+}
+
+// NewFetcher creates a fetcher whose requests time out after timeout
+func NewFetcher(timeout time.Duration) *Fetcher {
+	return newFetcher(timeout, SafeControl)
+}
+
+// newFetcher creates a fetcher with a custom dialer control, letting tests reach
+// local servers
+func newFetcher(timeout time.Duration, control func(string, string, syscall.RawConn) error) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	transport := &http.Transport{
+		// Never use an environment proxy: the address check must see the real target
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				return validateURL(req.URL)
+			},
+		},
+	}
+}
+
+// Fetch retrieves rawURL and builds a preview from its OpenGraph, oEmbed and HTML metadata
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (models.LinkPreview, error) {
+	preview := models.LinkPreview{URL: rawURL}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return preview, err
+	}
+	if err := validateURL(target); err != nil {
+		return preview, err
+	}
+
+	resp, err := f.get(ctx, target.String(), "text/html,application/xhtml+xml")
+	if err != nil {
+		return preview, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		// Direct links to images preview as the image itself
+		preview.Type = "image"
+		preview.ImageURL = resp.Request.URL.String()
+		return preview, nil
+	case mediaType != "text/html" && mediaType != "application/xhtml+xml":
+		return preview, fmt.Errorf("unsupported content type: %s", mediaType)
+	}
+
+	meta := parseHTML(io.LimitReader(resp.Body, maxPageBytes))
+	base := resp.Request.URL
+
+	preview.Title = firstNonEmpty(meta.properties["og:title"], meta.names["twitter:title"], meta.title)
+	preview.Description = firstNonEmpty(meta.properties["og:description"], meta.names["twitter:description"], meta.names["description"])
+	preview.ImageURL = resolveURL(base, firstNonEmpty(meta.properties["og:image"], meta.names["twitter:image"]))
+	preview.SiteName = meta.properties["og:site_name"]
+	preview.Type = meta.properties["og:type"]
+
+	// Fill any gaps from the oEmbed endpoint the page advertises
+	if meta.oEmbedURL != "" && (preview.Title == "" || preview.ImageURL == "" || preview.SiteName == "") {
+		if oembed, err := f.fetchOEmbed(ctx, resolveURL(base, meta.oEmbedURL)); err == nil {
+			preview.Title = firstNonEmpty(preview.Title, oembed.Title)
+			preview.ImageURL = firstNonEmpty(preview.ImageURL, resolveURL(base, oembed.ThumbnailURL))
+			preview.SiteName = firstNonEmpty(preview.SiteName, oembed.ProviderName)
+			preview.Type = firstNonEmpty(preview.Type, oembed.Type)
+		}
+	}
+
+	preview.Title = truncate(preview.Title, maxTitleLength)
+	preview.Description = truncate(preview.Description, maxDescriptionLength)
+
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return preview, errors.New("no preview metadata found")
+	}
+	return preview, nil
+}
+
+// oEmbedResponse holds the oEmbed fields used for previews
+type oEmbedResponse struct {
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// fetchOEmbed retrieves an oEmbed JSON document
+func (f *Fetcher) fetchOEmbed(ctx context.Context, rawURL string) (oEmbedResponse, error) {
+	var oembed oEmbedResponse
+	if rawURL == "" {
+		return oembed, errors.New("invalid oEmbed URL")
+	}
+
+	resp, err := f.get(ctx, rawURL, "application/json")
+	if err != nil {
+		return oembed, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxOEmbedBytes)).Decode(&oembed)
+	return oembed, err
+}
+
+// get performs a GET request and fails on non-2xx responses
+func (f *Fetcher) get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// pageMeta holds the metadata found in a page's head
+type pageMeta struct {
+	title      string
+	properties map[string]string
+	names      map[string]string
+	oEmbedURL  string
+}
+
+// parseHTML extracts meta tags, the title and the oEmbed discovery link, stopping at <body>
+func parseHTML(r io.Reader) pageMeta {
+	meta := pageMeta{
+		properties: make(map[string]string),
+		names:      make(map[string]string),
+	}
+
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return meta
+		case html.TextToken:
+			if inTitle && meta.title == "" {
+				meta.title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "title" {
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := make(map[string]string)
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[strings.ToLower(string(key))] = string(value)
+			}
+
+			switch string(name) {
+			case "body":
+				return meta
+			case "title":
+				inTitle = true
+			case "meta":
+				content := strings.TrimSpace(attrs["content"])
+				if property := strings.ToLower(attrs["property"]); property != "" {
+					if _, seen := meta.properties[property]; !seen {
+						meta.properties[property] = content
+					}
+				}
+				if name := strings.ToLower(attrs["name"]); name != "" {
+					if _, seen := meta.names[name]; !seen {
+						meta.names[name] = content
+					}
+				}
+			case "link":
+				if strings.EqualFold(attrs["rel"], "alternate") && strings.EqualFold(attrs["type"], "application/json+oembed") {
+					meta.oEmbedURL = attrs["href"]
+				}
+			}
+		}
+	}
+}
+
+// validateURL only allows absolute http and https URLs
+func validateURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("URL has no host")
+	}
+	return nil
+}
+
+// resolveURL resolves ref against base, returning "" unless the result is http or https
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || validateURL(u) != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,69 @@
+package unfurl
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a URL resolves to an address that must not be fetched
+var ErrBlockedAddress = errors.New("address is not allowed")
+
+// blockedNetworks lists ranges not covered by the net.IP helpers that still must not be reached
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, can embed private IPv4 addresses
+)
+
+// IsBlockedIP reports whether ip is loopback, private, link-local or otherwise not
+// publicly routable
+func IsBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SafeControl is a net.Dialer Control function that refuses to connect to blocked
+// addresses. It runs after DNS resolution, so it also catches DNS rebinding.
+func SafeControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlockedIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// mustParseCIDRs parses CIDR strings, panicking on invalid input
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,129 @@
+package unfurl
+
+import (
+	"context"
+	"regexp"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxURLsPerMessage limits how many links in one message are previewed
+	MaxURLsPerMessage = 3
+
+	// maxURLLength skips URLs too long to index or worth previewing
+	maxURLLength = 2048
+)
+
+// urlPattern matches the same links the web client turns into previews
+var urlPattern = regexp.MustCompile(`(?i)(https?://[^\s<>"']+|www\.[^\s<>"']+)`)
+
+// Service unfurls links in message content and caches the previews in the database
+type Service struct {
+	fetcher *Fetcher
+	ttl     time.Duration
+}
+
+// NewService creates an unfurl service from the unfurl configuration
+func NewService(cfg config.UnfurlConfig) *Service {
+	return &Service{
+		fetcher: NewFetcher(time.Duration(cfg.TimeoutSeconds) * time.Second),
+		ttl:     time.Duration(cfg.CacheTTLHours) * time.Hour,
+	}
+}
+
+// Unfurl fetches previews for the links in content that are not cached or whose
+// cache entry has expired, returning how many previews were fetched successfully
+func (s *Service) Unfurl(ctx context.Context, content string) (int, error) {
+	urls := ExtractURLs(content)
+	if len(urls) == 0 {
+		return 0, nil
+	}
+
+	var cached []models.LinkPreview
+	if err := database.DB.Where("url IN ? AND fetched_at > ?", urls, time.Now().Add(-s.ttl)).Find(&cached).Error; err != nil {
+		return 0, err
+	}
+	fresh := make(map[string]bool, len(cached))
+	for _, preview := range cached {
+		fresh[preview.URL] = true
+	}
+
+	fetched := 0
+	for _, u := range urls {
+		if fresh[u] {
+			continue
+		}
+
+		preview, err := s.fetcher.Fetch(ctx, u)
+		preview.FetchedAt = time.Now()
+		if err != nil {
+			// Cache failures too so a broken link isn't refetched on every message
+			preview = models.LinkPreview{URL: u, Error: err.Error(), FetchedAt: preview.FetchedAt}
+		} else {
+			fetched++
+		}
+
+		if err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "url"}},
+			DoUpdates: clause.AssignmentColumns([]string{"title", "description", "image_url", "site_name", "type", "error", "fetched_at"}),
+		}).Create(&preview).Error; err != nil {
+			return fetched, err
+		}
+	}
+
+	return fetched, nil
+}
+
+// Lookup returns the cached successful previews for urls, in the same order
+func Lookup(urls []string) ([]models.LinkPreview, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	var previews []models.LinkPreview
+	if err := database.DB.Where("url IN ? AND error = ?", urls, "").Find(&previews).Error; err != nil {
+		return nil, err
+	}
+
+	byURL := make(map[string]models.LinkPreview, len(previews))
+	for _, preview := range previews {
+		byURL[preview.URL] = preview
+	}
+
+	ordered := make([]models.LinkPreview, 0, len(previews))
+	for _, u := range urls {
+		if preview, ok := byURL[u]; ok {
+			ordered = append(ordered, preview)
+		}
+	}
+	return ordered, nil
+}
+
+// ExtractURLs returns the distinct links in content, up to MaxURLsPerMessage
+func ExtractURLs(content string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, match := range urlPattern.FindAllString(content, -1) {
+		// Trailing punctuation usually ends the sentence, not the URL
+		u := strings.TrimRight(match, ".,;:!?)]}")
+		if strings.HasPrefix(strings.ToLower(u), "www.") {
+			u = "http://" + u
+		}
+		if len(u) > maxURLLength || seen[u] {
+			continue
+		}
+
+		seen[u] = true
+		urls = append(urls, u)
+		if len(urls) == MaxURLsPerMessage {
+			break
+		}
+	}
+	return urls
+}
@@ -0,0 +1,96 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowAll lets tests reach httptest servers on loopback
+func allowAll(string, string, syscall.RawConn) error { return nil }
+
+func TestIsBlockedIP(t *testing.T) {
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"}
+	for _, ip := range blocked {
+		assert.True(t, IsBlockedIP(net.ParseIP(ip)), ip)
+	}
+
+	allowed := []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"}
+	for _, ip := range allowed {
+		assert.False(t, IsBlockedIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	urls := ExtractURLs("See https://example.com/runbook. Also www.example.org and https://example.com/runbook again, plus https://a.test https://b.test")
+	assert.Equal(t, []string{"https://example.com/runbook", "http://www.example.org", "https://a.test"}, urls)
+
+	assert.Empty(t, ExtractURLs("no links here"))
+}
+
+func TestParseHTML(t *testing.T) {
+	page := `<html><head>
+		<title> Fallback title </title>
+		<meta property="og:title" content="Incident runbook">
+		<meta name="description" content="How to fail over the database">
+		<meta property="og:image" content="/img/cover.png">
+		<link rel="alternate" type="application/json+oembed" href="/oembed?url=x">
+		</head><body><meta property="og:title" content="ignored"></body></html>`
+
+	meta := parseHTML(strings.NewReader(page))
+	assert.Equal(t, "Fallback title", meta.title)
+	assert.Equal(t, "Incident runbook", meta.properties["og:title"])
+	assert.Equal(t, "How to fail over the database", meta.names["description"])
+	assert.Equal(t, "/oembed?url=x", meta.oEmbedURL)
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><meta property="og:description" content="Status page">
+				<link rel="alternate" type="application/json+oembed" href="/oembed"></head></html>`)
+		case "/oembed":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"type":"rich","title":"Grafana","provider_name":"Grafana Labs","thumbnail_url":"/thumb.png"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newFetcher(time.Second, allowAll)
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	require.NoError(t, err)
+	assert.Equal(t, "Grafana", preview.Title)
+	assert.Equal(t, "Status page", preview.Description)
+	assert.Equal(t, "Grafana Labs", preview.SiteName)
+	assert.Equal(t, server.URL+"/thumb.png", preview.ImageURL)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+}
+
+func TestFetchBlocksPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<title>internal</title>`)
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher(time.Second).Fetch(context.Background(), server.URL)
+	assert.True(t, errors.Is(err, ErrBlockedAddress), "expected blocked address, got %v", err)
+
+	_, err = NewFetcher(time.Second).Fetch(context.Background(), "file:///etc/passwd")
+	assert.Error(t, err)
+}
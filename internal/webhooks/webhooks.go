@@ -0,0 +1,196 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"sre-chat-api/internal/models"
+	"sre-chat-api/internal/unfurl"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Message events a webhook can subscribe to
+const (
+	EventMessageCreated = "message.created"
+	EventMessageUpdated = "message.updated"
+	EventMessageDeleted = "message.deleted"
+)
+
+// Events lists every event a webhook can subscribe to
+var Events = []string{EventMessageCreated, EventMessageUpdated, EventMessageDeleted}
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// ErrInvalidURL is returned for webhook URLs that are not absolute http(s) URLs
+var ErrInvalidURL = errors.New("webhook URL must be an absolute http or https URL")
+
+// ErrPrivateURL is returned for webhook URLs naming a loopback, private or link-local host
+var ErrPrivateURL = errors.New("webhook URL must not point to a loopback, private or link-local address")
+
+// Payload is the JSON body POSTed to webhook endpoints
+type Payload struct {
+	Event      string    `json:"event"`
+	GroupID    uint      `json:"group_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Message    Message   `json:"message"`
+}
+
+// Message is the message as included in a payload, without computed fields
+type Message struct {
+	ID        uint      `json:"id"`
+	GroupID   uint      `json:"group_id"`
+	User      string    `json:"user"`
+	Content   string    `json:"content"`
+	Bot       bool      `json:"bot"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Enqueue adds a delivery to the outbox for every active webhook in the message's
+// group subscribed to the event. Pass the transaction that changes the message so
+// the event is recorded if and only if the change is committed.
+func Enqueue(tx *gorm.DB, event string, message models.Message) error {
+	var hooks []models.Webhook
+	if err := tx.Where("group_id = ? AND active = ?", message.GroupID, true).Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var deliveries []models.WebhookDelivery
+	for _, hook := range hooks {
+		if Subscribed(hook, event) {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				WebhookID:     hook.ID,
+				Event:         event,
+				Status:        models.WebhookPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Payload{
+		Event:      event,
+		GroupID:    message.GroupID,
+		OccurredAt: now,
+		Message: Message{
+			ID:        message.ID,
+			GroupID:   message.GroupID,
+			User:      message.User,
+			Content:   message.Content,
+			Bot:       message.Bot,
+			CreatedAt: message.CreatedAt,
+			UpdatedAt: message.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = body
+	}
+
+	return tx.Create(&deliveries).Error
+}
+
+// Subscribed reports whether the webhook receives the event
+func Subscribed(hook models.Webhook, event string) bool {
+	for _, subscribed := range hook.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidEvent reports whether the event is one webhooks can subscribe to
+func ValidEvent(event string) bool {
+	for _, known := range Events {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL checks that a webhook URL is an absolute http or https URL and, unless
+// allowPrivate is set, that its host is not obviously internal. Hostnames are only
+// resolved when a delivery is sent, where the dispatcher checks the address again.
+func ValidateURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateURL
+	}
+	if ip := net.ParseIP(host); ip != nil && unfurl.IsBlockedIP(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+// NewSecret generates a random secret for signing payloads
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for a payload sent at the given Unix
+// timestamp: the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the payload and timestamp. Receivers
+// should also reject timestamps too far in the past to prevent replays.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns how long to wait before retrying after the given number of failed
+// attempts: base doubled for each attempt after the first, capped at max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"sre-chat-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"message.created"}`)
+	signature := Sign("secret", "1700000000", body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("secret", "1700000000", body, signature))
+	assert.False(t, Verify("other", "1700000000", body, signature))
+	assert.False(t, Verify("secret", "1700000001", body, signature))
+	assert.False(t, Verify("secret", "1700000000", []byte(`{"event":"message.deleted"}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	assert.Equal(t, 10*time.Second, Backoff(1, base, max))
+	assert.Equal(t, 20*time.Second, Backoff(2, base, max))
+	assert.Equal(t, 40*time.Second, Backoff(3, base, max))
+	assert.Equal(t, time.Minute, Backoff(4, base, max))
+	assert.Equal(t, time.Minute, Backoff(100, base, max))
+	assert.Equal(t, time.Duration(0), Backoff(3, 0, max))
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://hooks.example.com/chat", false))
+	assert.ErrorIs(t, ValidateURL("ftp://example.com", false), ErrInvalidURL)
+	assert.ErrorIs(t, ValidateURL("/relative", false), ErrInvalidURL)
+	assert.ErrorIs(t, ValidateURL("https://", false), ErrInvalidURL)
+
+	// Internal hosts only when private addresses are allowed
+	for _, raw := range []string{
+		"http://10.0.0.5:8080/events",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://127.0.0.1:9000/hook",
+		"http://[::1]/hook",
+		"http://localhost:8080/hook",
+		"http://api.localhost./hook",
+	} {
+		assert.ErrorIs(t, ValidateURL(raw, false), ErrPrivateURL, raw)
+		assert.NoError(t, ValidateURL(raw, true), raw)
+	}
+}
+
+func TestSubscribed(t *testing.T) {
+	hook := models.Webhook{Events: []string{EventMessageCreated, EventMessageDeleted}}
+	assert.True(t, Subscribed(hook, EventMessageCreated))
+	assert.False(t, Subscribed(hook, EventMessageUpdated))
+	assert.True(t, ValidEvent(EventMessageUpdated))
+	assert.False(t, ValidEvent("message.read"))
+}
@@ -0,0 +1,11 @@
+-- Migration: Create retention_policies table
+-- Each group can limit the age and number of messages it keeps
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL UNIQUE REFERENCES groups(id) ON DELETE CASCADE,
+    max_age_days INTEGER NOT NULL DEFAULT 0,
+    max_messages INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
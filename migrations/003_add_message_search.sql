@@ -0,0 +1,7 @@
+-- Migration: Add full-text search to messages
+-- Generated tsvector column kept in sync with content, indexed with GIN
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', coalesce(content, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector);
@@ -0,0 +1,12 @@
+-- Migration: Create reactions table
+-- One row per user per emoji on a message
+
+CREATE TABLE IF NOT EXISTS reactions (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    "user" VARCHAR(255) NOT NULL,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reactions_message_user_emoji ON reactions(message_id, "user", emoji);
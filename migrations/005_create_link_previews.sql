@@ -0,0 +1,14 @@
+-- Migration: Create link_previews table
+-- Server-side cache of unfurled link metadata, keyed by URL
+
+CREATE TABLE IF NOT EXISTS link_previews (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT,
+    description TEXT,
+    image_url TEXT,
+    site_name TEXT,
+    type TEXT,
+    error TEXT,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,14 @@
+-- Migration: Create mentions table
+-- One row per mentioned user per message; user names are stored lowercased
+
+CREATE TABLE IF NOT EXISTS mentions (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    "user" VARCHAR(255) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_message_user ON mentions(message_id, "user");
+CREATE INDEX IF NOT EXISTS idx_mentions_user ON mentions("user");
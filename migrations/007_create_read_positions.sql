@@ -0,0 +1,12 @@
+-- Migration: Create read_positions table
+-- Last read message per user per group; user names are stored lowercased
+
+CREATE TABLE IF NOT EXISTS read_positions (
+    id SERIAL PRIMARY KEY,
+    "user" VARCHAR(255) NOT NULL,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    last_read_message_id INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_read_positions_user_group ON read_positions("user", group_id);
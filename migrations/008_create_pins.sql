@@ -0,0 +1,13 @@
+-- Migration: Create pins table
+-- A message can be pinned once; pins are listed per group
+
+CREATE TABLE IF NOT EXISTS pins (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    pinned_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pins_message_id ON pins(message_id);
+CREATE INDEX IF NOT EXISTS idx_pins_group_id ON pins(group_id);
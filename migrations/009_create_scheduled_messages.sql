@@ -0,0 +1,19 @@
+-- Migration: Create scheduled_messages table
+-- Messages posted by the scheduler once send_at has passed
+
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    "user" VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    send_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    message_id INTEGER,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_group_id ON scheduled_messages(group_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due ON scheduled_messages(status, send_at);
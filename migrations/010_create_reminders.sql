@@ -0,0 +1,37 @@
+-- Migration: Create reminders, reminder_runs and leader_leases tables
+-- Recurring cron reminders, their run history and the lease electing the replica that fires them
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    schedule VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    "user" VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_group_id ON reminders(group_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_next_run_at ON reminders(next_run_at);
+
+CREATE TABLE IF NOT EXISTS reminder_runs (
+    id SERIAL PRIMARY KEY,
+    reminder_id INTEGER NOT NULL REFERENCES reminders(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    fired_at TIMESTAMP NOT NULL,
+    message_id INTEGER,
+    error TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_runs_reminder_scheduled ON reminder_runs(reminder_id, scheduled_for);
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name VARCHAR(64) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,14 @@
+-- Migration: Create incoming_webhooks table
+-- Only the SHA-256 hash of each webhook's URL token is stored
+
+CREATE TABLE IF NOT EXISTS incoming_webhooks (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incoming_webhooks_group_id ON incoming_webhooks(group_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incoming_webhooks_token_hash ON incoming_webhooks(token_hash);
@@ -0,0 +1,21 @@
+-- Migration: Create alerts table
+-- One row per Alertmanager group key, pointing at the message it keeps up to date
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    group_key TEXT NOT NULL,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    message_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    receiver VARCHAR(255),
+    notification TEXT NOT NULL,
+    acknowledged_by VARCHAR(255),
+    acknowledged_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_group_key ON alerts(group_key);
+CREATE INDEX IF NOT EXISTS idx_alerts_group_id ON alerts(group_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
@@ -0,0 +1,18 @@
+-- Migration: Create incidents table
+-- Each incident is run from its own group; the timeline is built from that group's messages
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    severity VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    commander VARCHAR(255) NOT NULL,
+    declared_by VARCHAR(255) NOT NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_group_id ON incidents(group_id);
+CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents(status);
@@ -0,0 +1,32 @@
+-- Migration: Create on-call schedule tables
+-- A schedule rotates through its participants; overrides replace the rotation for a while
+
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    timezone VARCHAR(64) NOT NULL,
+    participants TEXT NOT NULL,
+    shift_days INTEGER NOT NULL,
+    handoff_time VARCHAR(5) NOT NULL,
+    start_date VARCHAR(10) NOT NULL,
+    last_oncall VARCHAR(255),
+    last_handoff_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oncall_schedules_name ON oncall_schedules(name);
+CREATE INDEX IF NOT EXISTS idx_oncall_schedules_group_id ON oncall_schedules(group_id);
+
+CREATE TABLE IF NOT EXISTS oncall_overrides (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+    "user" VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_overrides_schedule_id ON oncall_overrides(schedule_id);
@@ -0,0 +1,38 @@
+-- Migration: Create bot tables and flag bot-authored messages
+-- Bots authenticate with scoped tokens (stored as SHA-256 hashes) and receive the
+-- events of the groups they have joined
+
+CREATE TABLE IF NOT EXISTS bots (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    owner VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bots_name ON bots(name);
+
+CREATE TABLE IF NOT EXISTS bot_tokens (
+    id SERIAL PRIMARY KEY,
+    bot_id INTEGER NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+    name VARCHAR(100),
+    token_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bot_tokens_token_hash ON bot_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_bot_tokens_bot_id ON bot_tokens(bot_id);
+
+CREATE TABLE IF NOT EXISTS bot_memberships (
+    bot_id INTEGER NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (bot_id, group_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_memberships_group_id ON bot_memberships(group_id);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS bot BOOLEAN NOT NULL DEFAULT FALSE;
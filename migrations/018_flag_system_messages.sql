@@ -0,0 +1,9 @@
+-- Migration: Flag messages posted by the server itself
+-- Incident timelines list system messages by this flag rather than by the name they
+-- are posted as, which anyone can post under
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS system BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Earlier incident announcements can only be recognized by their name
+UPDATE messages SET system = TRUE
+WHERE "user" = 'incident' AND group_id IN (SELECT group_id FROM incidents);
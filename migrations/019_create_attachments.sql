@@ -0,0 +1,18 @@
+-- Migration: Create attachments table
+-- Files and images uploaded to messages; the blobs live in attachment storage under
+-- storage_key, with a thumbnail under thumbnail_key for images
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT,
+    width INTEGER,
+    height INTEGER,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id);
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// The methods in this file call admin endpoints and need the admin token (WithToken)
+// set on the server as ADMIN_TOKEN.
+
+// DeletedMessages returns the soft-deleted messages of a group
+func (c *Client) DeletedMessages(ctx context.Context, groupID uint) ([]Message, error) {
+	var messages []Message
+	err := c.get(ctx, "/api/v1/admin/groups/"+id(groupID)+"/messages/deleted", nil, &messages)
+	return messages, err
+}
+
+// RestoreMessage restores a soft-deleted message
+func (c *Client) RestoreMessage(ctx context.Context, messageID uint) (*Message, error) {
+	var message Message
+	if err := c.post(ctx, "/api/v1/admin/messages/"+id(messageID)+"/restore", nil, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// PurgeMessage permanently deletes a soft-deleted message
+func (c *Client) PurgeMessage(ctx context.Context, messageID uint) error {
+	return c.delete(ctx, "/api/v1/admin/messages/"+id(messageID), nil)
+}
+
+// RetentionPolicy returns a group's retention policy
+func (c *Client) RetentionPolicy(ctx context.Context, groupID uint) (*RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if err := c.get(ctx, "/api/v1/admin/groups/"+id(groupID)+"/retention", nil, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetRetentionPolicy sets a group's retention policy
+func (c *Client) SetRetentionPolicy(ctx context.Context, groupID uint, req RetentionPolicyRequest) (*RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if err := c.put(ctx, "/api/v1/admin/groups/"+id(groupID)+"/retention", req, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// DeleteRetentionPolicy removes a group's retention policy
+func (c *Client) DeleteRetentionPolicy(ctx context.Context, groupID uint) error {
+	return c.delete(ctx, "/api/v1/admin/groups/"+id(groupID)+"/retention", nil)
+}
+
+// RetentionRun is the outcome of enforcing retention policies
+type RetentionRun struct {
+	DryRun  bool              `json:"dry_run"`
+	Results []RetentionResult `json:"results"`
+}
+
+// RunRetention enforces retention policies now, or reports what would be removed
+func (c *Client) RunRetention(ctx context.Context, dryRun bool) (*RetentionRun, error) {
+	path := "/api/v1/admin/retention/run"
+	if dryRun {
+		path += "?dry_run=true"
+	}
+
+	var run RetentionRun
+	if err := c.post(ctx, path, nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RetentionMetrics returns the rows purged by retention since the server started
+func (c *Client) RetentionMetrics(ctx context.Context) (*RetentionMetrics, error) {
+	var metrics RetentionMetrics
+	if err := c.get(ctx, "/api/v1/admin/retention/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// ListWebhooks returns a group's outgoing webhooks
+func (c *Client) ListWebhooks(ctx context.Context, groupID uint) ([]Webhook, error) {
+	var hooks []Webhook
+	err := c.get(ctx, "/api/v1/admin/groups/"+id(groupID)+"/webhooks", nil, &hooks)
+	return hooks, err
+}
+
+// CreateWebhook subscribes a URL to a group's message events. The returned webhook
+// carries its signing secret, which is not returned again.
+func (c *Client) CreateWebhook(ctx context.Context, groupID uint, req CreateWebhookRequest) (*Webhook, error) {
+	var hook Webhook
+	if err := c.post(ctx, "/api/v1/admin/groups/"+id(groupID)+"/webhooks", req, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// UpdateWebhook changes a webhook's URL, events or active flag
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID uint, req UpdateWebhookRequest) (*Webhook, error) {
+	var hook Webhook
+	if err := c.put(ctx, "/api/v1/admin/webhooks/"+id(webhookID), req, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// DeleteWebhook deletes a webhook and its queued deliveries
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	return c.delete(ctx, "/api/v1/admin/webhooks/"+id(webhookID), nil)
+}
+
+// WebhookDeliveries returns a webhook's deliveries with their attempts, newest
+// first, optionally only those with the given status
+func (c *Client) WebhookDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := c.get(ctx, "/api/v1/admin/webhooks/"+id(webhookID)+"/deliveries", statusQuery(status, limit), &deliveries)
+	return deliveries, err
+}
+
+// DeadWebhookDeliveries returns the dead-lettered deliveries of all webhooks
+func (c *Client) DeadWebhookDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := c.get(ctx, "/api/v1/admin/webhook-deliveries/dead", statusQuery("", limit), &deliveries)
+	return deliveries, err
+}
+
+// RetryWebhookDelivery queues a dead or delivered payload again
+func (c *Client) RetryWebhookDelivery(ctx context.Context, deliveryID uint) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if err := c.post(ctx, "/api/v1/admin/webhook-deliveries/"+id(deliveryID)+"/retry", nil, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListIncomingWebhooks returns a group's incoming webhooks
+func (c *Client) ListIncomingWebhooks(ctx context.Context, groupID uint) ([]IncomingWebhook, error) {
+	var hooks []IncomingWebhook
+	err := c.get(ctx, "/api/v1/admin/groups/"+id(groupID)+"/incoming-webhooks", nil, &hooks)
+	return hooks, err
+}
+
+// CreateIncomingWebhook creates an incoming webhook. The returned webhook carries
+// its secret URL, which is not returned again.
+func (c *Client) CreateIncomingWebhook(ctx context.Context, groupID uint, name string) (*IncomingWebhook, error) {
+	var hook IncomingWebhook
+	err := c.post(ctx, "/api/v1/admin/groups/"+id(groupID)+"/incoming-webhooks", CreateIncomingWebhookRequest{Name: name}, &hook)
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// DeleteIncomingWebhook deletes an incoming webhook, revoking its URL
+func (c *Client) DeleteIncomingWebhook(ctx context.Context, hookID uint) error {
+	return c.delete(ctx, "/api/v1/admin/incoming-webhooks/"+id(hookID), nil)
+}
+
+// ListBots returns all bots
+func (c *Client) ListBots(ctx context.Context) ([]Bot, error) {
+	var bots []Bot
+	err := c.get(ctx, "/api/v1/admin/bots", nil, &bots)
+	return bots, err
+}
+
+// CreateBot creates a bot
+func (c *Client) CreateBot(ctx context.Context, req CreateBotRequest) (*Bot, error) {
+	var bot Bot
+	if err := c.post(ctx, "/api/v1/admin/bots", req, &bot); err != nil {
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// DeleteBot deletes a bot with its tokens and memberships
+func (c *Client) DeleteBot(ctx context.Context, botID uint) error {
+	return c.delete(ctx, "/api/v1/admin/bots/"+id(botID), nil)
+}
+
+// ListBotTokens returns a bot's tokens, without their secrets
+func (c *Client) ListBotTokens(ctx context.Context, botID uint) ([]BotToken, error) {
+	var tokens []BotToken
+	err := c.get(ctx, "/api/v1/admin/bots/"+id(botID)+"/tokens", nil, &tokens)
+	return tokens, err
+}
+
+// CreateBotToken issues a bot token with the given scopes. The returned token's
+// Token field is the secret, which is not returned again.
+func (c *Client) CreateBotToken(ctx context.Context, botID uint, req CreateBotTokenRequest) (*BotToken, error) {
+	var token BotToken
+	if err := c.post(ctx, "/api/v1/admin/bots/"+id(botID)+"/tokens", req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeBotToken revokes a bot token
+func (c *Client) RevokeBotToken(ctx context.Context, tokenID uint) error {
+	return c.delete(ctx, "/api/v1/admin/bot-tokens/"+id(tokenID), nil)
+}
+
+// CreateOncallSchedule creates an on-call schedule
+func (c *Client) CreateOncallSchedule(ctx context.Context, req OncallScheduleRequest) (*OncallSchedule, error) {
+	var schedule OncallSchedule
+	if err := c.post(ctx, "/api/v1/admin/oncall", req, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// UpdateOncallSchedule replaces a schedule
+func (c *Client) UpdateOncallSchedule(ctx context.Context, name string, req OncallScheduleRequest) (*OncallSchedule, error) {
+	var schedule OncallSchedule
+	if err := c.put(ctx, "/api/v1/admin/oncall/"+url.PathEscape(name), req, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DeleteOncallSchedule deletes a schedule and its overrides
+func (c *Client) DeleteOncallSchedule(ctx context.Context, name string) error {
+	return c.delete(ctx, "/api/v1/admin/oncall/"+url.PathEscape(name), nil)
+}
+
+// CreateOncallOverride puts someone on call for a while
+func (c *Client) CreateOncallOverride(ctx context.Context, name string, req OncallOverrideRequest) (*OncallOverride, error) {
+	var override OncallOverride
+	if err := c.post(ctx, "/api/v1/admin/oncall/"+url.PathEscape(name)+"/overrides", req, &override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// DeleteOncallOverride deletes an override
+func (c *Client) DeleteOncallOverride(ctx context.Context, name string, overrideID uint) error {
+	return c.delete(ctx, "/api/v1/admin/oncall/"+url.PathEscape(name)+"/overrides/"+id(overrideID), nil)
+}
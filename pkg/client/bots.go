@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+)
+
+// The methods in this file call the bot API and need a bot token (WithToken) with
+// the scope each endpoint requires.
+
+// BotIdentity is the bot a token belongs to, the token's scopes and the groups the
+// bot has joined
+type BotIdentity struct {
+	Bot      Bot      `json:"bot"`
+	Scopes   []string `json:"scopes"`
+	GroupIDs []uint   `json:"group_ids"`
+}
+
+// BotMe returns the calling bot
+func (c *Client) BotMe(ctx context.Context) (*BotIdentity, error) {
+	var identity BotIdentity
+	if err := c.get(ctx, "/api/v1/bot/me", nil, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// BotGroups returns the groups the calling bot has joined
+func (c *Client) BotGroups(ctx context.Context) ([]BotMembership, error) {
+	var memberships []BotMembership
+	err := c.get(ctx, "/api/v1/bot/groups", nil, &memberships)
+	return memberships, err
+}
+
+// BotJoinGroup makes the calling bot join a group
+func (c *Client) BotJoinGroup(ctx context.Context, groupID uint) error {
+	return c.post(ctx, "/api/v1/bot/groups/"+id(groupID)+"/join", nil, nil)
+}
+
+// BotLeaveGroup makes the calling bot leave a group
+func (c *Client) BotLeaveGroup(ctx context.Context, groupID uint) error {
+	return c.post(ctx, "/api/v1/bot/groups/"+id(groupID)+"/leave", nil, nil)
+}
+
+// BotPostMessage posts to a joined group as the calling bot
+func (c *Client) BotPostMessage(ctx context.Context, groupID uint, content string) (*Message, error) {
+	var message Message
+	if err := c.post(ctx, "/api/v1/bot/messages", BotMessageRequest{GroupID: groupID, Content: content}, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// BotListMessages returns up to limit messages of a joined group posted after
+// afterID, oldest first
+func (c *Client) BotListMessages(ctx context.Context, groupID, afterID uint, limit int) ([]Message, error) {
+	query := url.Values{}
+	setUint(query, "after_id", afterID)
+	setInt(query, "limit", limit)
+
+	var messages []Message
+	err := c.get(ctx, "/api/v1/bot/groups/"+id(groupID)+"/messages", query, &messages)
+	return messages, err
+}
+
+// BotMessages iterates over the messages of a joined group posted after afterID,
+// fetching them pageSize at a time (DefaultPageSize when zero; at most 200)
+func (c *Client) BotMessages(ctx context.Context, groupID, afterID uint, pageSize int) iter.Seq2[Message, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(Message, error) bool) {
+		for {
+			messages, err := c.BotListMessages(ctx, groupID, afterID, pageSize)
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			for _, message := range messages {
+				if !yield(message, nil) {
+					return
+				}
+			}
+			if len(messages) < pageSize {
+				return
+			}
+			afterID = messages[len(messages)-1].ID
+		}
+	}
+}
@@ -0,0 +1,359 @@
+// Package client is a Go client for the chat API. It covers every /api/v1
+// endpoint with typed methods using the server's models, pages through message
+// listings, follows the event stream across reconnects and retries requests that
+// failed in ways that are safe to retry.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for retrying failed requests
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = 250 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
+// Error is returned for responses with an error status. Message is the error the
+// API reported.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("chat api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("chat api: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an API error with status 404
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client calls the chat API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	user       string
+	token      string
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests. Its timeout also applies
+// to event streams, so leave it unset when subscribing.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUser identifies the caller with the X-User header
+func WithUser(user string) Option {
+	return func(c *Client) {
+		c.user = user
+	}
+}
+
+// WithToken sends a bearer token: the admin token for admin endpoints, or a bot
+// token for the bot API
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how often a failed request is retried and the delay before the
+// first retry, which doubles with every further attempt. Zero retries disables
+// retrying.
+func WithRetries(maxRetries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryDelay = delay
+	}
+}
+
+// New creates a client for the API served at baseURL, e.g. "http://chat:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// User returns the user the client identifies as
+func (c *Client) User() string {
+	return c.user
+}
+
+// request describes one API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	// body is sent as JSON unless contentType is set, in which case it is sent as is
+	body        interface{}
+	contentType string
+	header      http.Header
+}
+
+// do sends the request, retrying where that is safe, and decodes a successful
+// response into out unless out is nil. It returns the response status.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (int, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// send sends the request, retrying where that is safe, and returns the first
+// successful response. Error statuses are turned into *Error.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		if req.contentType != "" {
+			body = req.body.([]byte)
+		} else {
+			var err error
+			if body, err = json.Marshal(req.body); err != nil {
+				return nil, fmt.Errorf("failed to encode request: %w", err)
+			}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, req, body)
+		if err == nil && resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		if err == nil {
+			err = responseError(resp)
+		}
+		if attempt >= c.maxRetries || !retryable(req.method, resp, err) {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				wait = after
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// attempt sends the request once
+func (c *Client) attempt(ctx context.Context, req request, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.url(req.path, req.query), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	c.authorize(httpReq)
+
+	return c.httpClient.Do(httpReq)
+}
+
+// authorize adds the caller's identity to a request
+func (c *Client) authorize(req *http.Request) {
+	if c.user != "" {
+		req.Header.Set("X-User", c.user)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// url resolves an API path against the base URL
+func (c *Client) url(path string, query url.Values) string {
+	u := *c.baseURL
+	u.Path = strings.TrimRight(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// backoff returns the delay before a retry, with jitter so that clients failing
+// together do not retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	if c.retryDelay <= 0 {
+		return 0
+	}
+	delay := c.retryDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryable reports whether a failed attempt may be repeated. Rate limited
+// requests were not processed, so they are always retried; other failures only
+// for methods that can safely run twice.
+func retryable(method string, resp *http.Response, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	idempotent := method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+	if resp == nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// retryAfter reads the Retry-After header in seconds
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// responseError reads an error response and closes its body
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: body.Error}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	_, err := c.do(ctx, request{method: http.MethodGet, path: path, query: query}, out)
+	return err
+}
+
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: path, body: body}, out)
+	return err
+}
+
+func (c *Client) put(ctx context.Context, path string, body, out interface{}) error {
+	_, err := c.do(ctx, request{method: http.MethodPut, path: path, body: body}, out)
+	return err
+}
+
+func (c *Client) delete(ctx context.Context, path string, body interface{}) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: path, body: body}, nil)
+	return err
+}
+
+// deleteReturning sends a DELETE whose response body is decoded into out
+func (c *Client) deleteReturning(ctx context.Context, path string, out interface{}) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: path}, out)
+	return err
+}
+
+// id formats an ID for a path
+func id(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// setUint adds a non-zero ID to a query
+func setUint(query url.Values, key string, n uint) {
+	if n != 0 {
+		query.Set(key, id(n))
+	}
+}
+
+// setInt adds a non-zero number to a query
+func setInt(query url.Values, key string, n int) {
+	if n != 0 {
+		query.Set(key, strconv.Itoa(n))
+	}
+}
+
+// setString adds a non-empty value to a query
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setTime adds a time to a query in RFC 3339
+func setTime(query url.Values, key string, t *time.Time) {
+	if t != nil {
+		query.Set(key, t.Format(time.RFC3339Nano))
+	}
+}
+
+// statusQuery builds the query of a listing filtered by status and limited in size
+func statusQuery(status string, limit int) url.Values {
+	query := url.Values{}
+	setString(query, "status", status)
+	setInt(query, "limit", limit)
+	return query
+}
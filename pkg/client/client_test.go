@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sre-chat-api/internal"
+	"sre-chat-api/internal/config"
+	"sre-chat-api/internal/database"
+	"sre-chat-api/internal/jobs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestServer serves the real router
+func newTestServer(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.RateLimit = config.RateLimitConfig{}
+	cfg.Presence.GraceSeconds = 0
+
+	logger := zap.NewNop()
+	router := internal.SetupRouter(logger, cfg,
+		jobs.NewRetentionEnforcer(cfg.Retention, logger),
+		jobs.NewScheduler(cfg.Scheduler, logger),
+		jobs.NewReminderRunner(cfg.Reminders, logger),
+		jobs.NewHandoffAnnouncer(cfg.Oncall, logger),
+		jobs.NewPollCloser(cfg.Polls, logger),
+	)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setupTestGroup connects to the test database and creates a group of its own
+func setupTestGroup(t *testing.T) Group {
+	cfg := config.DatabaseConfig{
+		Host:     "localhost",
+		Port:     "5432",
+		User:     "postgres",
+		Password: "postgres",
+		DBName:   "chat_test_db",
+		SSLMode:  "disable",
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
+	if err != nil {
+		t.Skipf("Skipping test: database connection failed: %v", err)
+	}
+	database.DB = db
+	require.NoError(t, database.Migrate(true))
+
+	group := Group{Name: fmt.Sprintf("client-test-%d", time.Now().UnixNano())}
+	require.NoError(t, db.Create(&group).Error)
+	return group
+}
+
+func newTestClient(t *testing.T, baseURL string, opts ...Option) *Client {
+	c, err := New(baseURL, append([]Option{WithRetries(3, time.Millisecond)}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestMessages(t *testing.T) {
+	group := setupTestGroup(t)
+	server := newTestServer(t)
+	ctx := context.Background()
+	alice := newTestClient(t, server.URL, WithUser("alice"))
+
+	var posted []uint
+	for i := 0; i < 7; i++ {
+		message, err := alice.PostMessage(ctx, group.ID, fmt.Sprintf("message %d", i))
+		require.NoError(t, err)
+		posted = append(posted, message.ID)
+	}
+
+	// Pages of 3 cover every message once, in both directions
+	var ascending []uint
+	for message, err := range alice.Messages(ctx, MessageQuery{GroupID: group.ID}, 3) {
+		require.NoError(t, err)
+		ascending = append(ascending, message.ID)
+	}
+	assert.Equal(t, posted, ascending)
+
+	var descending []uint
+	for message, err := range alice.Messages(ctx, MessageQuery{GroupID: group.ID, Newest: true}, 3) {
+		require.NoError(t, err)
+		descending = append(descending, message.ID)
+	}
+	assert.Len(t, descending, len(posted))
+	assert.Equal(t, posted[len(posted)-1], descending[0])
+
+	// Slash commands reply instead of posting
+	result, err := alice.CreateMessage(ctx, CreateMessageRequest{User: "alice", GroupID: group.ID, Content: "/help"})
+	require.NoError(t, err)
+	assert.Nil(t, result.Message)
+	assert.NotNil(t, result.Command)
+
+	updated, err := alice.UpdateMessage(ctx, posted[0], "edited")
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updated.Content)
+
+	_, err = alice.AddReaction(ctx, posted[0], "👀")
+	require.NoError(t, err)
+	message, err := alice.GetMessage(ctx, posted[0])
+	require.NoError(t, err)
+	assert.Len(t, message.Reactions, 1)
+
+	require.NoError(t, alice.DeleteMessage(ctx, posted[0]))
+	_, err = alice.GetMessage(ctx, posted[0])
+	assert.True(t, IsNotFound(err))
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := calls.Add(1); {
+		case r.Method == http.MethodPost && n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, `{"error": "Try again"}`)
+		default:
+			io.WriteString(w, `{"id": 42, "content": "hello"}`)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// Idempotent requests are retried on unavailability
+	c := newTestClient(t, server.URL)
+	message, err := c.GetMessage(ctx, 42)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", message.Content)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Posts are retried when rate limited, but not when they may have been processed
+	calls.Store(0)
+	_, err = c.PostMessage(ctx, 1, "hello")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, "Try again", apiErr.Message)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Retries stop when the context is done
+	calls.Store(0)
+	slow := newTestClient(t, server.URL, WithRetries(3, time.Hour))
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = slow.GetMessage(ctx, 42)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// cuttableTransport lets a test drop the open event stream and records the
+// Last-Event-ID each stream was opened with
+type cuttableTransport struct {
+	mu           sync.Mutex
+	conn         net.Conn
+	lastEventIDs []string
+}
+
+func (t *cuttableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.lastEventIDs = append(t.lastEventIDs, req.Header.Get("Last-Event-ID"))
+	t.mu.Unlock()
+
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err == nil {
+				t.mu.Lock()
+				t.conn = conn
+				t.mu.Unlock()
+			}
+			return conn, err
+		},
+	}
+	return transport.RoundTrip(req)
+}
+
+func (t *cuttableTransport) cut() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conn.Close()
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	server := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transport := &cuttableTransport{}
+	alice := newTestClient(t, server.URL, WithUser("alice"), WithHTTPClient(&http.Client{Transport: transport}), WithRetries(3, 200*time.Millisecond))
+	bob := newTestClient(t, server.URL, WithUser("bob"))
+
+	events := make(chan Event, 100)
+	go alice.Subscribe(ctx, StreamOptions{}, func(event Event) error {
+		events <- event
+		return nil
+	})
+	next := func(match func(Event) bool) Event {
+		for {
+			select {
+			case event := <-events:
+				if match(event) {
+					return event
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for event")
+			}
+		}
+	}
+	bobPresence := func(status string) func(Event) bool {
+		return func(event Event) bool {
+			var presence Presence
+			return event.Type == EventPresence && event.Decode(&presence) == nil &&
+				presence.User == "bob" && presence.Status == status
+		}
+	}
+
+	next(func(event Event) bool { return event.Type == EventConnected })
+
+	// Bob comes online; alice sees it with an event ID to resume from
+	go bob.Subscribe(ctx, StreamOptions{}, func(Event) error { return nil })
+	online := next(bobPresence(PresenceOnline))
+	assert.NotEmpty(t, online.ID)
+
+	// Alice's stream drops and bob goes away before she is back
+	transport.cut()
+	_, err := bob.SetPresence(ctx, PresenceAway)
+	require.NoError(t, err)
+
+	away := next(bobPresence(PresenceAway))
+	assert.NotEmpty(t, away.ID)
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	require.GreaterOrEqual(t, len(transport.lastEventIDs), 2)
+	assert.Empty(t, transport.lastEventIDs[0])
+	assert.NotEmpty(t, transport.lastEventIDs[1])
+}
+
+func TestSubscribeResync(t *testing.T) {
+	server := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// An ID from another server process cannot be resumed
+	alice := newTestClient(t, server.URL, WithUser("alice"))
+	var types []string
+	err := alice.Subscribe(ctx, StreamOptions{LastEventID: "elsewhere-12"}, func(event Event) error {
+		types = append(types, event.Type)
+		if event.Type == EventResync {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, []string{EventConnected, EventResync}, types)
+}
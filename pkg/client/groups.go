@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// HealthCheck returns an error unless the API and its database are healthy
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return c.get(ctx, "/api/v1/healthcheck", nil, nil)
+}
+
+// ListGroups returns all groups with the client's user's read position and unread count
+func (c *Client) ListGroups(ctx context.Context) ([]GroupSummary, error) {
+	var groups []GroupSummary
+	err := c.get(ctx, "/api/v1/groups", nil, &groups)
+	return groups, err
+}
+
+// MarkRead advances the client's user's read position in a group
+func (c *Client) MarkRead(ctx context.Context, groupID, messageID uint) (*ReadPosition, error) {
+	var position ReadPosition
+	if err := c.put(ctx, "/api/v1/groups/"+id(groupID)+"/read", ReadPositionRequest{MessageID: messageID}, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// GroupPresence returns the online, away and offline status of a group's members
+func (c *Client) GroupPresence(ctx context.Context, groupID uint) ([]Presence, error) {
+	var presence []Presence
+	err := c.get(ctx, "/api/v1/groups/"+id(groupID)+"/presence", nil, &presence)
+	return presence, err
+}
+
+// Typing tells a group's streams that the client's user is typing
+func (c *Client) Typing(ctx context.Context, groupID uint) (*TypingEvent, error) {
+	var event TypingEvent
+	if err := c.post(ctx, "/api/v1/groups/"+id(groupID)+"/typing", nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// SetPresence marks the client's user away or back online; they need an open stream
+func (c *Client) SetPresence(ctx context.Context, status string) (*Presence, error) {
+	var presence Presence
+	if err := c.put(ctx, "/api/v1/me/presence", PresenceRequest{Status: status}, &presence); err != nil {
+		return nil, err
+	}
+	return &presence, nil
+}
+
+// GroupPins returns the pinned messages of a group, newest first
+func (c *Client) GroupPins(ctx context.Context, groupID uint) ([]Pin, error) {
+	var pins []Pin
+	err := c.get(ctx, "/api/v1/groups/"+id(groupID)+"/pins", nil, &pins)
+	return pins, err
+}
+
+// Mentions are the client's user's mentions with the number still unread
+type Mentions struct {
+	Mentions    []Mention `json:"mentions"`
+	UnreadCount int64     `json:"unread_count"`
+}
+
+// ListMentions returns the messages mentioning the client's user, newest first
+func (c *Client) ListMentions(ctx context.Context, unread bool, limit int) (*Mentions, error) {
+	query := url.Values{}
+	if unread {
+		query.Set("unread", "true")
+	}
+	setInt(query, "limit", limit)
+
+	var mentions Mentions
+	if err := c.get(ctx, "/api/v1/me/mentions", query, &mentions); err != nil {
+		return nil, err
+	}
+	return &mentions, nil
+}
+
+// MarkMentionRead marks one of the client's user's mentions as read
+func (c *Client) MarkMentionRead(ctx context.Context, mentionID uint) (*Mention, error) {
+	var mention Mention
+	if err := c.post(ctx, "/api/v1/me/mentions/"+id(mentionID)+"/read", nil, &mention); err != nil {
+		return nil, err
+	}
+	return &mention, nil
+}
+
+// MarkAllMentionsRead marks all of the client's user's mentions as read and returns
+// how many were unread
+func (c *Client) MarkAllMentionsRead(ctx context.Context) (int64, error) {
+	var result struct {
+		Updated int64 `json:"updated"`
+	}
+	err := c.post(ctx, "/api/v1/me/mentions/read", nil, &result)
+	return result.Updated, err
+}
+
+// ListReminders returns a group's recurring reminders
+func (c *Client) ListReminders(ctx context.Context, groupID uint) ([]Reminder, error) {
+	var reminders []Reminder
+	err := c.get(ctx, "/api/v1/groups/"+id(groupID)+"/reminders", nil, &reminders)
+	return reminders, err
+}
+
+// CreateReminder creates a recurring reminder in a group
+func (c *Client) CreateReminder(ctx context.Context, groupID uint, req ReminderRequest) (*Reminder, error) {
+	var reminder Reminder
+	if err := c.post(ctx, "/api/v1/groups/"+id(groupID)+"/reminders", req, &reminder); err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// GetReminder returns a reminder
+func (c *Client) GetReminder(ctx context.Context, reminderID uint) (*Reminder, error) {
+	var reminder Reminder
+	if err := c.get(ctx, "/api/v1/reminders/"+id(reminderID), nil, &reminder); err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// UpdateReminder replaces a reminder
+func (c *Client) UpdateReminder(ctx context.Context, reminderID uint, req ReminderRequest) (*Reminder, error) {
+	var reminder Reminder
+	if err := c.put(ctx, "/api/v1/reminders/"+id(reminderID), req, &reminder); err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// DeleteReminder deletes a reminder
+func (c *Client) DeleteReminder(ctx context.Context, reminderID uint) error {
+	return c.delete(ctx, "/api/v1/reminders/"+id(reminderID), nil)
+}
+
+// ReminderRuns returns the history of a reminder's runs, newest first
+func (c *Client) ReminderRuns(ctx context.Context, reminderID uint, limit int) ([]ReminderRun, error) {
+	query := url.Values{}
+	setInt(query, "limit", limit)
+
+	var runs []ReminderRun
+	err := c.get(ctx, "/api/v1/reminders/"+id(reminderID)+"/runs", query, &runs)
+	return runs, err
+}
+
+// PostIncomingWebhook posts to a group through an incoming webhook's secret token,
+// the last path segment of its URL
+func (c *Client) PostIncomingWebhook(ctx context.Context, token string, payload IncomingWebhookPayload) (*Message, error) {
+	var message Message
+	if err := c.post(ctx, "/api/v1/hooks/"+url.PathEscape(token), payload, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
@@ -0,0 +1,337 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize is how many messages Messages fetches per request
+const DefaultPageSize = 100
+
+// MessageQuery filters and orders a message listing. Zero fields do not filter.
+type MessageQuery struct {
+	GroupID  uint
+	User     string
+	Since    *time.Time
+	Until    *time.Time
+	Contains string
+	HasLink  *bool
+	// Newest lists the newest messages first
+	Newest bool
+}
+
+// Page selects one page of a message listing: at most Limit messages posted after
+// AfterID and before BeforeID. A zero Limit returns the whole listing.
+type Page struct {
+	AfterID  uint
+	BeforeID uint
+	Limit    int
+}
+
+func (q MessageQuery) values(page Page) url.Values {
+	query := url.Values{}
+	setUint(query, "group_id", q.GroupID)
+	setString(query, "user", q.User)
+	setTime(query, "since", q.Since)
+	setTime(query, "until", q.Until)
+	setString(query, "contains", q.Contains)
+	if q.HasLink != nil {
+		query.Set("has_link", strconv.FormatBool(*q.HasLink))
+	}
+	if q.Newest {
+		query.Set("sort", "desc")
+	}
+	setUint(query, "after_id", page.AfterID)
+	setUint(query, "before_id", page.BeforeID)
+	setInt(query, "limit", page.Limit)
+	return query
+}
+
+// CreateMessageResult is the outcome of CreateMessage: exactly one of the fields is
+// set, depending on whether the message was posted, scheduled or ran a slash command
+type CreateMessageResult struct {
+	Message   *Message
+	Scheduled *ScheduledMessage
+	Command   *CommandResponse
+}
+
+// CreateMessage posts a message, schedules it when SendAt is in the future, or
+// runs it as a slash command when it starts with "/"
+func (c *Client) CreateMessage(ctx context.Context, req CreateMessageRequest) (*CreateMessageResult, error) {
+	var raw json.RawMessage
+	status, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/messages", body: req}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateMessageResult
+	switch status {
+	case http.StatusAccepted:
+		result.Scheduled = new(ScheduledMessage)
+		err = json.Unmarshal(raw, result.Scheduled)
+	case http.StatusOK:
+		result.Command = new(CommandResponse)
+		err = json.Unmarshal(raw, result.Command)
+	default:
+		result.Message = new(Message)
+		err = json.Unmarshal(raw, result.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
+
+// PostMessage posts content to a group as the client's user
+func (c *Client) PostMessage(ctx context.Context, groupID uint, content string) (*Message, error) {
+	result, err := c.CreateMessage(ctx, CreateMessageRequest{User: c.user, GroupID: groupID, Content: content})
+	if err != nil {
+		return nil, err
+	}
+	if result.Message == nil {
+		return nil, fmt.Errorf("message was not posted: %q ran as a slash command", content)
+	}
+	return result.Message, nil
+}
+
+// ListMessages returns one page of messages
+func (c *Client) ListMessages(ctx context.Context, q MessageQuery, page Page) ([]Message, error) {
+	var messages []Message
+	err := c.get(ctx, "/api/v1/messages", q.values(page), &messages)
+	return messages, err
+}
+
+// Messages iterates over all messages matching the query, fetching them pageSize at
+// a time (DefaultPageSize when zero). Iteration stops at the first error.
+func (c *Client) Messages(ctx context.Context, q MessageQuery, pageSize int) iter.Seq2[Message, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(Message, error) bool) {
+		page := Page{Limit: pageSize}
+		for {
+			messages, err := c.ListMessages(ctx, q, page)
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			for _, message := range messages {
+				if !yield(message, nil) {
+					return
+				}
+			}
+			if len(messages) < pageSize {
+				return
+			}
+
+			last := messages[len(messages)-1].ID
+			if q.Newest {
+				page.BeforeID = last
+			} else {
+				page.AfterID = last
+			}
+		}
+	}
+}
+
+// GetMessage returns a message with its reactions, attachments and link previews
+func (c *Client) GetMessage(ctx context.Context, messageID uint) (*Message, error) {
+	var message Message
+	if err := c.get(ctx, "/api/v1/messages/"+id(messageID), nil, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// UpdateMessage replaces a message's content
+func (c *Client) UpdateMessage(ctx context.Context, messageID uint, content string) (*Message, error) {
+	var message Message
+	if err := c.put(ctx, "/api/v1/messages/"+id(messageID), UpdateMessageRequest{Content: content}, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// DeleteMessage soft-deletes a message
+func (c *Client) DeleteMessage(ctx context.Context, messageID uint) error {
+	return c.delete(ctx, "/api/v1/messages/"+id(messageID), nil)
+}
+
+// AddReaction reacts to a message as the client's user
+func (c *Client) AddReaction(ctx context.Context, messageID uint, emoji string) (*Reaction, error) {
+	var reaction Reaction
+	err := c.post(ctx, "/api/v1/messages/"+id(messageID)+"/reactions", ReactionRequest{User: c.user, Emoji: emoji}, &reaction)
+	if err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// RemoveReaction removes the client's user's reaction from a message
+func (c *Client) RemoveReaction(ctx context.Context, messageID uint, emoji string) error {
+	return c.delete(ctx, "/api/v1/messages/"+id(messageID)+"/reactions", ReactionRequest{User: c.user, Emoji: emoji})
+}
+
+// Upload is a file to attach to a message
+type Upload struct {
+	Filename string
+	Data     []byte
+}
+
+// UploadAttachments attaches files to a message
+func (c *Client) UploadAttachments(ctx context.Context, messageID uint, files ...Upload) ([]Attachment, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range files {
+		part, err := form.CreateFormFile("file", file.Filename)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(file.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var attachments []Attachment
+	_, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/messages/" + id(messageID) + "/attachments",
+		body:        body.Bytes(),
+		contentType: form.FormDataContentType(),
+	}, &attachments)
+	return attachments, err
+}
+
+// DownloadAttachment opens the signed URL of an attachment or its thumbnail, as
+// found in Attachment. The caller closes the returned body.
+func (c *Client) DownloadAttachment(ctx context.Context, signedURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attachment URL: %w", err)
+	}
+	if !strings.HasPrefix(u.Path, "/api/v1/attachments/") {
+		return nil, fmt.Errorf("invalid attachment URL %q", signedURL)
+	}
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: u.Path, query: u.Query()})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CreatePoll posts a poll
+func (c *Client) CreatePoll(ctx context.Context, req CreatePollRequest) (*Message, error) {
+	var message Message
+	if err := c.post(ctx, "/api/v1/polls", req, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Vote votes on the poll of a message as the client's user, replacing any earlier vote
+func (c *Client) Vote(ctx context.Context, messageID uint, optionIDs ...uint) (*Message, error) {
+	var message Message
+	err := c.post(ctx, "/api/v1/messages/"+id(messageID)+"/poll/votes", PollVoteRequest{OptionIDs: optionIDs}, &message)
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// RetractVote withdraws the client's user's vote
+func (c *Client) RetractVote(ctx context.Context, messageID uint) (*Message, error) {
+	var message Message
+	if err := c.deleteReturning(ctx, "/api/v1/messages/"+id(messageID)+"/poll/votes", &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ClosePoll closes a poll; only its author may
+func (c *Client) ClosePoll(ctx context.Context, messageID uint) (*Message, error) {
+	var message Message
+	if err := c.post(ctx, "/api/v1/messages/"+id(messageID)+"/poll/close", nil, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// PinMessage pins a message to its group
+func (c *Client) PinMessage(ctx context.Context, messageID uint) (*Pin, error) {
+	var pin Pin
+	if err := c.post(ctx, "/api/v1/messages/"+id(messageID)+"/pin", nil, &pin); err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// UnpinMessage unpins a message
+func (c *Client) UnpinMessage(ctx context.Context, messageID uint) error {
+	return c.delete(ctx, "/api/v1/messages/"+id(messageID)+"/pin", nil)
+}
+
+// ScheduledQuery filters scheduled messages; Status defaults to pending
+type ScheduledQuery struct {
+	GroupID uint
+	User    string
+	Status  string
+}
+
+// ListScheduledMessages returns scheduled messages
+func (c *Client) ListScheduledMessages(ctx context.Context, q ScheduledQuery) ([]ScheduledMessage, error) {
+	query := url.Values{}
+	setUint(query, "group_id", q.GroupID)
+	setString(query, "user", q.User)
+	setString(query, "status", q.Status)
+
+	var scheduled []ScheduledMessage
+	err := c.get(ctx, "/api/v1/scheduled-messages", query, &scheduled)
+	return scheduled, err
+}
+
+// CancelScheduledMessage cancels a pending scheduled message
+func (c *Client) CancelScheduledMessage(ctx context.Context, scheduledID uint) (*ScheduledMessage, error) {
+	var scheduled ScheduledMessage
+	if err := c.deleteReturning(ctx, "/api/v1/scheduled-messages/"+id(scheduledID), &scheduled); err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+// SearchQuery is a full-text search with optional filters
+type SearchQuery struct {
+	Q       string
+	GroupID uint
+	User    string
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+}
+
+// Search searches messages, best matches first
+func (c *Client) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	query := url.Values{"q": {q.Q}}
+	setUint(query, "group_id", q.GroupID)
+	setString(query, "user", q.User)
+	setTime(query, "since", q.Since)
+	setTime(query, "until", q.Until)
+	setInt(query, "limit", q.Limit)
+
+	var results []SearchResult
+	err := c.get(ctx, "/api/v1/search", query, &results)
+	return results, err
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SendAlertmanagerNotification posts an Alertmanager webhook notification; set the
+// receiver's token with WithToken when ALERTMANAGER_TOKEN is configured
+func (c *Client) SendAlertmanagerNotification(ctx context.Context, notification AlertNotification) (*Alert, error) {
+	var alert Alert
+	if err := c.post(ctx, "/api/v1/alertmanager", notification, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// AlertQuery filters alerts; Status is firing or resolved
+type AlertQuery struct {
+	Status  string
+	GroupID uint
+	Limit   int
+}
+
+// ListAlerts returns alerts, most recently updated first
+func (c *Client) ListAlerts(ctx context.Context, q AlertQuery) ([]Alert, error) {
+	query := statusQuery(q.Status, q.Limit)
+	setUint(query, "group_id", q.GroupID)
+
+	var list []Alert
+	err := c.get(ctx, "/api/v1/alerts", query, &list)
+	return list, err
+}
+
+// AcknowledgeAlert acknowledges an alert as the client's user
+func (c *Client) AcknowledgeAlert(ctx context.Context, alertID uint) (*Alert, error) {
+	var alert Alert
+	if err := c.post(ctx, "/api/v1/alerts/"+id(alertID)+"/ack", nil, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ListIncidents returns incidents, newest first. status filters by a status, or
+// by "active" for every unresolved incident.
+func (c *Client) ListIncidents(ctx context.Context, status string, limit int) ([]Incident, error) {
+	var list []Incident
+	err := c.get(ctx, "/api/v1/incidents", statusQuery(status, limit), &list)
+	return list, err
+}
+
+// DeclareIncident declares an incident as the client's user, creating its group
+func (c *Client) DeclareIncident(ctx context.Context, req DeclareIncidentRequest) (*Incident, error) {
+	var incident Incident
+	if err := c.post(ctx, "/api/v1/incidents", req, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// GetIncident returns an incident
+func (c *Client) GetIncident(ctx context.Context, incidentID uint) (*Incident, error) {
+	var incident Incident
+	if err := c.get(ctx, "/api/v1/incidents/"+id(incidentID), nil, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// UpdateIncident changes an incident's status, severity or commander
+func (c *Client) UpdateIncident(ctx context.Context, incidentID uint, req UpdateIncidentRequest) (*Incident, error) {
+	var incident Incident
+	if err := c.put(ctx, "/api/v1/incidents/"+id(incidentID), req, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// IncidentTimeline returns an incident's timeline
+func (c *Client) IncidentTimeline(ctx context.Context, incidentID uint) (*IncidentTimeline, error) {
+	var timeline IncidentTimeline
+	if err := c.get(ctx, "/api/v1/incidents/"+id(incidentID)+"/timeline", nil, &timeline); err != nil {
+		return nil, err
+	}
+	return &timeline, nil
+}
+
+// IncidentTimelineMarkdown returns an incident's timeline rendered for a postmortem
+func (c *Client) IncidentTimelineMarkdown(ctx context.Context, incidentID uint) (string, error) {
+	resp, err := c.send(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/incidents/" + id(incidentID) + "/timeline",
+		query:  url.Values{"format": {"markdown"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	markdown, err := io.ReadAll(resp.Body)
+	return string(markdown), err
+}
+
+// ListOncallSchedules returns on-call schedules, of one group when groupID is set
+func (c *Client) ListOncallSchedules(ctx context.Context, groupID uint) ([]OncallSchedule, error) {
+	query := url.Values{}
+	setUint(query, "group_id", groupID)
+
+	var schedules []OncallSchedule
+	err := c.get(ctx, "/api/v1/oncall", query, &schedules)
+	return schedules, err
+}
+
+// GetOncallSchedule returns a schedule by name
+func (c *Client) GetOncallSchedule(ctx context.Context, name string) (*OncallSchedule, error) {
+	var schedule OncallSchedule
+	if err := c.get(ctx, "/api/v1/oncall/"+url.PathEscape(name), nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Oncall returns who is on call for a schedule at the given time, or now when at
+// is zero, and who is next
+func (c *Client) Oncall(ctx context.Context, name string, at time.Time) (*OncallNow, error) {
+	query := url.Values{}
+	if !at.IsZero() {
+		query.Set("at", at.Format(time.RFC3339))
+	}
+
+	var now OncallNow
+	if err := c.get(ctx, "/api/v1/oncall/"+url.PathEscape(name)+"/now", query, &now); err != nil {
+		return nil, err
+	}
+	return &now, nil
+}
+
+// ListOncallOverrides returns a schedule's current and upcoming overrides, and
+// ended ones too when past is set
+func (c *Client) ListOncallOverrides(ctx context.Context, name string, past bool) ([]OncallOverride, error) {
+	query := url.Values{}
+	if past {
+		query.Set("past", "true")
+	}
+
+	var overrides []OncallOverride
+	err := c.get(ctx, "/api/v1/oncall/"+url.PathEscape(name)+"/overrides", query, &overrides)
+	return overrides, err
+}
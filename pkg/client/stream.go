@@ -0,0 +1,192 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Event types sent on the event stream
+const (
+	EventConnected       = "connected"
+	EventResync          = "resync"
+	EventMessage         = "message"
+	EventMessageRestored = "message_restored"
+	EventMessageUpdated  = "message_updated"
+	EventReactionAdded   = "reaction_added"
+	EventReactionRemoved = "reaction_removed"
+	EventAttachmentAdded = "attachment_added"
+	EventLinkPreview     = "link_preview"
+	EventMention         = "mention"
+	EventReadPosition    = "read_position"
+	EventPresence        = "presence"
+	EventTyping          = "typing"
+	EventMessagePinned   = "message_pinned"
+	EventMessageUnpinned = "message_unpinned"
+	EventPollUpdated     = "poll_updated"
+	EventCommandResponse = "command_response"
+
+	eventPing = "ping"
+)
+
+// maxEventSize bounds one event of the stream
+const maxEventSize = 4 << 20
+
+// Event is one event of the stream. Message is set for message events; other
+// events carry Data, which Decode unmarshals.
+type Event struct {
+	// ID is the event's position in the stream; it is empty for events that cannot
+	// be resumed from, such as typing indicators
+	ID      string          `json:"-"`
+	Type    string          `json:"type"`
+	Message *Message        `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Decode unmarshals the event's data, e.g. into Presence for presence events
+func (e Event) Decode(v interface{}) error {
+	if len(e.Data) == 0 {
+		return fmt.Errorf("%s event has no data", e.Type)
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// StreamOptions selects the events of a subscription
+type StreamOptions struct {
+	// GroupID limits the stream to one group's events
+	GroupID uint
+	// LastEventID resumes a stream after the event with this ID
+	LastEventID string
+}
+
+// handlerError carries an error returned by a subscription's handler
+type handlerError struct {
+	err error
+}
+
+func (e handlerError) Error() string {
+	return e.err.Error()
+}
+
+// Subscribe passes the events of the stream to handle until ctx is done or handle
+// returns an error, which Subscribe then returns. Dropped connections are reopened
+// with backoff, resuming after the last event received. When the server cannot
+// resume the stream it sends an EventResync event: events were missed and
+// whatever the caller shows should be reloaded.
+func (c *Client) Subscribe(ctx context.Context, opts StreamOptions, handle func(Event) error) error {
+	query := url.Values{}
+	setUint(query, "group_id", opts.GroupID)
+	return c.subscribe(ctx, "/api/v1/messages/stream", query, opts.LastEventID, handle)
+}
+
+// SubscribeBot is Subscribe for the bot event stream, which carries the events of
+// the groups the calling bot has joined
+func (c *Client) SubscribeBot(ctx context.Context, lastEventID string, handle func(Event) error) error {
+	return c.subscribe(ctx, "/api/v1/bot/events", nil, lastEventID, handle)
+}
+
+func (c *Client) subscribe(ctx context.Context, path string, query url.Values, lastEventID string, handle func(Event) error) error {
+	for attempt := 0; ; attempt++ {
+		connected, err := c.stream(ctx, path, query, &lastEventID, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var stop handlerError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		// Refused streams stay refused, except when rate limited
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+
+		if connected {
+			attempt = 0
+		}
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stream reads one connection of the event stream until it ends, keeping
+// lastEventID current. connected reports whether the server accepted the stream.
+func (c *Client) stream(ctx context.Context, path string, query url.Values, lastEventID *string, handle func(Event) error) (connected bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(path, query), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		req.Header.Set("Last-Event-ID", *lastEventID)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode >= 400 {
+		return false, responseError(resp)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), maxEventSize)
+
+	var id string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if err := dispatch(data.String(), id, handle); err != nil {
+					return true, err
+				}
+				if id != "" {
+					*lastEventID = id
+				}
+			}
+			id = ""
+			data.Reset()
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, fmt.Errorf("event stream closed")
+}
+
+// dispatch decodes one event and passes it to handle, skipping keepalive pings
+func dispatch(data, id string, handle func(Event) error) error {
+	var event Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+	if event.Type == eventPing {
+		return nil
+	}
+	event.ID = id
+	if err := handle(event); err != nil {
+		return handlerError{err}
+	}
+	return nil
+}
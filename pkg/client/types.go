@@ -0,0 +1,154 @@
+package client
+
+import (
+	"sre-chat-api/internal/alerts"
+	"sre-chat-api/internal/jobs"
+	"sre-chat-api/internal/models"
+)
+
+// The API's request and response types, re-exported so that code outside this
+// module, which cannot import the server's internal packages, can name them.
+
+// Groups, messages and what hangs off them
+type (
+	Group             = models.Group
+	GroupSummary      = models.GroupSummary
+	Message           = models.Message
+	Reaction          = models.Reaction
+	ReactionSummary   = models.ReactionSummary
+	Attachment        = models.Attachment
+	LinkPreview       = models.LinkPreview
+	Mention           = models.Mention
+	Pin               = models.Pin
+	ReadPosition      = models.ReadPosition
+	Presence          = models.Presence
+	TypingEvent       = models.TypingEvent
+	SearchResult      = models.SearchResult
+	CommandResponse   = models.CommandResponse
+	ScheduledMessage  = models.ScheduledMessage
+	PollResults       = models.PollResults
+	PollOptionResults = models.PollOptionResults
+	Reminder          = models.Reminder
+	ReminderRun       = models.ReminderRun
+)
+
+// Request bodies
+type (
+	CreateMessageRequest         = models.CreateMessageRequest
+	UpdateMessageRequest         = models.UpdateMessageRequest
+	ReactionRequest              = models.ReactionRequest
+	ReadPositionRequest          = models.ReadPositionRequest
+	PresenceRequest              = models.PresenceRequest
+	CreatePollRequest            = models.CreatePollRequest
+	PollVoteRequest              = models.PollVoteRequest
+	ReminderRequest              = models.ReminderRequest
+	IncomingWebhookPayload       = models.IncomingWebhookPayload
+	IncomingAttachment           = models.IncomingAttachment
+	DeclareIncidentRequest       = models.DeclareIncidentRequest
+	UpdateIncidentRequest        = models.UpdateIncidentRequest
+	OncallScheduleRequest        = models.OncallScheduleRequest
+	OncallOverrideRequest        = models.OncallOverrideRequest
+	RetentionPolicyRequest       = models.RetentionPolicyRequest
+	CreateWebhookRequest         = models.CreateWebhookRequest
+	UpdateWebhookRequest         = models.UpdateWebhookRequest
+	CreateIncomingWebhookRequest = models.CreateIncomingWebhookRequest
+	CreateBotRequest             = models.CreateBotRequest
+	CreateBotTokenRequest        = models.CreateBotTokenRequest
+	BotMessageRequest            = models.BotMessageRequest
+)
+
+// Alerts, incidents and on-call
+type (
+	Alert            = models.Alert
+	Incident         = models.Incident
+	IncidentTimeline = models.IncidentTimeline
+	TimelineEntry    = models.TimelineEntry
+	OncallSchedule   = models.OncallSchedule
+	OncallOverride   = models.OncallOverride
+	OncallShift      = models.OncallShift
+	OncallNow        = models.OncallNow
+	// AlertNotification is the body Alertmanager posts to its webhook receivers
+	AlertNotification = alerts.Notification
+	// NotificationAlert is one alert of an AlertNotification
+	NotificationAlert = alerts.Alert
+)
+
+// Administration
+type (
+	RetentionPolicy  = models.RetentionPolicy
+	RetentionResult  = jobs.RetentionResult
+	RetentionMetrics = jobs.RetentionMetrics
+	Webhook          = models.Webhook
+	WebhookDelivery  = models.WebhookDelivery
+	IncomingWebhook  = models.IncomingWebhook
+	Bot              = models.Bot
+	BotToken         = models.BotToken
+	BotMembership    = models.BotMembership
+)
+
+// Presence statuses
+const (
+	PresenceOnline  = models.PresenceOnline
+	PresenceAway    = models.PresenceAway
+	PresenceOffline = models.PresenceOffline
+)
+
+// Mention kinds
+const (
+	MentionUser   = models.MentionUser
+	MentionHere   = models.MentionHere
+	MentionGroup  = models.MentionGroup
+	MentionOncall = models.MentionOncall
+)
+
+// Scheduled message statuses
+const (
+	ScheduledPending   = models.ScheduledPending
+	ScheduledSent      = models.ScheduledSent
+	ScheduledCancelled = models.ScheduledCancelled
+	ScheduledFailed    = models.ScheduledFailed
+)
+
+// Alert statuses
+const (
+	AlertFiring   = models.AlertFiring
+	AlertResolved = models.AlertResolved
+)
+
+// Incident severities, most severe first
+const (
+	SeverityOne   = models.SeverityOne
+	SeverityTwo   = models.SeverityTwo
+	SeverityThree = models.SeverityThree
+	SeverityFour  = models.SeverityFour
+)
+
+// Incident statuses
+const (
+	IncidentInvestigating = models.IncidentInvestigating
+	IncidentIdentified    = models.IncidentIdentified
+	IncidentMonitoring    = models.IncidentMonitoring
+	IncidentResolved      = models.IncidentResolved
+)
+
+// Incident timeline entry kinds
+const (
+	TimelineSystem = models.TimelineSystem
+	TimelinePinned = models.TimelinePinned
+	TimelineTagged = models.TimelineTagged
+)
+
+// Webhook delivery statuses
+const (
+	WebhookPending   = models.WebhookPending
+	WebhookDelivered = models.WebhookDelivered
+	WebhookDead      = models.WebhookDead
+)
+
+// Bot token scopes
+const (
+	BotScopeMessagesRead  = models.BotScopeMessagesRead
+	BotScopeMessagesWrite = models.BotScopeMessagesWrite
+	BotScopeEvents        = models.BotScopeEvents
+	BotScopeGroups        = models.BotScopeGroups
+)